@@ -22,6 +22,9 @@ func main() {
 		crawlChapters = flag.Bool("crawl-chapters", true, "Automatically crawl new chapters")
 		crawlPages    = flag.Bool("crawl-pages", false, "Automatically crawl new pages")
 		verbose       = flag.Bool("verbose", true, "Verbose logging")
+		baseURL       = flag.String("base-url", "https://api.shngm.io/v1", "Upstream API base URL")
+		rps           = flag.Float64("rps", crawler.DefaultRequestsPerSecond, "Max upstream requests per second per host")
+		driftInterval = flag.Duration("drift-interval", 6*time.Hour, "Upstream schema drift check interval (0 disables)")
 		help          = flag.Bool("help", false, "Show help")
 	)
 	flag.Parse()
@@ -38,6 +41,7 @@ func main() {
 	log.Printf("   Crawl Chapters: %v", *crawlChapters)
 	log.Printf("   Crawl Pages: %v", *crawlPages)
 	log.Printf("   Verbose: %v", *verbose)
+	log.Printf("   Drift Check Interval: %v", *driftInterval)
 	log.Println("")
 
 	// Load configuration
@@ -52,10 +56,11 @@ func main() {
 
 	// Initialize crawler
 	crawlerConfig := &crawler.Config{
-		BaseURL:   "https://api.shngm.io/v1",
+		BaseURL:   *baseURL,
 		BatchSize: 10,
 		DryRun:    false,
 		Verbose:   *verbose,
+		RequestsPerSecond: *rps,
 		Headers: map[string]string{
 			"User-Agent":       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/137.0.0.0 Safari/537.36",
 			"Origin":           "https://app.shinigami.asia",
@@ -71,13 +76,14 @@ func main() {
 
 	// Update configuration
 	config := &autoupdate.Config{
-		BaseURL:       "https://api.shngm.io/v1",
+		BaseURL:       *baseURL,
 		Interval:      *interval,
 		PageSize:      *pageSize,
 		MaxPages:      *maxPages,
 		Enabled:       true,
 		CrawlChapters: *crawlChapters,
 		CrawlPages:    *crawlPages,
+		DriftCheckInterval: *driftInterval,
 	}
 	autoUpdateService.UpdateConfig(config)
 
@@ -118,6 +124,9 @@ func showHelp() {
 	log.Println("  -crawl-chapters        Automatically crawl new chapters (default: true)")
 	log.Println("  -crawl-pages           Automatically crawl new pages (default: false)")
 	log.Println("  -verbose               Verbose logging (default: true)")
+	log.Println("  -base-url string       Upstream API base URL (default: https://api.shngm.io/v1)")
+	log.Println("  -rps float             Max upstream requests per second per host (default: 4)")
+	log.Println("  -drift-interval duration  Upstream schema drift check interval, 0 disables (default: 6h)")
 	log.Println("  -help                  Show this help")
 	log.Println("")
 	log.Println("Examples:")
@@ -135,6 +144,7 @@ func showHelp() {
 	log.Println("  ✅ Automatic detection of new chapters")
 	log.Println("  ✅ Configurable crawling of chapters and pages")
 	log.Println("  ✅ Rate limiting and error handling")
+	log.Println("  ✅ Periodic upstream schema drift checks")
 	log.Println("  ✅ Graceful shutdown with Ctrl+C")
 	log.Println("")
 	log.Println("API Endpoint:")
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+	"baca-komik-api/config"
+	"baca-komik-api/database"
+	"baca-komik-api/internal/crawler"
+	"baca-komik-api/internal/crawler/fakeapi"
+)
+
+// benchResult is the timing of one write mode
+type benchResult struct {
+	name           string
+	chapterInserts time.Duration // first chapter crawl, every chapter new
+	chapterUpdates time.Duration // second chapter crawl, every chapter known
+	pages          time.Duration
+}
+
+func (r benchResult) total() time.Duration {
+	return r.chapterInserts + r.chapterUpdates + r.pages
+}
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	var (
+		mangaCount = flag.Int("manga", 5, "Synthetic manga to crawl")
+		chapters   = flag.Int("chapters", 200, "Chapters per manga")
+		pages      = flag.Int("pages", 30, "Pages per chapter")
+		rps        = flag.Float64("rps", 10000, "Max requests per second to the fake upstream")
+		keep       = flag.Bool("keep", false, "Keep the synthetic rows in the database afterwards")
+	)
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fixtures := fakeapi.Synthetic(fakeapi.SyntheticSpec{
+		Manga:            *mangaCount,
+		ChaptersPerManga: *chapters,
+		PagesPerChapter:  *pages,
+	})
+	manga, chapterIDs, err := loadSynthetic(fixtures)
+	if err != nil {
+		log.Fatalf("Failed to read synthetic fixtures: %v", err)
+	}
+
+	server := fakeapi.New(fixtures)
+	defer server.Close()
+
+	db, err := database.Connect(config.Load())
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	log.Printf("🏁 Benchmarking crawler writes: %d manga x %d chapters x %d pages against %s",
+		*mangaCount, *chapters, *pages, server.BaseURL())
+
+	mangaIDs := make([]string, len(manga))
+	for i, m := range manga {
+		mangaIDs[i] = m.ID
+	}
+	defer func() {
+		if *keep {
+			return
+		}
+		if err := cleanup(context.WithoutCancel(ctx), db, mangaIDs, true); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}()
+
+	var results []benchResult
+	for _, rowByRow := range []bool{true, false} {
+		c := crawler.New(db, &crawler.Config{
+			BaseURL:           server.BaseURL(),
+			RequestsPerSecond: *rps,
+			RowByRowWrites:    rowByRow,
+		})
+
+		// Every run starts from the same manga and no chapters
+		if err := cleanup(ctx, db, mangaIDs, false); err != nil {
+			log.Fatalf("Failed to clean up: %v", err)
+		}
+		if err := c.SaveMangaList(ctx, manga); err != nil {
+			log.Fatalf("Failed to save synthetic manga: %v", err)
+		}
+
+		result := benchResult{name: "set-based"}
+		if rowByRow {
+			result.name = "row-by-row"
+		}
+		log.Printf("⏱️  Running %s writes...", result.name)
+
+		if result.chapterInserts, err = timeEach(mangaIDs, func(id string) error {
+			return c.CrawlChaptersForManga(ctx, id)
+		}); err != nil {
+			log.Fatalf("Chapter crawl failed: %v", err)
+		}
+		if result.chapterUpdates, err = timeEach(mangaIDs, func(id string) error {
+			return c.CrawlChaptersForManga(ctx, id)
+		}); err != nil {
+			log.Fatalf("Chapter re-crawl failed: %v", err)
+		}
+		if result.pages, err = timeEach(chapterIDs, func(id string) error {
+			return c.CrawlPagesForChapter(ctx, id)
+		}); err != nil {
+			log.Fatalf("Page crawl failed: %v", err)
+		}
+		results = append(results, result)
+	}
+
+	fmt.Println()
+	fmt.Printf("%-12s %16s %16s %16s %16s\n", "mode", "chapter inserts", "chapter updates", "pages", "total")
+	for _, r := range results {
+		fmt.Printf("%-12s %16s %16s %16s %16s\n", r.name,
+			r.chapterInserts.Round(time.Millisecond), r.chapterUpdates.Round(time.Millisecond),
+			r.pages.Round(time.Millisecond), r.total().Round(time.Millisecond))
+	}
+	rowByRow, setBased := results[0], results[1]
+	fmt.Printf("%-12s %15.1fx %15.1fx %15.1fx %15.1fx\n", "speedup",
+		speedup(rowByRow.chapterInserts, setBased.chapterInserts),
+		speedup(rowByRow.chapterUpdates, setBased.chapterUpdates),
+		speedup(rowByRow.pages, setBased.pages),
+		speedup(rowByRow.total(), setBased.total()))
+}
+
+// loadSynthetic reads the manga and every chapter ID back from generated fixtures
+func loadSynthetic(fixtures fs.FS) ([]crawler.ExternalManga, []string, error) {
+	var manga []crawler.ExternalManga
+	if err := readJSON(fixtures, "manga.json", &manga); err != nil {
+		return nil, nil, err
+	}
+
+	var chapterIDs []string
+	for _, m := range manga {
+		var chapters []crawler.ExternalChapter
+		if err := readJSON(fixtures, "chapters/"+m.ID+".json", &chapters); err != nil {
+			return nil, nil, err
+		}
+		for _, chapter := range chapters {
+			chapterIDs = append(chapterIDs, chapter.ID)
+		}
+	}
+	return manga, chapterIDs, nil
+}
+
+func readJSON(fixtures fs.FS, name string, v interface{}) error {
+	data, err := fs.ReadFile(fixtures, name)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// cleanup deletes the chapters and pages of the synthetic manga, and the
+// manga themselves (with any duplicate reviews they raised and their archived
+// payloads) if withManga is set
+func cleanup(ctx context.Context, db *database.DB, mangaIDs []string, withManga bool) error {
+	statements := []string{
+		`DELETE FROM "trChapter" WHERE id_chapter IN (
+			SELECT c.id FROM "mChapter" c JOIN "mKomik" k ON k.id = c.id_komik
+			WHERE k.external_id = ANY($1)
+		)`,
+		`DELETE FROM "mChapter" WHERE id_komik IN (SELECT id FROM "mKomik" WHERE external_id = ANY($1))`,
+	}
+	if withManga {
+		statements = append(statements,
+			`DELETE FROM "mKomikDuplicate" WHERE external_id = ANY($1)`,
+			`DELETE FROM "mRawPayload" WHERE external_id = ANY($1) OR payload->>'manga_id' = ANY($1)`,
+			`DELETE FROM "mKomik" WHERE external_id = ANY($1)`,
+		)
+	}
+
+	for _, statement := range statements {
+		if _, err := db.Pool.Exec(ctx, statement, mangaIDs); err != nil {
+			return fmt.Errorf("failed to delete synthetic rows: %w", err)
+		}
+	}
+	return nil
+}
+
+// timeEach runs fn for every ID and returns how long it took in total
+func timeEach(ids []string, fn func(id string) error) (time.Duration, error) {
+	start := time.Now()
+	for _, id := range ids {
+		if err := fn(id); err != nil {
+			return 0, fmt.Errorf("%s: %w", id, err)
+		}
+	}
+	return time.Since(start), nil
+}
+
+func speedup(before, after time.Duration) float64 {
+	if after <= 0 {
+		return 0
+	}
+	return float64(before) / float64(after)
+}
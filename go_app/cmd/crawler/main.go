@@ -1,14 +1,21 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	"baca-komik-api/config"
 	"baca-komik-api/database"
+	"baca-komik-api/internal/autoupdate"
 	"baca-komik-api/internal/crawler"
 )
 
@@ -20,18 +27,51 @@ func main() {
 
 	// Define command line flags
 	var (
-		mode      = flag.String("mode", "", "Crawling mode: genres, formats, types, authors, artists, manga, chapters, pages, resume, status")
+		mode      = flag.String("mode", "", "Crawling mode: genres, formats, types, authors, artists, manga, manga_incremental, chapters, pages, reprocess, reconcile, drift, resume, status")
 		startPage = flag.Int("start-page", 1, "Start page for pagination")
-		endPage   = flag.Int("end-page", 1, "End page for pagination")
+		endPage   = flag.Int("end-page", 1, "End page for pagination (default -1 for manga_incremental)")
 		batchSize = flag.Int("batch-size", 10, "Batch size for processing")
-		mangaID   = flag.String("manga-id", "", "Specific manga ID to crawl (for chapters/pages)")
+		mangaID   = flag.String("manga-id", "", "Specific manga ID to crawl (for chapters/pages/reprocess/reconcile)")
 		dryRun    = flag.Bool("dry-run", false, "Run without saving to database")
 		verbose   = flag.Bool("verbose", false, "Enable verbose logging")
-		clearCheckpoint = flag.Bool("clear-checkpoint", false, "Clear existing checkpoint")
+		clearCheckpoint = flag.Bool("clear-checkpoint", false, "Clear existing checkpoint (only --job-id's if set)")
+		enqueue   = flag.Bool("enqueue", false, "Queue the job for a worker instead of running it here")
+		workerConcurrency = flag.Int("worker-concurrency", 1, "Worker mode: jobs run at the same time")
+		jobID     = flag.String("job-id", "", "Job ID to checkpoint under (default cli_<mode>_<timestamp>); for resume/status, the job to load (default latest)")
+		baseURL   = flag.String("base-url", "https://api.shngm.io/v1", "Upstream API base URL (e.g. a cmd/fake-upstream instance)")
+		recordDir = flag.String("record", "", "Archive every raw upstream response to this directory")
+		replayDir = flag.String("replay", "", "Serve upstream responses from a recorded archive instead of the network")
+		maxAttempts = flag.Int("max-attempts", 0, "Attempts per upstream request before giving up (default 4)")
+		rps       = flag.Float64("rps", crawler.DefaultRequestsPerSecond, "Max upstream requests per second per host (slows down automatically on 429/503)")
+		newChaptersOnly = flag.Bool("new-chapters-only", false, "chapters/pipeline: stop each chapter list at the first page of known chapters")
+		overlap   = flag.Duration("overlap", crawler.DefaultIncrementalOverlap, "manga_incremental: keep paginating this far past the high-water mark")
+
+		mangaWorkers   = flag.Int("manga-workers", 0, "Pipeline: concurrent manga list pages (default 1)")
+		chapterWorkers = flag.Int("chapter-workers", 0, "Pipeline: concurrent chapter list workers (default 2)")
+		pageWorkers    = flag.Int("page-workers", 0, "Pipeline: concurrent chapter detail workers (default 4)")
+		chapterQueue   = flag.Int("chapter-queue", 0, "Pipeline: max manga waiting for the chapter stage (default 100)")
+		pageQueue      = flag.Int("page-queue", 0, "Pipeline: max chapters waiting for the page stage (default 500)")
+
+		listFailures   = flag.Bool("list-failures", false, "List dead-lettered failures (filter with --failure-status, --failure-entity)")
+		retryFailures  = flag.String("retry-failures", "", "Retry dead-lettered failures: comma-separated IDs, or \"all\" pending ones")
+		ignoreFailures = flag.String("ignore-failures", "", "Mark dead-lettered failures ignored: comma-separated IDs")
+		failureEntity  = flag.String("failure-entity", "", "Failure commands: only manga_page, manga or chapter failures")
+		failureStatus  = flag.String("failure-status", crawler.FailurePending, "--list-failures: pending, ignored, resolved or all")
 	)
 	flag.Parse()
 
-	if *mode == "" {
+	// manga_incremental stops on its own at already-seen updates; a default
+	// end page would stop it early and keep the high-water mark from moving
+	if *mode == "manga_incremental" {
+		endPageSet := false
+		flag.Visit(func(f *flag.Flag) { endPageSet = endPageSet || f.Name == "end-page" })
+		if !endPageSet {
+			*endPage = -1
+		}
+	}
+
+	failureCommand := *listFailures || *retryFailures != "" || *ignoreFailures != ""
+	if *mode == "" && !failureCommand {
 		fmt.Println("Usage: crawler --mode=<mode> [options]")
 		fmt.Println("\nAvailable modes:")
 		fmt.Println("  genres    - Crawl all genres")
@@ -40,25 +80,56 @@ func main() {
 		fmt.Println("  authors   - Crawl all authors")
 		fmt.Println("  artists   - Crawl all artists")
 		fmt.Println("  manga     - Crawl manga list")
+		fmt.Println("  manga_incremental - Crawl manga updated since the last run's high-water mark")
 		fmt.Println("  chapters  - Crawl chapters for manga")
 		fmt.Println("  pages     - Crawl pages for chapters")
+		fmt.Println("  pipeline  - Crawl manga, chapters and pages concurrently as a pipeline")
 		fmt.Println("  all       - Crawl everything (master data first)")
 		fmt.Println("  auto      - Auto crawl all master data (full pagination)")
-		fmt.Println("  resume    - Resume from last checkpoint")
+		fmt.Println("  reprocess - Rebuild manga, chapters and pages from archived raw payloads (no network)")
+		fmt.Println("  reconcile - Mark manga and chapters no longer listed upstream as removed")
+		fmt.Println("  drift     - Check sample upstream responses against our structs (exits 1 on drift)")
+		fmt.Println("  resume    - Resume from last checkpoint (or --job-id's)")
 		fmt.Println("  status    - Show current crawling status")
+		fmt.Println("  worker    - Run queued jobs (from the API or --enqueue) until stopped")
+		fmt.Println("  jobs      - List recent jobs")
 		fmt.Println("\nExamples:")
 		fmt.Println("  crawler --mode=genres")
 		fmt.Println("  crawler --mode=manga --start-page=1 --end-page=10 --batch-size=20")
 		fmt.Println("  crawler --mode=manga --start-page=1 --end-page=-1  # Crawl ALL pages")
+		fmt.Println("  crawler --mode=manga_incremental  # Daily sync: stop at already-seen updates")
 		fmt.Println("  crawler --mode=chapters --manga-id=all --batch-size=5")
+		fmt.Println("  crawler --mode=chapters --manga-id=all --new-chapters-only  # Only pick up new chapters")
 		fmt.Println("  crawler --mode=auto --dry-run  # Auto crawl all master data")
+		fmt.Println("  crawler --mode=pipeline --end-page=-1 --chapter-workers=4 --page-workers=8")
 		fmt.Println("  crawler --mode=all --dry-run")
 		fmt.Println("  crawler --mode=resume  # Resume interrupted crawling")
+		fmt.Println("  crawler --mode=resume --job-id=cli_manga_1718000000  # Resume a specific job")
 		fmt.Println("  crawler --mode=status  # Check crawling progress")
 		fmt.Println("  crawler --clear-checkpoint  # Clear saved progress")
+		fmt.Println("  crawler --mode=pipeline --end-page=-1 --enqueue  # Queue for a worker")
+		fmt.Println("  crawler --mode=worker --worker-concurrency=2  # Drain the shared job queue")
+		fmt.Println("  crawler --mode=manga --base-url=http://127.0.0.1:8089/v1  # Crawl a local fake upstream")
+		fmt.Println("  crawler --mode=manga --end-page=5 --record=./archive/2025-06-09  # Archive raw responses")
+		fmt.Println("  crawler --mode=manga --end-page=5 --replay=./archive/2025-06-09  # Re-run ingestion offline")
+		fmt.Println("  crawler --mode=reprocess --manga-id=<id>  # Re-derive one manga from its archived payloads")
+		fmt.Println("  crawler --mode=reconcile --manga-id=all --dry-run  # Show what the upstream took down")
+		fmt.Println("  crawler --mode=drift --base-url=http://127.0.0.1:8089/v1  # Check a fake upstream for schema drift")
+		fmt.Println("  crawler --list-failures --failure-entity=chapter  # Show pending dead-lettered chapters")
+		fmt.Println("  crawler --retry-failures=12,15  # Retry specific failures (--retry-failures=all for every pending one)")
+		fmt.Println("  crawler --ignore-failures=7  # Stop retrying a failure")
 		os.Exit(1)
 	}
 
+	if *recordDir != "" && *replayDir != "" {
+		log.Fatal("--record and --replay cannot be used together")
+	}
+	if *replayDir != "" {
+		if info, err := os.Stat(*replayDir); err != nil || !info.IsDir() {
+			log.Fatalf("Replay archive not found: %s", *replayDir)
+		}
+	}
+
 	// Initialize configuration
 	cfg := config.Load()
 
@@ -71,10 +142,22 @@ func main() {
 
 	// Initialize crawler
 	crawlerConfig := &crawler.Config{
-		BaseURL:   "https://api.shngm.io/v1",
+		BaseURL:   *baseURL,
 		BatchSize: *batchSize,
 		DryRun:    *dryRun,
 		Verbose:   *verbose,
+		RecordDir: *recordDir,
+		ReplayDir: *replayDir,
+		RequestsPerSecond: *rps,
+		IncrementalOverlap: *overlap,
+		Retry:             crawler.RetryPolicy{MaxAttempts: *maxAttempts},
+		Pipeline: crawler.PipelineConfig{
+			MangaWorkers:   *mangaWorkers,
+			ChapterWorkers: *chapterWorkers,
+			PageWorkers:    *pageWorkers,
+			ChapterQueue:   *chapterQueue,
+			PageQueue:      *pageQueue,
+		},
 		Headers: map[string]string{
 			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/137.0.0.0 Safari/537.36",
 			"Origin":     "https://app.shinigami.asia",
@@ -88,65 +171,158 @@ func main() {
 
 	// Handle checkpoint operations
 	if *clearCheckpoint {
-		if err := c.ClearCheckpoint(); err != nil {
+		if err := c.ClearCheckpoint(context.Background(), *jobID); err != nil {
 			log.Fatalf("Failed to clear checkpoint: %v", err)
 		}
 		log.Println("Checkpoint cleared successfully!")
 		return
 	}
 
+	if *failureEntity != "" && !crawler.ValidEntity(*failureEntity) {
+		log.Fatalf("Unknown --failure-entity: %s", *failureEntity)
+	}
+
+	// Cancel the crawl cleanly on Ctrl+C / SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	worker := crawler.NewWorker(c, crawler.WorkerConfig{Concurrency: *workerConcurrency})
+
+	// Handle dead-letter operations
+	if *listFailures {
+		status := *failureStatus
+		if status == "all" {
+			status = ""
+		}
+		failures, total, err := c.ListDeadLetters(ctx, crawler.DeadLetterFilter{
+			Status: status,
+			Entity: *failureEntity,
+			Limit:  100,
+		})
+		if err != nil {
+			log.Fatalf("Failed to list failures: %v", err)
+		}
+		for _, f := range failures {
+			fmt.Printf("%-6d %-10s %-40s %-8s attempts=%d last=%s [%s] %s\n",
+				f.ID, f.Entity, f.ExternalID, f.Status, f.Attempts,
+				f.LastAttemptAt.Format(time.RFC3339), f.Reason, f.Error)
+		}
+		fmt.Printf("%d of %d failure(s) shown\n", len(failures), total)
+		return
+	}
+	if *ignoreFailures != "" {
+		ids, err := parseIDs(*ignoreFailures)
+		if err != nil {
+			log.Fatalf("Invalid --ignore-failures: %v", err)
+		}
+		n, err := c.IgnoreDeadLetters(ctx, ids)
+		if err != nil {
+			log.Fatalf("Failed to ignore failures: %v", err)
+		}
+		log.Printf("%d failure(s) marked ignored", n)
+		return
+	}
+	if *retryFailures != "" {
+		params := crawler.JobParams{
+			Mode:          "retry_failures",
+			DryRun:        *dryRun,
+			Verbose:       verbose,
+			BaseURL:       *baseURL,
+			FailureEntity: *failureEntity,
+		}
+		if *retryFailures != "all" {
+			ids, err := parseIDs(*retryFailures)
+			if err != nil {
+				log.Fatalf("Invalid --retry-failures: %v", err)
+			}
+			params.FailureIDs = ids
+		}
+		if *jobID == "" {
+			*jobID = crawler.NewJobID("cli", params.Mode)
+		}
+		if err := worker.RunNow(ctx, *jobID, params); err != nil {
+			log.Fatalf("Failed to retry failures: %v", err)
+		}
+		log.Println("Retry completed!")
+		return
+	}
+
 	// Execute crawling based on mode
 	switch *mode {
-	case "genres":
-		if err := c.CrawlGenres(); err != nil {
-			log.Fatalf("Failed to crawl genres: %v", err)
-		}
-	case "formats":
-		if err := c.CrawlFormats(); err != nil {
-			log.Fatalf("Failed to crawl formats: %v", err)
-		}
-	case "types":
-		if err := c.CrawlTypes(); err != nil {
-			log.Fatalf("Failed to crawl types: %v", err)
-		}
-	case "authors":
-		if err := c.CrawlAuthors(); err != nil {
-			log.Fatalf("Failed to crawl authors: %v", err)
-		}
-	case "artists":
-		if err := c.CrawlArtists(); err != nil {
-			log.Fatalf("Failed to crawl artists: %v", err)
-		}
-	case "manga":
-		if err := c.CrawlManga(*startPage, *endPage); err != nil {
-			log.Fatalf("Failed to crawl manga: %v", err)
-		}
-	case "chapters":
-		if *mangaID == "all" {
-			if err := c.CrawlAllChapters(); err != nil {
-				log.Fatalf("Failed to crawl all chapters: %v", err)
+	case "genres", "formats", "types", "authors", "artists", "manga", "manga_incremental", "chapters", "pages", "pipeline", "all", "auto", "reprocess", "reconcile":
+		if *mode == "chapters" && *mangaID == "" {
+			log.Fatal("Please specify --manga-id=<id> or --manga-id=all")
+		}
+
+		params := crawler.JobParams{
+			Mode:      *mode,
+			StartPage: *startPage,
+			EndPage:   *endPage,
+			BatchSize: *batchSize,
+			MangaID:   *mangaID,
+			DryRun:    *dryRun,
+			Verbose:   verbose,
+			BaseURL:   *baseURL,
+
+			NewChaptersOnly: *newChaptersOnly,
+		}
+		if *jobID == "" {
+			*jobID = crawler.NewJobID("cli", *mode)
+		}
+
+		// Leave the job to whichever worker claims it (API server or --mode=worker)
+		if *enqueue {
+			job, err := c.Jobs().Enqueue(ctx, *jobID, params)
+			if err != nil {
+				log.Fatalf("Failed to queue job: %v", err)
+			}
+			log.Printf("Job %s queued", job.ID)
+			return
+		}
+
+		// Run here, registered in the shared job table so the API sees it
+		log.Printf("Job ID: %s (resume with --mode=resume --job-id=%s)", *jobID, *jobID)
+		if err := worker.RunNow(ctx, *jobID, params); err != nil {
+			log.Fatalf("Failed to crawl %s: %v", *mode, err)
+		}
+	case "worker":
+		worker.Run(ctx)
+		return
+	case "drift":
+		report, err := c.CheckSchemaDrift(ctx, append(crawler.DefaultDriftTargets(), autoupdate.DriftTargets()...))
+		if err != nil {
+			log.Fatalf("Failed to check schema drift: %v", err)
+		}
+		for _, result := range report.Results {
+			switch {
+			case result.Error != "":
+				fmt.Printf("%-26s skipped: %s\n", result.Target, result.Error)
+			case len(result.Changes) == 0:
+				fmt.Printf("%-26s ok\n", result.Target)
+			default:
+				fmt.Printf("%-26s %d change(s)\n", result.Target, len(result.Changes))
 			}
-		} else if *mangaID != "" {
-			if err := c.CrawlChaptersForManga(*mangaID); err != nil {
-				log.Fatalf("Failed to crawl chapters for manga %s: %v", *mangaID, err)
+			for _, change := range result.Changes {
+				fmt.Printf("    %-8s %-40s expected=%s observed=%s\n", change.Change, change.Path, change.Expected, change.Observed)
 			}
-		} else {
-			log.Fatal("Please specify --manga-id=<id> or --manga-id=all")
 		}
-	case "pages":
-		if err := c.CrawlAllPages(); err != nil {
-			log.Fatalf("Failed to crawl pages: %v", err)
+		if report.Drifted {
+			os.Exit(1)
 		}
-	case "all":
-		if err := c.CrawlAll(); err != nil {
-			log.Fatalf("Failed to crawl all data: %v", err)
+		return
+	case "jobs":
+		jobs, err := c.Jobs().List(ctx, "", 20)
+		if err != nil {
+			log.Fatalf("Failed to list jobs: %v", err)
 		}
-	case "auto":
-		if err := c.CrawlAllMasterData(); err != nil {
-			log.Fatalf("Failed to auto crawl master data: %v", err)
+		for _, job := range jobs {
+			fmt.Printf("%-40s %-10s %-10s processed=%d succeeded=%d failed=%d quarantined=%d created=%s %s\n",
+				job.ID, job.Mode, job.Status, job.Processed, job.Succeeded, job.Failed, job.Quarantined,
+				job.CreatedAt.Format(time.RFC3339), job.Error)
 		}
+		return
 	case "resume":
-		checkpoint, err := c.LoadCheckpoint()
+		checkpoint, err := c.LoadCheckpoint(ctx, *jobID)
 		if err != nil {
 			log.Fatalf("Failed to load checkpoint: %v", err)
 		}
@@ -154,11 +330,24 @@ func main() {
 			log.Println("No checkpoint found. Nothing to resume.")
 			return
 		}
-		log.Printf("Resuming %s crawling from page %d...", checkpoint.Phase, checkpoint.CurrentPage)
-		// TODO: Implement resume logic based on checkpoint.Phase
-		log.Println("Resume functionality will be implemented in the next update")
+		if checkpoint.CompletedAt != nil {
+			log.Printf("Job %s already completed. Nothing to resume.", checkpoint.JobID)
+			return
+		}
+		log.Printf("Resuming %s crawling of job %s from page %d...", checkpoint.Phase, checkpoint.JobID, checkpoint.CurrentPage)
+		// Keep the job's original options
+		params := crawler.JobParams{Mode: checkpoint.Phase, EndPage: checkpoint.EndPage}
+		if job, err := c.Jobs().Get(ctx, checkpoint.JobID); err != nil {
+			log.Fatalf("Failed to load job: %v", err)
+		} else if job != nil {
+			params = job.Params
+		}
+		params.Resume = true
+		if err := worker.RunNow(ctx, checkpoint.JobID, params); err != nil {
+			log.Fatalf("Failed to resume crawling: %v", err)
+		}
 	case "status":
-		checkpoint, err := c.LoadCheckpoint()
+		checkpoint, err := c.LoadCheckpoint(ctx, *jobID)
 		if err != nil {
 			log.Fatalf("Failed to load checkpoint: %v", err)
 		}
@@ -173,3 +362,23 @@ func main() {
 
 	log.Println("Crawling completed successfully!")
 }
+
+// parseIDs parses a comma-separated list of dead letter IDs
+func parseIDs(list string) ([]int64, error) {
+	var ids []int64
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID %q", part)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no IDs given")
+	}
+	return ids, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"baca-komik-api/internal/crawler/fakeapi"
+)
+
+func main() {
+	var (
+		addr          = flag.String("addr", "127.0.0.1:8089", "Address to listen on")
+		fixturesDir   = flag.String("fixtures", "", "Fixture directory (default: bundled fixtures)")
+		pageSize      = flag.Int("page-size", 0, "Force page size for list endpoints (0 = honour page_size)")
+		hideTotalPage = flag.Bool("hide-total-page", false, "Omit total_page from list meta")
+		throttle      = flag.Int("throttle", 0, "Answer the first N requests with 429 Too Many Requests")
+		slow          = flag.Duration("slow", 0, "Delay every response by this duration")
+	)
+	flag.Parse()
+
+	var server *fakeapi.Server
+	if *fixturesDir != "" {
+		server = fakeapi.NewUnstarted(os.DirFS(*fixturesDir))
+	} else {
+		server = fakeapi.NewUnstarted(fakeapi.DefaultFixtures())
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", *addr, err)
+	}
+	server.Listener.Close()
+	server.Listener = listener
+
+	server.SetPageSize(*pageSize)
+	server.HideTotalPage(*hideTotalPage)
+	if *throttle > 0 {
+		server.Inject(fakeapi.Fault{Status: 429, RetryAfter: "1", Times: *throttle})
+	}
+	if *slow > 0 {
+		server.Inject(fakeapi.Fault{Delay: *slow})
+	}
+
+	server.Start()
+	defer server.Close()
+
+	log.Printf("🧪 Fake upstream API listening on %s", server.BaseURL())
+	log.Printf("   Point the crawler at it with --base-url=%s", server.BaseURL())
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	<-c
+	log.Println("🛑 Fake upstream API stopped")
+}
@@ -34,6 +34,12 @@ type Config struct {
 	CORSAllowedOrigins []string `mapstructure:"CORS_ALLOWED_ORIGINS"`
 	CORSAllowedMethods []string `mapstructure:"CORS_ALLOWED_METHODS"`
 	CORSAllowedHeaders []string `mapstructure:"CORS_ALLOWED_HEADERS"`
+
+	// Admin Configuration (crawler and auto-update endpoints)
+	AdminEmails []string `mapstructure:"ADMIN_EMAILS"`
+
+	// Crawler Configuration: upstream base URLs a crawl job may use besides the default
+	CrawlerBaseURLs []string `mapstructure:"CRAWLER_BASE_URLS"`
 }
 
 func Load() *Config {
@@ -80,6 +86,14 @@ func Load() *Config {
 		config.CORSAllowedHeaders = strings.Split(corsHeaders, ",")
 	}
 
+	// Parse comma-separated values for admins and crawler base URLs
+	if adminEmails := os.Getenv("ADMIN_EMAILS"); adminEmails != "" {
+		config.AdminEmails = strings.Split(adminEmails, ",")
+	}
+	if crawlerBaseURLs := os.Getenv("CRAWLER_BASE_URLS"); crawlerBaseURLs != "" {
+		config.CrawlerBaseURLs = strings.Split(crawlerBaseURLs, ",")
+	}
+
 	return &config
 }
 
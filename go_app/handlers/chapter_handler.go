@@ -64,8 +64,13 @@ func (h *ChapterHandler) GetCompleteChapterDetails(c *gin.Context) {
 		}
 	}
 
+	lowQuality, ok := pageQuality(c)
+	if !ok {
+		return
+	}
+
 	// Get complete chapter details from service
-	chapter, err := h.chapterService.GetCompleteChapterDetails(id, userIDStr)
+	chapter, err := h.chapterService.GetCompleteChapterDetails(id, userIDStr, lowQuality)
 	if err != nil {
 		if err.Error() == "chapter not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Chapter not found"})
@@ -89,8 +94,13 @@ func (h *ChapterHandler) GetChapterPages(c *gin.Context) {
 		return
 	}
 
+	lowQuality, ok := pageQuality(c)
+	if !ok {
+		return
+	}
+
 	// Get chapter pages from service
-	pages, err := h.chapterService.GetChapterPages(id)
+	pages, err := h.chapterService.GetChapterPages(id, lowQuality)
 	if err != nil {
 		if err.Error() == "chapter not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Chapter not found"})
@@ -104,6 +114,20 @@ func (h *ChapterHandler) GetChapterPages(c *gin.Context) {
 	c.JSON(http.StatusOK, pages)
 }
 
+// pageQuality reads ?quality=low|high (default high) and reports whether
+// low quality page URLs were asked for. An unknown quality is answered with 400.
+func pageQuality(c *gin.Context) (lowQuality bool, ok bool) {
+	switch quality := c.DefaultQuery("quality", "high"); quality {
+	case "high":
+		return false, true
+	case "low":
+		return true, true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quality (use low or high)"})
+		return false, false
+	}
+}
+
 // GetAdjacentChapters handles GET /api/chapters/:id/adjacent
 func (h *ChapterHandler) GetAdjacentChapters(c *gin.Context) {
 	id := c.Param("id")
@@ -17,7 +17,8 @@ type AutoUpdateService struct {
 	db       *database.DB
 	crawler  *crawler.Crawler
 	config   *Config
-	stopChan chan bool
+	client   *http.Client
+	cancel   context.CancelFunc
 	running  bool
 }
 
@@ -30,17 +31,18 @@ type Config struct {
 	Enabled      bool          `json:"enabled"`
 	CrawlChapters bool         `json:"crawl_chapters"`
 	CrawlPages   bool          `json:"crawl_pages"`
+	// DriftCheckInterval is how often upstream responses are checked for
+	// schema drift (0 disables the check)
+	DriftCheckInterval time.Duration `json:"drift_check_interval"`
 }
 
-// UpdateResponse from external API
+// UpdateResponse from external API: the is_update manga listing, the same
+// shape the crawler reads (crawler.MangaListResponse)
 type UpdateResponse struct {
-	RetCode int     `json:"retcode"`
-	Message string  `json:"message"`
-	Meta    APIMeta `json:"meta"`
-	Data    struct {
-		Data       []UpdatedManga `json:"data"`
-		Pagination Pagination     `json:"pagination"`
-	} `json:"data"`
+	RetCode int            `json:"retcode"`
+	Message string         `json:"message"`
+	Meta    APIMeta        `json:"meta"`
+	Data    []UpdatedManga `json:"data"`
 }
 
 type APIMeta struct {
@@ -53,30 +55,23 @@ type APIMeta struct {
 	TotalRecord *int   `json:"total_record"`
 }
 
-type Pagination struct {
-	TotalPage   int `json:"total_page"`
-	PageSize    int `json:"page_size"`
-	TotalRecord int `json:"total_record"`
-}
-
 type UpdatedManga struct {
-	ID                string    `json:"id"`
-	Title             string    `json:"title"`
-	AlternativeTitle  string    `json:"alternative_title"`
-	Description       string    `json:"description"`
-	Status            string    `json:"status"`
-	Country           string    `json:"country"`
-	ViewCount         int       `json:"view_count"`
-	VoteCount         int       `json:"vote_count"`
-	BookmarkCount     int       `json:"bookmark_count"`
-	CoverImageURL     string    `json:"cover_image_url"`
-	CreatedAt         time.Time `json:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at"`
-	Rank              int       `json:"rank"`
-	ReleaseYear       int       `json:"release_year"`
-	LatestChapterID   string    `json:"latest_chapter_id"`
-	LatestChapterNum  int       `json:"latest_chapter_number"`
-	LatestChapterDate time.Time `json:"latest_chapter_date"`
+	ID               string                    `json:"manga_id"`
+	Title            string                    `json:"title"`
+	AlternativeTitle *string                   `json:"alternative_title"`
+	Description      *string                   `json:"description"`
+	Status           int                       `json:"status"`
+	CountryID        string                    `json:"country_id"`
+	ViewCount        *int                      `json:"view_count"`
+	VoteCount        *int                      `json:"vote_count"`
+	BookmarkCount    *int                      `json:"bookmark_count"`
+	CoverImageURL    *string                   `json:"cover_image_url"`
+	CreatedAt        *time.Time                `json:"created_at"`
+	UpdatedAt        *time.Time                `json:"updated_at"`
+	Rank             *float64                  `json:"rank"`
+	ReleaseYear      *string                   `json:"release_year"`
+	Taxonomy         *crawler.ExternalTaxonomy `json:"taxonomy,omitempty"`
+	LatestChapterNum float64                   `json:"latest_chapter_number"` // 0 when not listed
 }
 
 // NewAutoUpdateService creates a new auto-update service
@@ -89,14 +84,16 @@ func NewAutoUpdateService(db *database.DB, crawler *crawler.Crawler) *AutoUpdate
 		Enabled:       true,
 		CrawlChapters: true,
 		CrawlPages:    false, // Pages can be crawled separately
+		DriftCheckInterval: 6 * time.Hour,
 	}
 
 	return &AutoUpdateService{
 		db:       db,
 		crawler:  crawler,
 		config:   config,
-		stopChan: make(chan bool),
 		running:  false,
+		// Share the crawler's rate limiter, retries and circuit breaker
+		client:   crawler.UpstreamClient(),
 	}
 }
 
@@ -112,8 +109,12 @@ func (s *AutoUpdateService) Start() error {
 	log.Printf("   Max Pages: %d", s.config.MaxPages)
 	log.Printf("   Crawl Chapters: %v", s.config.CrawlChapters)
 	log.Printf("   Crawl Pages: %v", s.config.CrawlPages)
+	log.Printf("   Drift Check Interval: %v", s.config.DriftCheckInterval)
 
-	go s.run()
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	go s.run(ctx)
 	return nil
 }
 
@@ -124,7 +125,7 @@ func (s *AutoUpdateService) Stop() {
 	}
 
 	log.Println("🛑 Stopping Auto-Update Service...")
-	s.stopChan <- true
+	s.cancel()
 	s.running = false
 }
 
@@ -133,6 +134,23 @@ func (s *AutoUpdateService) IsRunning() bool {
 	return s.running
 }
 
+// RateLimitState returns the upstream rate limiter state shared with the crawler
+func (s *AutoUpdateService) RateLimitState() []crawler.HostLimitState {
+	if s.crawler.Limiter() == nil {
+		return nil
+	}
+	return s.crawler.Limiter().State()
+}
+
+// BreakerState returns the upstream circuit breaker state shared with the crawler
+func (s *AutoUpdateService) BreakerState() *crawler.BreakerState {
+	if s.crawler.Breaker() == nil {
+		return nil
+	}
+	state := s.crawler.Breaker().State()
+	return &state
+}
+
 // GetConfig returns current configuration
 func (s *AutoUpdateService) GetConfig() *Config {
 	return s.config
@@ -144,23 +162,70 @@ func (s *AutoUpdateService) UpdateConfig(config *Config) {
 	log.Printf("🔧 Auto-Update Service config updated")
 }
 
+// RunOnce performs a single update check synchronously
+func (s *AutoUpdateService) RunOnce(ctx context.Context) {
+	s.checkForUpdates(ctx)
+}
+
+// DriftTargets returns the responses the auto-updater decodes, to check for
+// schema drift next to crawler.DefaultDriftTargets
+func DriftTargets() []crawler.DriftTarget {
+	return []crawler.DriftTarget{
+		{Name: "auto-updater manga list", Endpoint: crawler.EndpointMangaList, Expected: UpdateResponse{}},
+	}
+}
+
+// CheckSchemaDrift compares sample upstream responses against both the
+// crawler's and the auto-updater's structs
+func (s *AutoUpdateService) CheckSchemaDrift(ctx context.Context) (*crawler.DriftReport, error) {
+	return s.crawler.CheckSchemaDrift(ctx, append(crawler.DefaultDriftTargets(), DriftTargets()...))
+}
+
+// LatestDriftReport returns the last schema drift check, or nil if none ran yet
+func (s *AutoUpdateService) LatestDriftReport(ctx context.Context) (*crawler.DriftReport, error) {
+	return s.crawler.LatestDriftReport(ctx)
+}
+
+// checkSchemaDrift runs a drift check and logs its outcome
+func (s *AutoUpdateService) checkSchemaDrift(ctx context.Context) {
+	report, err := s.CheckSchemaDrift(ctx)
+	if err != nil {
+		log.Printf("❌ Schema drift check failed: %v", err)
+		return
+	}
+	if report.Drifted {
+		log.Printf("⚠️ Upstream schema drift detected, see GET /api/auto-update/drift")
+	}
+}
+
 // run is the main service loop
-func (s *AutoUpdateService) run() {
+func (s *AutoUpdateService) run(ctx context.Context) {
 	log.Println("✅ Auto-Update Service started")
 	
 	// Initial update check
-	s.checkForUpdates()
+	s.checkForUpdates(ctx)
 
 	ticker := time.NewTicker(s.config.Interval)
 	defer ticker.Stop()
 
+	// Drift checks run on their own, slower schedule
+	var driftTick <-chan time.Time
+	if s.config.DriftCheckInterval > 0 {
+		s.checkSchemaDrift(ctx)
+		driftTicker := time.NewTicker(s.config.DriftCheckInterval)
+		defer driftTicker.Stop()
+		driftTick = driftTicker.C
+	}
+
 	for {
 		select {
 		case <-ticker.C:
 			if s.config.Enabled {
-				s.checkForUpdates()
+				s.checkForUpdates(ctx)
 			}
-		case <-s.stopChan:
+		case <-driftTick:
+			s.checkSchemaDrift(ctx)
+		case <-ctx.Done():
 			log.Println("✅ Auto-Update Service stopped")
 			return
 		}
@@ -168,7 +233,7 @@ func (s *AutoUpdateService) run() {
 }
 
 // checkForUpdates checks for new manga and chapters
-func (s *AutoUpdateService) checkForUpdates() {
+func (s *AutoUpdateService) checkForUpdates(ctx context.Context) {
 	log.Printf("🔍 Checking for updates... (%s)", time.Now().Format("15:04:05"))
 	
 	startTime := time.Now()
@@ -182,20 +247,25 @@ func (s *AutoUpdateService) checkForUpdates() {
 	}()
 
 	for page := 1; page <= s.config.MaxPages; page++ {
-		updates, err := s.fetchUpdates(page)
+		if ctx.Err() != nil {
+			log.Printf("🛑 Update check cancelled")
+			return
+		}
+
+		updates, err := s.fetchUpdates(ctx, page)
 		if err != nil {
 			log.Printf("❌ Failed to fetch updates page %d: %v", page, err)
 			continue
 		}
 
-		if len(updates.Data.Data) == 0 {
+		if len(updates.Data) == 0 {
 			log.Printf("📄 No more updates on page %d, stopping", page)
 			break
 		}
 
-		for _, manga := range updates.Data.Data {
+		for _, manga := range updates.Data {
 			// Check if manga exists in database
-			exists, err := s.mangaExists(manga.ID)
+			exists, err := s.mangaExists(ctx, manga.ID)
 			if err != nil {
 				log.Printf("❌ Failed to check manga existence %s: %v", manga.ID, err)
 				continue
@@ -204,14 +274,14 @@ func (s *AutoUpdateService) checkForUpdates() {
 			if !exists {
 				// New manga found
 				log.Printf("🆕 New manga found: %s", manga.Title)
-				if err := s.crawlNewManga(manga); err != nil {
+				if err := s.crawlNewManga(ctx, manga); err != nil {
 					log.Printf("❌ Failed to crawl new manga %s: %v", manga.ID, err)
 				} else {
 					newManga++
 				}
 			} else {
 				// Check for new chapters
-				hasNewChapters, err := s.checkNewChapters(manga)
+				hasNewChapters, err := s.checkNewChapters(ctx, manga)
 				if err != nil {
 					log.Printf("❌ Failed to check new chapters for %s: %v", manga.ID, err)
 					continue
@@ -219,26 +289,33 @@ func (s *AutoUpdateService) checkForUpdates() {
 
 				if hasNewChapters {
 					log.Printf("📖 New chapters found for: %s", manga.Title)
-					if err := s.crawlNewChapters(manga.ID); err != nil {
+					if n, err := s.crawlNewChapters(ctx, manga.ID); err != nil {
 						log.Printf("❌ Failed to crawl new chapters for %s: %v", manga.ID, err)
 					} else {
-						newChapters++
+						newChapters += n
 					}
 				}
 			}
 		}
 
-		// Rate limiting between pages
-		time.Sleep(200 * time.Millisecond)
+		if updates.Meta.TotalPage != nil && page >= *updates.Meta.TotalPage {
+			log.Printf("📄 Reached last page (%d), stopping", page)
+			break
+		}
 	}
 }
 
 // fetchUpdates fetches updates from external API
-func (s *AutoUpdateService) fetchUpdates(page int) (*UpdateResponse, error) {
+func (s *AutoUpdateService) fetchUpdates(ctx context.Context, page int) (*UpdateResponse, error) {
 	url := fmt.Sprintf("%s/manga/list?type=&page=%d&page_size=%d&is_update=true&sort=latest&sort_order=desc",
 		s.config.BaseURL, page, s.config.PageSize)
 
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch updates: %w", err)
 	}
@@ -257,8 +334,7 @@ func (s *AutoUpdateService) fetchUpdates(page int) (*UpdateResponse, error) {
 }
 
 // mangaExists checks if manga exists in database
-func (s *AutoUpdateService) mangaExists(externalID string) (bool, error) {
-	ctx := context.Background()
+func (s *AutoUpdateService) mangaExists(ctx context.Context, externalID string) (bool, error) {
 	var count int
 
 	query := `SELECT COUNT(*) FROM "mKomik" WHERE external_id = $1`
@@ -270,10 +346,15 @@ func (s *AutoUpdateService) mangaExists(externalID string) (bool, error) {
 	return count > 0, nil
 }
 
-// checkNewChapters checks if manga has new chapters
-func (s *AutoUpdateService) checkNewChapters(manga UpdatedManga) (bool, error) {
-	ctx := context.Background()
-	var latestChapterNum int
+// checkNewChapters checks if manga has new chapters. Without a latest
+// chapter number in the listing it assumes so; CrawlNewChapters stops at the
+// first page of known chapters anyway.
+func (s *AutoUpdateService) checkNewChapters(ctx context.Context, manga UpdatedManga) (bool, error) {
+	if manga.LatestChapterNum == 0 {
+		return true, nil
+	}
+
+	var latestChapterNum float64
 
 	query := `
 		SELECT COALESCE(MAX(c.chapter_number), 0)
@@ -291,64 +372,37 @@ func (s *AutoUpdateService) checkNewChapters(manga UpdatedManga) (bool, error) {
 }
 
 // crawlNewManga crawls a new manga
-func (s *AutoUpdateService) crawlNewManga(manga UpdatedManga) error {
+func (s *AutoUpdateService) crawlNewManga(ctx context.Context, manga UpdatedManga) error {
 	log.Printf("🚀 Crawling new manga: %s", manga.Title)
 
 	// Convert to crawler format and save
-	// Helper function to convert string to *string
-	stringPtr := func(s string) *string {
-		if s == "" {
-			return nil
-		}
-		return &s
-	}
-
-	// Helper function to convert int to *int
-	intPtr := func(i int) *int {
-		return &i
-	}
-
-	// Convert status string to int
-	statusInt := 1 // Default to "On Going"
-	switch manga.Status {
-	case "On Going":
-		statusInt = 1
-	case "End":
-		statusInt = 2
-	case "Hiatus":
-		statusInt = 3
-	case "Break":
-		statusInt = 4
-	}
-
-	// Convert release year to string pointer
-	releaseYearStr := fmt.Sprintf("%d", manga.ReleaseYear)
-
 	mangaList := []crawler.ExternalManga{
 		{
 			ID:               manga.ID,
 			Title:            manga.Title,
-			AlternativeTitle: stringPtr(manga.AlternativeTitle),
-			Description:      stringPtr(manga.Description),
-			Status:           statusInt,
-			CountryID:        manga.Country,
-			ViewCount:        intPtr(manga.ViewCount),
-			VoteCount:        intPtr(manga.VoteCount),
-			BookmarkCount:    intPtr(manga.BookmarkCount),
-			CoverImageURL:    stringPtr(manga.CoverImageURL),
-			CreatedAt:        &manga.CreatedAt,
-			Rank:             nil, // Set to nil for now
-			ReleaseYear:      &releaseYearStr,
+			AlternativeTitle: manga.AlternativeTitle,
+			Description:      manga.Description,
+			Status:           manga.Status,
+			CountryID:        manga.CountryID,
+			ViewCount:        manga.ViewCount,
+			VoteCount:        manga.VoteCount,
+			BookmarkCount:    manga.BookmarkCount,
+			CoverImageURL:    manga.CoverImageURL,
+			CreatedAt:        manga.CreatedAt,
+			UpdatedAt:        manga.UpdatedAt,
+			Rank:             manga.Rank,
+			ReleaseYear:      manga.ReleaseYear,
+			Taxonomy:         manga.Taxonomy,
 		},
 	}
 
-	if err := s.crawler.SaveMangaList(mangaList); err != nil {
+	if err := s.crawler.SaveMangaList(ctx, mangaList); err != nil {
 		return fmt.Errorf("failed to save new manga: %w", err)
 	}
 
 	// If enabled, also crawl chapters
 	if s.config.CrawlChapters {
-		if err := s.crawler.CrawlChaptersForManga(manga.ID); err != nil {
+		if err := s.crawler.CrawlChaptersForManga(ctx, manga.ID); err != nil {
 			log.Printf("⚠️ Failed to crawl chapters for new manga %s: %v", manga.ID, err)
 		}
 	}
@@ -356,58 +410,35 @@ func (s *AutoUpdateService) crawlNewManga(manga UpdatedManga) error {
 	return nil
 }
 
-// crawlNewChapters crawls new chapters for existing manga
-func (s *AutoUpdateService) crawlNewChapters(mangaID string) error {
+// crawlNewChapters crawls the chapters of an existing manga down to the first
+// page of known ones, and the pages of the chapters it inserted if enabled.
+// Returns how many chapters were new.
+func (s *AutoUpdateService) crawlNewChapters(ctx context.Context, mangaID string) (int, error) {
 	log.Printf("📖 Crawling new chapters for manga: %s", mangaID)
 
-	if err := s.crawler.CrawlChaptersForManga(mangaID); err != nil {
-		return fmt.Errorf("failed to crawl new chapters: %w", err)
+	result, err := s.crawler.CrawlNewChapters(ctx, mangaID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to crawl new chapters: %w", err)
 	}
+	log.Printf("📖 %d new chapter(s) for manga %s (%d list page(s) fetched)", len(result.Inserted), mangaID, result.Pages)
 
-	// If enabled, also crawl pages for new chapters
+	// If enabled, also crawl pages for the new chapters
 	if s.config.CrawlPages {
-		// Get newly added chapters and crawl their pages
-		if err := s.crawlPagesForNewChapters(mangaID); err != nil {
+		if err := s.crawlPagesForChapters(ctx, result.Inserted); err != nil {
 			log.Printf("⚠️ Failed to crawl pages for new chapters %s: %v", mangaID, err)
 		}
 	}
 
-	return nil
+	return len(result.Inserted), nil
 }
 
-// crawlPagesForNewChapters crawls pages for newly added chapters
-func (s *AutoUpdateService) crawlPagesForNewChapters(mangaID string) error {
-	ctx := context.Background()
-
-	// Get chapters that don't have pages yet
-	query := `
-		SELECT c.external_id
-		FROM "mChapter" c
-		JOIN "mKomik" m ON c.id_komik = m.id
-		LEFT JOIN "trChapter" p ON c.id = p.id_chapter
-		WHERE m.external_id = $1
-		AND c.external_id IS NOT NULL
-		AND p.id_chapter IS NULL
-	`
-
-	rows, err := s.db.Pool.Query(ctx, query, mangaID)
-	if err != nil {
-		return err
-	}
-	defer rows.Close()
-
-	var chapterIDs []string
-	for rows.Next() {
-		var chapterID string
-		if err := rows.Scan(&chapterID); err != nil {
+// crawlPagesForChapters crawls pages for the given chapters
+func (s *AutoUpdateService) crawlPagesForChapters(ctx context.Context, chapterIDs []string) error {
+	for _, chapterID := range chapterIDs {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
-		chapterIDs = append(chapterIDs, chapterID)
-	}
-
-	// Crawl pages for each new chapter
-	for _, chapterID := range chapterIDs {
-		if err := s.crawler.CrawlPagesForChapter(chapterID); err != nil {
+		if err := s.crawler.CrawlPagesForChapter(ctx, chapterID); err != nil {
 			log.Printf("⚠️ Failed to crawl pages for chapter %s: %v", chapterID, err)
 		}
 	}
@@ -0,0 +1,92 @@
+package autoupdate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"baca-komik-api/internal/crawler"
+	"baca-komik-api/internal/crawler/crawltest"
+	"baca-komik-api/internal/crawler/fakeapi"
+)
+
+// fixtureMangaID is the fixture manga with 30 chapters, first in the listing
+const fixtureMangaID = "6513270e-269e-4d37-b2a7-4de452e6b438"
+
+func TestCheckForUpdatesFakeAPI(t *testing.T) {
+	db := crawltest.DB(t)
+	fixtures := fakeapi.DefaultFixtures()
+	mangaIDs := crawltest.MangaIDs(t, fixtures)
+	t.Cleanup(func() { crawltest.DeleteManga(t, db, mangaIDs) })
+
+	// newService starts from an empty catalog, with a fake upstream listing
+	// one manga per page
+	newService := func(t *testing.T) (*AutoUpdateService, *fakeapi.Server) {
+		crawltest.DeleteManga(t, db, mangaIDs)
+
+		srv := fakeapi.New(fixtures)
+		t.Cleanup(srv.Close)
+		srv.SetPageSize(1)
+
+		c := crawler.New(db, &crawler.Config{
+			BaseURL:           srv.BaseURL(),
+			RequestsPerSecond: 1000,
+			Retry:             crawler.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+		})
+		s := NewAutoUpdateService(db, c)
+		s.config.BaseURL = srv.BaseURL()
+		s.config.MaxPages = 10
+		s.config.CrawlPages = false
+		return s, srv
+	}
+	ctx := context.Background()
+
+	t.Run("paginates and crawls new manga with their chapters", func(t *testing.T) {
+		s, srv := newService(t)
+		s.checkForUpdates(ctx)
+
+		if got := srv.Hits("/manga/list"); got != 3 {
+			t.Errorf("manga list requests = %d, want 3", got)
+		}
+		for _, id := range mangaIDs {
+			if !crawltest.MangaExists(t, db, id) {
+				t.Errorf("manga %s was not saved", id)
+			}
+		}
+		if got := crawltest.CountChapters(t, db, fixtureMangaID); got != 30 {
+			t.Errorf("stored chapters = %d, want 30", got)
+		}
+
+		// Known manga only get their first chapter list page checked
+		before := srv.Hits("/chapter/")
+		s.checkForUpdates(ctx)
+		if got := srv.Hits("/chapter/") - before; got != len(mangaIDs) {
+			t.Errorf("chapter list requests on the second check = %d, want %d", got, len(mangaIDs))
+		}
+	})
+
+	t.Run("retries 429s", func(t *testing.T) {
+		s, srv := newService(t)
+		srv.Inject(fakeapi.Fault{PathPrefix: "/manga/list", Status: 429, Times: 2})
+		s.checkForUpdates(ctx)
+
+		for _, id := range mangaIDs {
+			if !crawltest.MangaExists(t, db, id) {
+				t.Errorf("manga %s was not saved", id)
+			}
+		}
+	})
+
+	t.Run("skips a page of malformed JSON", func(t *testing.T) {
+		s, srv := newService(t)
+		srv.Inject(fakeapi.Fault{PathPrefix: "/manga/list", Malformed: true, Times: 1})
+		s.checkForUpdates(ctx)
+
+		for _, id := range mangaIDs {
+			want := id != fixtureMangaID
+			if got := crawltest.MangaExists(t, db, id); got != want {
+				t.Errorf("manga %s saved = %v, want %v", id, got, want)
+			}
+		}
+	})
+}
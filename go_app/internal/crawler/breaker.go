@@ -0,0 +1,161 @@
+package crawler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+const (
+	// DefaultBreakerThreshold is how many consecutive failed attempts open the breaker
+	DefaultBreakerThreshold = 8
+	// DefaultBreakerCooldown is the first pause after the breaker opens
+	DefaultBreakerCooldown = 30 * time.Second
+	// maxBreakerCooldown caps the pause as repeated probes keep failing
+	maxBreakerCooldown = 10 * time.Minute
+)
+
+// CircuitBreaker pauses every request sharing it once the upstream looks down.
+//
+// After Threshold consecutive failed attempts (transport errors or 5xx) the
+// breaker opens and Wait blocks all callers for the cooldown. Then a single
+// probe request is let through: success closes the breaker, failure opens it
+// again with the cooldown doubled.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+
+	state     string
+	failures  int
+	current   time.Duration // cooldown for the next opening
+	openUntil time.Time
+	probing   bool
+	probeAt   time.Time
+	trips     int
+}
+
+// BreakerState is a snapshot of a circuit breaker, for status endpoints
+type BreakerState struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Trips               int        `json:"trips"`
+	OpenUntil           *time.Time `json:"open_until,omitempty"`
+}
+
+// NewCircuitBreaker creates a breaker (defaults for zero arguments)
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = DefaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerClosed,
+		current:   cooldown,
+	}
+}
+
+// Wait blocks while the breaker is open, or ctx is cancelled
+func (b *CircuitBreaker) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		var wait time.Duration
+		switch b.state {
+		case BreakerClosed:
+			b.mu.Unlock()
+			return nil
+		case BreakerOpen:
+			wait = time.Until(b.openUntil)
+			if wait <= 0 {
+				b.state = BreakerHalfOpen
+				b.probing = true
+				b.probeAt = time.Now()
+				b.mu.Unlock()
+				log.Printf("🔌 Circuit breaker half-open, probing upstream")
+				return nil
+			}
+		case BreakerHalfOpen:
+			// Also replace a probe that never reported back (e.g. its caller was cancelled)
+			if !b.probing || time.Since(b.probeAt) > b.cooldown {
+				b.probing = true
+				b.probeAt = time.Now()
+				b.mu.Unlock()
+				return nil
+			}
+			wait = time.Second
+		}
+		b.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// Record reports the outcome of one attempt
+func (b *CircuitBreaker) Record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		if b.state != BreakerClosed {
+			log.Printf("🔌 Circuit breaker closed, upstream is back")
+		}
+		b.state = BreakerClosed
+		b.failures = 0
+		b.current = b.cooldown
+		b.probing = false
+		return
+	}
+
+	b.failures++
+	switch {
+	case b.state == BreakerHalfOpen:
+		b.open()
+	case b.state == BreakerClosed && b.failures >= b.threshold:
+		b.open()
+	}
+}
+
+// open pauses requests for the current cooldown. Caller holds mu.
+func (b *CircuitBreaker) open() {
+	b.state = BreakerOpen
+	b.probing = false
+	b.openUntil = time.Now().Add(b.current)
+	b.trips++
+	log.Printf("🔌 Circuit breaker open after %d consecutive failures, pausing upstream requests for %v", b.failures, b.current)
+	b.current = min(b.current*2, maxBreakerCooldown)
+}
+
+// State returns a snapshot of the breaker
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := BreakerState{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		Trips:               b.trips,
+	}
+	if b.state == BreakerOpen {
+		openUntil := b.openUntil
+		state.OpenUntil = &openUntil
+	}
+	return state
+}
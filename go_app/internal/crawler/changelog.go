@@ -0,0 +1,119 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Change log entity types
+const (
+	ChangeKomik   = "komik"
+	ChangeChapter = "chapter"
+)
+
+// Fields whose changes are logged to "mChangeLog" when a crawl updates a row.
+// View, vote and bookmark counts and rank are left out: they change on nearly
+// every crawl.
+// removed_at is logged when a reconciliation marks a row removed upstream
+// and when a crawl sees it again.
+var (
+	trackedKomikFields = []string{
+		"title", "alternative_title", "description", "status", "cover_image_url",
+		"release_year", "removed_at",
+	}
+	trackedChapterFields = []string{
+		"chapter_number", "chapter_title", "release_date", "thumbnail_image_url", "removed_at",
+	}
+)
+
+// FieldChange is one logged change of a comic or chapter field
+type FieldChange struct {
+	ID        int64     `json:"id"`
+	Entity    string    `json:"entity"`
+	EntityID  string    `json:"entity_id"`
+	KomikID   string    `json:"komik_id"`
+	Field     string    `json:"field"`
+	OldValue  *string   `json:"old_value"`
+	NewValue  *string   `json:"new_value"`
+	JobID     string    `json:"job_id,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// ChangeFilter selects logged changes; empty fields match everything
+type ChangeFilter struct {
+	KomikID string
+	Entity  string
+	Field   string
+	Since   *time.Time
+	Limit   int
+	Offset  int
+}
+
+// changeReturning returns the RETURNING clause of an UPDATE that aliases the
+// table as cur and self-joins it as prev (the row before the update): the row
+// ID, its comic ID and the old and new values of fields as text arrays.
+// Used as a CTE named "updated" followed by insertChanges.
+func changeReturning(komikIDColumn string, fields []string) string {
+	oldValues := make([]string, len(fields))
+	newValues := make([]string, len(fields))
+	for i, field := range fields {
+		oldValues[i] = "prev." + field + "::text"
+		newValues[i] = "cur." + field + "::text"
+	}
+	return fmt.Sprintf("RETURNING cur.id, cur.%s AS komik_id, ARRAY[%s] AS old_values, ARRAY[%s] AS new_values",
+		komikIDColumn, strings.Join(oldValues, ", "), strings.Join(newValues, ", "))
+}
+
+// insertChanges returns the statement that logs every field of the "updated"
+// CTE (see changeReturning) whose value changed. jobParam is the placeholder
+// number of the job ID argument.
+func insertChanges(entity string, fields []string, jobParam int) string {
+	return fmt.Sprintf(`
+		INSERT INTO "mChangeLog" (entity_type, entity_id, komik_id, field, old_value, new_value, job_id)
+		SELECT '%s', u.id, u.komik_id, f.field, f.old_value, f.new_value, NULLIF($%d, '')
+		FROM updated u, unnest('{%s}'::text[], u.old_values, u.new_values) AS f(field, old_value, new_value)
+		WHERE f.old_value IS DISTINCT FROM f.new_value
+	`, entity, jobParam, strings.Join(fields, ","))
+}
+
+// ListChanges returns logged changes matching filter, newest first, and how
+// many match in total
+func (c *Crawler) ListChanges(ctx context.Context, filter ChangeFilter) ([]FieldChange, int, error) {
+	where := `
+		WHERE ($1 = '' OR komik_id = NULLIF($1, '')::uuid)
+		AND ($2 = '' OR entity_type = $2)
+		AND ($3 = '' OR field = $3)
+		AND ($4::timestamp IS NULL OR changed_at >= $4)
+	`
+	args := []interface{}{filter.KomikID, filter.Entity, filter.Field, filter.Since}
+
+	var total int
+	if err := c.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM "mChangeLog"`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count changes: %w", err)
+	}
+
+	rows, err := c.db.Pool.Query(ctx, `
+		SELECT id, entity_type, entity_id::text, komik_id::text, field, old_value, new_value,
+			COALESCE(job_id, ''), changed_at
+		FROM "mChangeLog"`+where+`
+		ORDER BY changed_at DESC, id DESC
+		LIMIT $5 OFFSET $6
+	`, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []FieldChange{}
+	for rows.Next() {
+		var ch FieldChange
+		if err := rows.Scan(&ch.ID, &ch.Entity, &ch.EntityID, &ch.KomikID, &ch.Field,
+			&ch.OldValue, &ch.NewValue, &ch.JobID, &ch.ChangedAt); err != nil {
+			return nil, 0, err
+		}
+		changes = append(changes, ch)
+	}
+	return changes, total, rows.Err()
+}
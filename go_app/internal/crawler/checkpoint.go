@@ -1,88 +1,244 @@
 package crawler
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
-	"os"
 	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
 // CrawlCheckpoint represents the current state of crawling
 type CrawlCheckpoint struct {
-	Phase           string    `json:"phase"`           // "manga", "chapters", "pages"
-	CurrentPage     int       `json:"current_page"`    // Current page being processed
-	TotalProcessed  int       `json:"total_processed"` // Total items processed so far
-	LastMangaID     string    `json:"last_manga_id"`   // Last manga ID processed (for chapters)
-	LastChapterID   string    `json:"last_chapter_id"` // Last chapter ID processed (for pages)
-	StartTime       time.Time `json:"start_time"`      // When crawling started
-	LastUpdateTime  time.Time `json:"last_update"`     // Last checkpoint update
-	EstimatedTotal  int       `json:"estimated_total"` // Estimated total items to process
-	ErrorCount      int       `json:"error_count"`     // Number of errors encountered
-	SuccessCount    int       `json:"success_count"`   // Number of successful operations
+	JobID          string     `json:"job_id"`                 // Job the checkpoint belongs to
+	Phase          string     `json:"phase"`                  // "manga", "manga_incremental", "chapters", "pages", "pipeline"
+	CurrentPage    int        `json:"current_page"`           // Last page fully processed
+	EndPage        int        `json:"end_page"`               // Requested end page (-1 = all)
+	TotalProcessed int        `json:"total_processed"`        // Total items processed so far
+	LastMangaID    string     `json:"last_manga_id"`          // Last manga ID processed (for chapters)
+	LastChapterID  string     `json:"last_chapter_id"`        // Last chapter ID processed (for pages)
+	StartTime      time.Time  `json:"start_time"`             // When crawling started
+	LastUpdateTime time.Time  `json:"last_update"`            // Last checkpoint update
+	CompletedAt    *time.Time `json:"completed_at,omitempty"` // Set once the crawl finished
+	EstimatedTotal int        `json:"estimated_total"`        // Estimated total items to process
+	ErrorCount     int        `json:"error_count"`            // Number of errors encountered
+	SuccessCount   int        `json:"success_count"`          // Number of successful operations
+
+	Stages map[string]*StageCheckpoint `json:"stages,omitempty"` // Per-stage progress (pipeline crawls)
+}
+
+// StageCheckpoint is the progress of one pipeline stage
+type StageCheckpoint struct {
+	Workers     int    `json:"workers"`
+	Queued      int    `json:"queued"` // Items waiting in the stage's input queue
+	Processed   int    `json:"processed"`
+	Success     int    `json:"success"`
+	Errors      int    `json:"errors"`
+	Skipped     int    `json:"skipped"`      // Items that needed no work (e.g. chapter already has pages)
+	CurrentPage int    `json:"current_page"` // Highest list page handled (manga stage)
+	LastID      string `json:"last_id"`      // Last external ID handled
+}
+
+type jobIDKey struct{}
+
+// WithJobID returns a context whose crawls save their checkpoints under jobID.
+// Crawls run without a job ID do not save checkpoints.
+func WithJobID(ctx context.Context, jobID string) context.Context {
+	return context.WithValue(ctx, jobIDKey{}, jobID)
 }
 
-const checkpointFile = "crawler_checkpoint.json"
+// JobIDFromContext returns the job ID set by WithJobID, or ""
+func JobIDFromContext(ctx context.Context) string {
+	jobID, _ := ctx.Value(jobIDKey{}).(string)
+	return jobID
+}
 
-// SaveCheckpoint saves the current crawling state
-func (c *Crawler) SaveCheckpoint(checkpoint CrawlCheckpoint) error {
+// SaveCheckpoint saves the current crawling state for checkpoint.JobID
+func (c *Crawler) SaveCheckpoint(ctx context.Context, checkpoint CrawlCheckpoint) error {
+	if checkpoint.JobID == "" {
+		return fmt.Errorf("checkpoint has no job ID")
+	}
 	checkpoint.LastUpdateTime = time.Now()
-	
-	data, err := json.MarshalIndent(checkpoint, "", "  ")
+
+	stages, err := json.Marshal(checkpoint.Stages)
 	if err != nil {
-		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+		return fmt.Errorf("failed to marshal checkpoint stages: %w", err)
 	}
-	
-	// Create backup of existing checkpoint
-	if _, err := os.Stat(checkpointFile); err == nil {
-		backupFile := fmt.Sprintf("%s.backup", checkpointFile)
-		if err := os.Rename(checkpointFile, backupFile); err != nil {
-			log.Printf("Warning: failed to create checkpoint backup: %v", err)
-		}
+
+	query := `
+		INSERT INTO "mCrawlCheckpoint" (
+			job_id, phase, current_page, end_page, total_processed, last_manga_id, last_chapter_id,
+			estimated_total, success_count, error_count, stages, start_time, completed_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (job_id) DO UPDATE SET
+			phase = EXCLUDED.phase,
+			current_page = EXCLUDED.current_page,
+			end_page = EXCLUDED.end_page,
+			total_processed = EXCLUDED.total_processed,
+			last_manga_id = EXCLUDED.last_manga_id,
+			last_chapter_id = EXCLUDED.last_chapter_id,
+			estimated_total = EXCLUDED.estimated_total,
+			success_count = EXCLUDED.success_count,
+			error_count = EXCLUDED.error_count,
+			stages = EXCLUDED.stages,
+			completed_at = EXCLUDED.completed_at,
+			updated_at = EXCLUDED.updated_at
+	`
+	if _, err := c.db.Pool.Exec(ctx, query,
+		checkpoint.JobID, checkpoint.Phase, checkpoint.CurrentPage, checkpoint.EndPage,
+		checkpoint.TotalProcessed, checkpoint.LastMangaID, checkpoint.LastChapterID,
+		checkpoint.EstimatedTotal, checkpoint.SuccessCount, checkpoint.ErrorCount,
+		stages, checkpoint.StartTime, checkpoint.CompletedAt, checkpoint.LastUpdateTime,
+	); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
 	}
-	
-	if err := os.WriteFile(checkpointFile, data, 0644); err != nil {
-		return fmt.Errorf("failed to write checkpoint file: %w", err)
+
+	if c.config.Verbose {
+		log.Printf("📊 Checkpoint saved: %s [%s] - Page %d - Processed %d/%d",
+			checkpoint.JobID, checkpoint.Phase, checkpoint.CurrentPage,
+			checkpoint.TotalProcessed, checkpoint.EstimatedTotal)
 	}
-	
-	log.Printf("📊 Checkpoint saved: %s - Page %d - Processed %d/%d (%.1f%%)",
-		checkpoint.Phase, checkpoint.CurrentPage, checkpoint.TotalProcessed,
-		checkpoint.EstimatedTotal, float64(checkpoint.TotalProcessed)/float64(checkpoint.EstimatedTotal)*100)
-	
+
 	return nil
 }
 
-// LoadCheckpoint loads the last saved crawling state
-func (c *Crawler) LoadCheckpoint() (*CrawlCheckpoint, error) {
-	if _, err := os.Stat(checkpointFile); os.IsNotExist(err) {
+// LoadCheckpoint loads the checkpoint of a job, or the most recently updated
+// one when jobID is empty. Returns nil if there is none.
+func (c *Crawler) LoadCheckpoint(ctx context.Context, jobID string) (*CrawlCheckpoint, error) {
+	query := `
+		SELECT job_id, phase, current_page, end_page, total_processed, last_manga_id, last_chapter_id,
+			estimated_total, success_count, error_count, stages, start_time, completed_at, updated_at
+		FROM "mCrawlCheckpoint"
+		WHERE $1 = '' OR job_id = $1
+		ORDER BY updated_at DESC
+		LIMIT 1
+	`
+
+	var checkpoint CrawlCheckpoint
+	var stages []byte
+	err := c.db.Pool.QueryRow(ctx, query, jobID).Scan(
+		&checkpoint.JobID, &checkpoint.Phase, &checkpoint.CurrentPage, &checkpoint.EndPage,
+		&checkpoint.TotalProcessed, &checkpoint.LastMangaID, &checkpoint.LastChapterID,
+		&checkpoint.EstimatedTotal, &checkpoint.SuccessCount, &checkpoint.ErrorCount,
+		&stages, &checkpoint.StartTime, &checkpoint.CompletedAt, &checkpoint.LastUpdateTime,
+	)
+	if err == pgx.ErrNoRows {
 		return nil, nil // No checkpoint exists
 	}
-	
-	data, err := os.ReadFile(checkpointFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read checkpoint file: %w", err)
+		return nil, fmt.Errorf("failed to load checkpoint: %w", err)
 	}
-	
-	var checkpoint CrawlCheckpoint
-	if err := json.Unmarshal(data, &checkpoint); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal checkpoint: %w", err)
+
+	if len(stages) > 0 {
+		if err := json.Unmarshal(stages, &checkpoint.Stages); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal checkpoint stages: %w", err)
+		}
 	}
-	
-	log.Printf("📂 Checkpoint loaded: %s - Page %d - Processed %d/%d",
-		checkpoint.Phase, checkpoint.CurrentPage, checkpoint.TotalProcessed, checkpoint.EstimatedTotal)
-	
+
+	log.Printf("📂 Checkpoint loaded: %s [%s] - Page %d - Processed %d/%d",
+		checkpoint.JobID, checkpoint.Phase, checkpoint.CurrentPage, checkpoint.TotalProcessed, checkpoint.EstimatedTotal)
+
 	return &checkpoint, nil
 }
 
-// ClearCheckpoint removes the checkpoint file
-func (c *Crawler) ClearCheckpoint() error {
-	if err := os.Remove(checkpointFile); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to remove checkpoint file: %w", err)
+// ClearCheckpoint removes the checkpoint of a job, or every checkpoint when jobID is empty
+func (c *Crawler) ClearCheckpoint(ctx context.Context, jobID string) error {
+	if _, err := c.db.Pool.Exec(ctx, `DELETE FROM "mCrawlCheckpoint" WHERE $1 = '' OR job_id = $1`, jobID); err != nil {
+		return fmt.Errorf("failed to clear checkpoint: %w", err)
 	}
 	log.Println("🗑️ Checkpoint cleared")
 	return nil
 }
 
+// startCheckpoint begins progress tracking for a crawl phase, continuing
+// from resume when the crawl is a resumed one
+func (c *Crawler) startCheckpoint(ctx context.Context, phase string, resume *CrawlCheckpoint) *CrawlCheckpoint {
+	if resume != nil {
+		checkpoint := *resume
+		checkpoint.CompletedAt = nil
+		return &checkpoint
+	}
+	return &CrawlCheckpoint{
+		JobID:     JobIDFromContext(ctx),
+		Phase:     phase,
+		StartTime: time.Now(),
+	}
+}
+
+// saveProgress reports a job's progress (see reportProgress) and saves its
+// checkpoint (not in dry runs) if the crawl belongs to a job. It still saves
+// after ctx is cancelled so a stopped job can be resumed; failures are logged
+// rather than aborting the crawl.
+func (c *Crawler) saveProgress(ctx context.Context, checkpoint *CrawlCheckpoint) {
+	if checkpoint.JobID == "" {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	c.reportProgress(ctx, checkpoint)
+
+	if c.config.DryRun {
+		return
+	}
+	if err := c.SaveCheckpoint(ctx, *checkpoint); err != nil {
+		jobEvent(ctx, EventWarning, fmt.Sprintf("Warning: %v", err), nil)
+	}
+}
+
+// reportProgress updates the job's counters and emits a progress event
+func (c *Crawler) reportProgress(ctx context.Context, checkpoint *CrawlCheckpoint) {
+	if checkpoint.JobID == "" {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	if err := c.jobs.UpdateCounters(ctx, checkpoint.JobID, checkpoint.TotalProcessed,
+		checkpoint.SuccessCount, checkpoint.ErrorCount, checkpoint.EstimatedTotal); err != nil {
+		jobEvent(ctx, EventWarning, fmt.Sprintf("Warning: %v", err), nil)
+	}
+	recordJobEvent(ctx, EventProgress, fmt.Sprintf("%s: %d/%d processed, %d success, %d failed",
+		checkpoint.Phase, checkpoint.TotalProcessed, checkpoint.EstimatedTotal,
+		checkpoint.SuccessCount, checkpoint.ErrorCount), progressEventData(checkpoint))
+}
+
+// finishCheckpoint marks a crawl phase as completed
+func (c *Crawler) finishCheckpoint(ctx context.Context, checkpoint *CrawlCheckpoint) {
+	now := time.Now()
+	checkpoint.CompletedAt = &now
+	c.saveProgress(ctx, checkpoint)
+}
+
+// Resume continues a crawl from its checkpoint without repeating finished work:
+// manga and pipeline crawls restart after CurrentPage, chapter crawls after
+// LastMangaID and page crawls after LastChapterID. Progress keeps being saved
+// under the checkpoint's job ID.
+func (c *Crawler) Resume(ctx context.Context, checkpoint *CrawlCheckpoint) error {
+	if checkpoint.CompletedAt != nil {
+		return fmt.Errorf("job %s already completed at %s", checkpoint.JobID, checkpoint.CompletedAt.Format(time.RFC3339))
+	}
+	ctx = WithJobID(ctx, checkpoint.JobID)
+
+	switch checkpoint.Phase {
+	case "manga":
+		_, _, err := c.crawlManga(ctx, checkpoint.CurrentPage+1, checkpoint.EndPage, checkpoint, nil)
+		return err
+	case "manga_incremental":
+		return c.crawlMangaIncremental(ctx, checkpoint.CurrentPage+1, checkpoint.EndPage, checkpoint)
+	case "chapters":
+		return c.crawlAllChapters(ctx, checkpoint)
+	case "pages":
+		return c.crawlAllPages(ctx, checkpoint)
+	case "pipeline":
+		return c.crawlPipeline(ctx, checkpoint.CurrentPage+1, checkpoint.EndPage, checkpoint)
+	case "reconcile":
+		return c.reconcile(ctx, checkpoint)
+	default:
+		return fmt.Errorf("cannot resume phase %q", checkpoint.Phase)
+	}
+}
+
 // GetProgressReport generates a detailed progress report
 func (c *Crawler) GetProgressReport(checkpoint CrawlCheckpoint) string {
 	elapsed := time.Since(checkpoint.StartTime)
@@ -1,5 +1,7 @@
 package crawler
 
+import "time"
+
 // Config holds crawler configuration
 type Config struct {
 	BaseURL   string
@@ -7,6 +9,38 @@ type Config struct {
 	DryRun    bool
 	Verbose   bool
 	Headers   map[string]string
+
+	// RecordDir, when set, archives every raw upstream response to this directory
+	RecordDir string
+	// ReplayDir, when set, serves upstream responses from a recorded archive
+	// instead of the network. Takes precedence over RecordDir.
+	ReplayDir string
+
+	// RequestsPerSecond is the per-host upstream request rate (DefaultRequestsPerSecond if 0)
+	RequestsPerSecond float64
+	// Limiter, when set, is used instead of creating a limiter from RequestsPerSecond,
+	// so several crawlers can share one budget per host
+	Limiter *RateLimiter
+
+	// Retry controls retries of failed upstream requests; zero fields use defaults
+	Retry RetryPolicy
+	// Breaker, when set, is shared instead of creating a new circuit breaker
+	Breaker *CircuitBreaker
+
+	// Pipeline sets per-stage concurrency for CrawlPipeline; zero fields use defaults
+	Pipeline PipelineConfig
+
+	// IncrementalOverlap is how far past the high-water mark an incremental
+	// manga crawl keeps paginating (DefaultIncrementalOverlap if 0)
+	IncrementalOverlap time.Duration
+
+	// NewChaptersOnly makes chapter crawls stop after the first chapter list
+	// page whose chapters are all known, instead of walking the whole list
+	NewChaptersOnly bool
+
+	// RowByRowWrites saves chapters and pages with one statement per row instead
+	// of set-based upserts. Slower; kept so cmd/crawl-bench can compare the two.
+	RowByRowWrites bool
 }
 
 // Default headers for API requests
@@ -1,102 +1,150 @@
 package crawler
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"baca-komik-api/database"
 )
 
+// defaultBatchSize is used when Config.BatchSize is not set
+const defaultBatchSize = 10
+
 type Crawler struct {
-	db     *database.DB
-	config *Config
-	client *http.Client
+	db       *database.DB
+	config   *Config
+	source   Source
+	limiter  *RateLimiter
+	breaker  *CircuitBreaker
+	failures *failureLog
+	jobs     *JobQueue
+
+	// newSource rebuilds the source for a different config (nil for NewWithSource)
+	newSource func(config *Config) Source
 }
 
+// New creates a crawler reading from the Shinigami API
 func New(db *database.DB, config *Config) *Crawler {
-	return &Crawler{
-		db:     db,
-		config: config,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+	limiter := config.Limiter
+	if limiter == nil {
+		limiter = NewRateLimiter(config.RequestsPerSecond)
 	}
-}
-
-// makeRequest makes HTTP request with proper headers
-func (c *Crawler) makeRequest(url string) (*http.Response, error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	breaker := config.Breaker
+	if breaker == nil {
+		breaker = NewCircuitBreaker(0, 0)
 	}
 
-	// Add headers
-	for key, value := range c.config.Headers {
-		req.Header.Set(key, value)
+	c := NewWithSource(db, config, NewShngmSource(config, limiter, breaker))
+	c.limiter = limiter
+	c.breaker = breaker
+	c.newSource = func(config *Config) Source {
+		return NewShngmSource(config, limiter, breaker)
 	}
+	return c
+}
 
-	if c.config.Verbose {
-		log.Printf("Making request to: %s", url)
-	}
+// Options override the crawler config for a single job
+type Options struct {
+	DryRun    bool   // never write to the database (a dry-run crawler stays dry-run)
+	Verbose   *bool  // nil keeps the crawler's setting
+	BatchSize int    // 0 keeps the crawler's setting
+	BaseURL   string // "" keeps the crawler's upstream
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+	NewChaptersOnly bool // crawl only new chapters (false keeps the crawler's setting)
+}
+
+// WithOptions returns a crawler for one job: it has its own copy of the config
+// with opts applied, so concurrent jobs never see each other's settings, but
+// shares the database, job queue, failure log, rate limiter and circuit breaker.
+func (c *Crawler) WithOptions(opts Options) (*Crawler, error) {
+	config := *c.config
+	config.DryRun = config.DryRun || opts.DryRun
+	if opts.Verbose != nil {
+		config.Verbose = *opts.Verbose
 	}
+	if opts.BatchSize > 0 {
+		config.BatchSize = opts.BatchSize
+	}
+	config.NewChaptersOnly = config.NewChaptersOnly || opts.NewChaptersOnly
 
-	if resp.StatusCode != http.StatusOK {
-		resp.Body.Close()
-		return nil, fmt.Errorf("API returned status %d for URL: %s", resp.StatusCode, url)
+	source := c.source
+	baseURLChanged := opts.BaseURL != "" && opts.BaseURL != config.BaseURL
+	if baseURLChanged {
+		if c.newSource == nil {
+			return nil, fmt.Errorf("source %s does not support a custom base URL", c.source.Name())
+		}
+		config.BaseURL = opts.BaseURL
+	}
+	if c.newSource != nil && (baseURLChanged || config.Verbose != c.config.Verbose) {
+		source = c.newSource(&config)
 	}
 
-	return resp, nil
+	job := *c
+	job.config = &config
+	job.source = source
+	return &job, nil
 }
 
-// fetchJSON fetches and unmarshals JSON response
-func (c *Crawler) fetchJSON(url string, target interface{}) error {
-	resp, err := c.makeRequest(url)
-	if err != nil {
-		return err
+// batchSize returns Config.BatchSize, or defaultBatchSize if unset
+func (c *Crawler) batchSize() int {
+	if c.config.BatchSize > 0 {
+		return c.config.BatchSize
 	}
-	defer resp.Body.Close()
+	return defaultBatchSize
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+// NewWithSource creates a crawler reading from the given upstream source.
+// The source is responsible for its own rate limiting and retries.
+func NewWithSource(db *database.DB, config *Config, source Source) *Crawler {
+	return &Crawler{
+		db:       db,
+		config:   config,
+		source:   source,
+		limiter:  config.Limiter,
+		breaker:  config.Breaker,
+		failures: newFailureLog(),
+		jobs:     NewJobQueue(db),
 	}
+}
 
-	if err := json.Unmarshal(body, target); err != nil {
-		return fmt.Errorf("failed to unmarshal JSON: %w", err)
-	}
+// Source returns the upstream source this crawler reads from
+func (c *Crawler) Source() Source {
+	return c.source
+}
 
-	return nil
+// Jobs returns the shared crawl job queue
+func (c *Crawler) Jobs() *JobQueue {
+	return c.jobs
+}
+
+// Limiter returns the per-host rate limiter upstream requests go through (may be nil)
+func (c *Crawler) Limiter() *RateLimiter {
+	return c.limiter
+}
+
+// Breaker returns the circuit breaker upstream requests go through (may be nil)
+func (c *Crawler) Breaker() *CircuitBreaker {
+	return c.breaker
+}
+
+// UpstreamClient returns an HTTP client sharing this crawler's retry policy,
+// rate limiter and circuit breaker, for code that calls the upstream directly
+func (c *Crawler) UpstreamClient() *http.Client {
+	return newUpstreamClient(networkTransport(), c.config.Retry, c.limiter, c.breaker)
 }
 
 // CrawlGenres crawls all genres
-func (c *Crawler) CrawlGenres() error {
+func (c *Crawler) CrawlGenres(ctx context.Context) error {
 	log.Println("Starting to crawl genres...")
 	
-	url := fmt.Sprintf("%s/genre/list", c.config.BaseURL)
-	var response APIResponse
-	
-	if err := c.fetchJSON(url, &response); err != nil {
-		return fmt.Errorf("failed to fetch genres: %w", err)
-	}
-
-	// Parse genres data
-	genresData, err := json.Marshal(response.Data)
+	genres, err := c.source.ListGenres(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to marshal genres data: %w", err)
-	}
-
-	var genres []ExternalGenre
-	if err := json.Unmarshal(genresData, &genres); err != nil {
-		return fmt.Errorf("failed to unmarshal genres: %w", err)
+		return fmt.Errorf("failed to fetch genres: %w", err)
 	}
 
 	log.Printf("Found %d genres to process", len(genres))
@@ -110,11 +158,11 @@ func (c *Crawler) CrawlGenres() error {
 	}
 
 	// Save to database
-	return c.saveGenres(genres)
+	return c.saveGenres(ctx, genres)
 }
 
 // CrawlFormats crawls all formats (simple endpoint with no pagination)
-func (c *Crawler) CrawlFormats() error {
+func (c *Crawler) CrawlFormats(ctx context.Context) error {
 	log.Println("Starting to crawl formats...")
 
 	var allFormats []ExternalFormat
@@ -125,22 +173,9 @@ func (c *Crawler) CrawlFormats() error {
 
 	for {
 		log.Printf("Fetching formats page %d...", page)
-		url := fmt.Sprintf("%s/format/list?page=%d", c.config.BaseURL, page)
-		var response APIResponse
-
-		if err := c.fetchJSON(url, &response); err != nil {
-			return fmt.Errorf("failed to fetch formats page %d: %w", page, err)
-		}
-
-		// Parse formats data
-		formatsData, err := json.Marshal(response.Data)
+		formats, err := c.source.ListFormats(ctx, page)
 		if err != nil {
-			return fmt.Errorf("failed to marshal formats data: %w", err)
-		}
-
-		var formats []ExternalFormat
-		if err := json.Unmarshal(formatsData, &formats); err != nil {
-			return fmt.Errorf("failed to unmarshal formats: %w", err)
+			return fmt.Errorf("failed to fetch formats page %d: %w", page, err)
 		}
 
 		if len(formats) == 0 {
@@ -174,7 +209,6 @@ func (c *Crawler) CrawlFormats() error {
 		}
 
 		page++
-		time.Sleep(200 * time.Millisecond) // Rate limiting
 	}
 
 	log.Printf("Total unique formats found: %d", len(allFormats))
@@ -188,11 +222,11 @@ func (c *Crawler) CrawlFormats() error {
 	}
 
 	// Save to database
-	return c.saveFormats(allFormats)
+	return c.saveFormats(ctx, allFormats)
 }
 
 // CrawlTypes crawls all types (simple endpoint with no pagination)
-func (c *Crawler) CrawlTypes() error {
+func (c *Crawler) CrawlTypes(ctx context.Context) error {
 	log.Println("Starting to crawl types...")
 
 	var allTypes []ExternalType
@@ -203,22 +237,9 @@ func (c *Crawler) CrawlTypes() error {
 
 	for {
 		log.Printf("Fetching types page %d...", page)
-		url := fmt.Sprintf("%s/type/list?page=%d", c.config.BaseURL, page)
-		var response APIResponse
-
-		if err := c.fetchJSON(url, &response); err != nil {
-			return fmt.Errorf("failed to fetch types page %d: %w", page, err)
-		}
-
-		// Parse types data
-		typesData, err := json.Marshal(response.Data)
+		types, err := c.source.ListTypes(ctx, page)
 		if err != nil {
-			return fmt.Errorf("failed to marshal types data: %w", err)
-		}
-
-		var types []ExternalType
-		if err := json.Unmarshal(typesData, &types); err != nil {
-			return fmt.Errorf("failed to unmarshal types: %w", err)
+			return fmt.Errorf("failed to fetch types page %d: %w", page, err)
 		}
 
 		if len(types) == 0 {
@@ -252,7 +273,6 @@ func (c *Crawler) CrawlTypes() error {
 		}
 
 		page++
-		time.Sleep(200 * time.Millisecond) // Rate limiting
 	}
 
 	log.Printf("Total unique types found: %d", len(allTypes))
@@ -266,11 +286,11 @@ func (c *Crawler) CrawlTypes() error {
 	}
 
 	// Save to database
-	return c.saveTypes(allTypes)
+	return c.saveTypes(ctx, allTypes)
 }
 
 // CrawlAuthors crawls all authors with auto-pagination and multiple search queries
-func (c *Crawler) CrawlAuthors() error {
+func (c *Crawler) CrawlAuthors(ctx context.Context) error {
 	log.Println("Starting to crawl authors...")
 
 	var allAuthors []ExternalAuthor
@@ -280,40 +300,31 @@ func (c *Crawler) CrawlAuthors() error {
 	searchQueries := []string{"", "a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n", "o", "p", "q", "r", "s", "t", "u", "v", "w", "x", "y", "z", "0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}
 
 	for _, query := range searchQueries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		log.Printf("Searching authors with query: '%s'", query)
 		page := 1
 		totalPages := 0
 
 		for {
 			log.Printf("Fetching authors page %d for query '%s'...", page, query)
-			url := fmt.Sprintf("%s/author/list?q=%s&page=%d", c.config.BaseURL, query, page)
-			var response APIResponse
-
-			if err := c.fetchJSON(url, &response); err != nil {
+			result, err := c.source.ListAuthors(ctx, query, page)
+			if err != nil {
 				log.Printf("Failed to fetch authors page %d for query '%s': %v", page, query, err)
 				break
 			}
 
-			// Get pagination info from meta
-			if response.Meta.TotalPage != nil {
-				totalPages = *response.Meta.TotalPage
-				if page == 1 && totalPages > 0 {
+			// Get pagination info
+			if result.TotalPage > 0 {
+				totalPages = result.TotalPage
+				if page == 1 {
 					log.Printf("Query '%s' has %d pages available", query, totalPages)
 				}
 			}
 
-			// Parse authors data
-			authorsData, err := json.Marshal(response.Data)
-			if err != nil {
-				log.Printf("Failed to marshal authors data: %v", err)
-				break
-			}
-
-			var authors []ExternalAuthor
-			if err := json.Unmarshal(authorsData, &authors); err != nil {
-				log.Printf("Failed to unmarshal authors: %v", err)
-				break
-			}
+			authors := result.Authors
 
 			if len(authors) == 0 {
 				log.Printf("No more authors found on page %d for query '%s'", page, query)
@@ -339,10 +350,7 @@ func (c *Crawler) CrawlAuthors() error {
 			}
 
 			page++
-			time.Sleep(100 * time.Millisecond) // Rate limiting
 		}
-
-		time.Sleep(300 * time.Millisecond) // Rate limiting between queries
 	}
 
 	log.Printf("Total unique authors found: %d", len(allAuthors))
@@ -361,11 +369,11 @@ func (c *Crawler) CrawlAuthors() error {
 	}
 
 	// Save to database
-	return c.saveAuthors(allAuthors)
+	return c.saveAuthors(ctx, allAuthors)
 }
 
 // CrawlArtists crawls all artists with auto-pagination and multiple search queries
-func (c *Crawler) CrawlArtists() error {
+func (c *Crawler) CrawlArtists(ctx context.Context) error {
 	log.Println("Starting to crawl artists...")
 
 	var allArtists []ExternalArtist
@@ -375,40 +383,31 @@ func (c *Crawler) CrawlArtists() error {
 	searchQueries := []string{"", "a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n", "o", "p", "q", "r", "s", "t", "u", "v", "w", "x", "y", "z"}
 
 	for _, query := range searchQueries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		log.Printf("Searching artists with query: '%s'", query)
 		page := 1
 		totalPages := 0
 
 		for {
 			log.Printf("Fetching artists page %d for query '%s'...", page, query)
-			url := fmt.Sprintf("%s/artist/list?q=%s&page=%d", c.config.BaseURL, query, page)
-			var response APIResponse
-
-			if err := c.fetchJSON(url, &response); err != nil {
+			result, err := c.source.ListArtists(ctx, query, page)
+			if err != nil {
 				log.Printf("Failed to fetch artists page %d for query '%s': %v", page, query, err)
 				break
 			}
 
-			// Get pagination info from meta
-			if response.Meta.TotalPage != nil {
-				totalPages = *response.Meta.TotalPage
-				if page == 1 && totalPages > 0 {
+			// Get pagination info
+			if result.TotalPage > 0 {
+				totalPages = result.TotalPage
+				if page == 1 {
 					log.Printf("Query '%s' has %d pages available", query, totalPages)
 				}
 			}
 
-			// Parse artists data
-			artistsData, err := json.Marshal(response.Data)
-			if err != nil {
-				log.Printf("Failed to marshal artists data: %v", err)
-				break
-			}
-
-			var artists []ExternalArtist
-			if err := json.Unmarshal(artistsData, &artists); err != nil {
-				log.Printf("Failed to unmarshal artists: %v", err)
-				break
-			}
+			artists := result.Artists
 
 			if len(artists) == 0 {
 				log.Printf("No more artists found on page %d for query '%s'", page, query)
@@ -434,10 +433,7 @@ func (c *Crawler) CrawlArtists() error {
 			}
 
 			page++
-			time.Sleep(100 * time.Millisecond) // Rate limiting
 		}
-
-		time.Sleep(300 * time.Millisecond) // Rate limiting between queries
 	}
 
 	log.Printf("Total unique artists found: %d", len(allArtists))
@@ -456,40 +452,40 @@ func (c *Crawler) CrawlArtists() error {
 	}
 
 	// Save to database
-	return c.saveArtists(allArtists)
+	return c.saveArtists(ctx, allArtists)
 }
 
 // CrawlAllMasterData crawls all master data with full auto-pagination
-func (c *Crawler) CrawlAllMasterData() error {
+func (c *Crawler) CrawlAllMasterData(ctx context.Context) error {
 	log.Println("🚀 Starting AUTO master data crawl (full pagination)...")
 
 	// Step 1: Genres
 	log.Println("📚 Phase 1: Auto-crawling genres...")
-	if err := c.CrawlGenres(); err != nil {
+	if err := c.CrawlGenres(ctx); err != nil {
 		return fmt.Errorf("failed to crawl genres: %w", err)
 	}
 
 	// Step 2: Formats
 	log.Println("📝 Phase 2: Auto-crawling formats...")
-	if err := c.CrawlFormats(); err != nil {
+	if err := c.CrawlFormats(ctx); err != nil {
 		return fmt.Errorf("failed to crawl formats: %w", err)
 	}
 
 	// Step 3: Types
 	log.Println("🏷️ Phase 3: Auto-crawling types...")
-	if err := c.CrawlTypes(); err != nil {
+	if err := c.CrawlTypes(ctx); err != nil {
 		return fmt.Errorf("failed to crawl types: %w", err)
 	}
 
 	// Step 4: Authors (comprehensive search)
 	log.Println("✍️ Phase 4: Auto-crawling authors (comprehensive search)...")
-	if err := c.CrawlAuthors(); err != nil {
+	if err := c.CrawlAuthors(ctx); err != nil {
 		return fmt.Errorf("failed to crawl authors: %w", err)
 	}
 
 	// Step 5: Artists (comprehensive search)
 	log.Println("🎨 Phase 5: Auto-crawling artists (comprehensive search)...")
-	if err := c.CrawlArtists(); err != nil {
+	if err := c.CrawlArtists(ctx); err != nil {
 		return fmt.Errorf("failed to crawl artists: %w", err)
 	}
 
@@ -498,83 +494,183 @@ func (c *Crawler) CrawlAllMasterData() error {
 }
 
 // CrawlAll crawls everything in proper order
-func (c *Crawler) CrawlAll() error {
+func (c *Crawler) CrawlAll(ctx context.Context) error {
 	log.Println("Starting complete crawl process...")
 
-	// Step 1: Master data
+	// Step 1: Master data. Optional: manga ingestion creates any genre,
+	// author, artist, format or type it is missing, so a failure here only
+	// means fewer unused entries.
 	log.Println("Phase 1: Crawling master data...")
-	if err := c.CrawlAllMasterData(); err != nil {
-		return fmt.Errorf("failed to crawl master data: %w", err)
+	if err := c.CrawlAllMasterData(ctx); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		jobEvent(ctx, EventWarning, fmt.Sprintf("Warning: master data crawl failed, continuing: %v", err), nil)
+	} else {
+		log.Println("Phase 1 completed: Master data crawled successfully")
 	}
 
-	log.Println("Phase 1 completed: Master data crawled successfully")
-
-	// Step 2: Manga data (start with first 10 pages)
-	log.Println("Phase 2: Crawling manga data...")
-	if err := c.CrawlManga(1, 10); err != nil {
-		return fmt.Errorf("failed to crawl manga: %w", err)
+	// Step 2: Manga, chapters and pages streamed through the pipeline (start with first 10 pages)
+	log.Println("Phase 2: Crawling manga, chapters and pages...")
+	if err := c.CrawlPipeline(ctx, 1, 10); err != nil {
+		return fmt.Errorf("failed to crawl manga pipeline: %w", err)
 	}
 
-	log.Println("Phase 2 completed: Manga data crawled successfully")
+	log.Println("All phases completed successfully!")
+	return nil
+}
 
-	// Step 3: Chapters (for crawled manga)
-	log.Println("Phase 3: Crawling chapters...")
-	if err := c.CrawlAllChapters(); err != nil {
-		return fmt.Errorf("failed to crawl chapters: %w", err)
-	}
+// RunJob runs a crawl job according to its parameters, with the job's own
+// options (see WithOptions)
+func (c *Crawler) RunJob(ctx context.Context, job *Job) error {
+	params := job.Params
 
-	log.Println("Phase 3 completed: Chapters crawled successfully")
+	jc, err := c.WithOptions(params.Options())
+	if err != nil {
+		return err
+	}
+	if jc.config.DryRun {
+		log.Printf("DRY RUN: job %s will not write to the database", job.ID)
+	}
 
-	// Step 4: Pages
-	log.Println("Phase 4: Crawling pages...")
-	if err := c.CrawlAllPages(); err != nil {
-		return fmt.Errorf("failed to crawl pages: %w", err)
+	if params.Resume {
+		checkpoint, err := jc.LoadCheckpoint(ctx, job.ID)
+		if err != nil {
+			return err
+		}
+		if checkpoint == nil {
+			return fmt.Errorf("no checkpoint found for job %s", job.ID)
+		}
+		return jc.Resume(ctx, checkpoint)
+	}
+
+	switch params.Mode {
+	case "genres":
+		return jc.CrawlGenres(ctx)
+	case "formats":
+		return jc.CrawlFormats(ctx)
+	case "types":
+		return jc.CrawlTypes(ctx)
+	case "authors":
+		return jc.CrawlAuthors(ctx)
+	case "artists":
+		return jc.CrawlArtists(ctx)
+	case "manga":
+		return jc.CrawlManga(ctx, params.StartPage, params.EndPage)
+	case "manga_incremental":
+		// Newest first, until the pages are older than the high-water mark
+		return jc.CrawlMangaIncremental(ctx, params.StartPage, params.EndPage)
+	case "chapters":
+		if params.MangaID == "all" || params.MangaID == "" {
+			// Auto-crawl chapters for all manga in database
+			return jc.CrawlAllChapters(ctx)
+		}
+		// Crawl chapters for specific manga ID
+		return jc.CrawlChaptersForManga(ctx, params.MangaID)
+	case "pages":
+		return jc.CrawlAllPages(ctx)
+	case "pipeline":
+		// Manga, chapters and pages streamed concurrently
+		return jc.CrawlPipeline(ctx, params.StartPage, params.EndPage)
+	case "all":
+		return jc.CrawlAll(ctx)
+	case "auto":
+		return jc.CrawlAllMasterData(ctx)
+	case "retry_failures":
+		return jc.RetryDeadLetters(ctx, params.FailureIDs, params.FailureEntity)
+	case "reprocess":
+		// Re-derive rows from archived payloads, no upstream requests
+		return jc.Reprocess(ctx, params.MangaID)
+	case "reconcile":
+		// Mark manga and chapters no longer listed upstream as removed
+		return jc.Reconcile(ctx, params.MangaID)
+	default:
+		return fmt.Errorf("unknown mode: %s", params.Mode)
 	}
+}
 
-	log.Println("All phases completed successfully!")
-	return nil
+// CrawlManga crawls manga list with auto-pagination (if endPage = -1, crawl all).
+// Progress is checkpointed after every page when ctx carries a job ID.
+func (c *Crawler) CrawlManga(ctx context.Context, startPage, endPage int) error {
+	_, _, err := c.crawlManga(ctx, startPage, endPage, nil, nil)
+	return err
 }
 
-// CrawlManga crawls manga list with auto-pagination (if endPage = -1, crawl all)
-func (c *Crawler) CrawlManga(startPage, endPage int) error {
+// Why a manga crawl stopped
+type mangaStop int
+
+const (
+	stopEndOfListing mangaStop = iota // an empty page, or the source's last page
+	stopOlderPage                     // a page older than the watermark's cutoff
+	stopEndPage                       // the requested end page
+)
+
+// crawlManga crawls manga pages, continuing the counters of resume if not nil,
+// and returns the final checkpoint and why it stopped. With a watermark it is
+// an incremental crawl: it stops at the first page older than the watermark's
+// cutoff and records the newest update seen.
+func (c *Crawler) crawlManga(ctx context.Context, startPage, endPage int, resume *CrawlCheckpoint, watermark *mangaWatermark) (*CrawlCheckpoint, mangaStop, error) {
 	if endPage == -1 {
-		log.Printf("Starting to crawl ALL manga from page %d...", startPage)
+		jobEvent(ctx, EventInfo, fmt.Sprintf("Starting to crawl ALL manga from page %d...", startPage), nil)
 	} else {
-		log.Printf("Starting to crawl manga from page %d to %d...", startPage, endPage)
+		jobEvent(ctx, EventInfo, fmt.Sprintf("Starting to crawl manga from page %d to %d...", startPage, endPage), nil)
 	}
 
-	totalProcessed := 0
-	totalSuccess := 0
-	totalFailed := 0
+	phase := "manga"
+	if watermark != nil {
+		phase = "manga_incremental"
+	}
+	checkpoint := c.startCheckpoint(ctx, phase, resume)
+	checkpoint.EndPage = endPage
+	if resume == nil {
+		checkpoint.CurrentPage = startPage - 1
+	}
 	page := startPage
+	stop := stopEndOfListing
 
 	for {
+		if err := ctx.Err(); err != nil {
+			return nil, stop, err
+		}
+
 		if endPage != -1 && page > endPage {
 			log.Printf("Reached specified end page (%d), stopping", endPage)
+			stop = stopEndPage
 			break
 		}
 
 		log.Printf("Processing manga page %d...", page)
 
-		url := fmt.Sprintf("%s/manga/list?type=&page=%d&page_size=24&is_update=true&sort=latest&sort_order=desc",
-			c.config.BaseURL, page)
-
-		var response MangaListResponse
-		if err := c.fetchJSON(url, &response); err != nil {
-			log.Printf("Failed to fetch manga page %d: %v", page, err)
-			totalFailed++
+		result, err := c.source.ListManga(ctx, page)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, stop, ctx.Err()
+			}
+			c.recordFailure(ctx, EntityMangaPage, strconv.Itoa(page), err)
+			checkpoint.ErrorCount++
+			checkpoint.CurrentPage = page
+			c.saveProgress(ctx, checkpoint)
 			page++
 			continue
 		}
 
-		mangaList := response.Data
+		mangaList := result.Manga
 		if len(mangaList) == 0 {
 			log.Printf("No more manga found on page %d, stopping", page)
 			break
 		}
 
+		if watermark != nil {
+			if watermark.olderPage(mangaList) {
+				log.Printf("Page %d is older than %s, stopping", page, watermark.cutoff.Format(time.RFC3339))
+				stop = stopOlderPage
+				break
+			}
+			watermark.observe(mangaList)
+		}
+
 		log.Printf("Found %d manga on page %d", len(mangaList), page)
-		totalProcessed += len(mangaList)
+		checkpoint.TotalProcessed += len(mangaList)
 
 		if c.config.DryRun {
 			log.Printf("DRY RUN: Would save %d manga from page %d", len(mangaList), page)
@@ -586,92 +682,139 @@ func (c *Crawler) CrawlManga(startPage, endPage int) error {
 			if len(mangaList) > 3 {
 				log.Printf("  ... and %d more manga", len(mangaList)-3)
 			}
-			totalSuccess += len(mangaList)
+			checkpoint.SuccessCount += len(mangaList)
 		} else {
 			// Save manga to database
-			if err := c.saveMangaList(mangaList); err != nil {
-				log.Printf("Failed to save manga from page %d: %v", page, err)
-				totalFailed += len(mangaList)
+			if err := c.saveMangaList(ctx, mangaList); err != nil {
+				if ctx.Err() != nil {
+					return nil, stop, ctx.Err()
+				}
+				c.recordFailure(ctx, EntityMangaPage, strconv.Itoa(page), err)
+				checkpoint.ErrorCount += len(mangaList)
 			} else {
 				log.Printf("Successfully processed %d manga from page %d", len(mangaList), page)
-				totalSuccess += len(mangaList)
+				checkpoint.SuccessCount += len(mangaList)
 			}
 		}
 
-		// Check if we've reached the last page from API response
-		if response.Meta.TotalPage != nil && page >= *response.Meta.TotalPage {
-			log.Printf("Reached last page (%d) from API, stopping", *response.Meta.TotalPage)
+		checkpoint.CurrentPage = page
+		checkpoint.LastMangaID = mangaList[len(mangaList)-1].ID
+		if result.TotalPage > 0 {
+			checkpoint.EstimatedTotal = result.TotalPage * len(mangaList)
+		}
+		c.saveProgress(ctx, checkpoint)
+
+		// Check if we've reached the last page reported by the source
+		if result.TotalPage > 0 && page >= result.TotalPage {
+			log.Printf("Reached last page (%d) from API, stopping", result.TotalPage)
 			break
 		}
 
 		page++
-		time.Sleep(500 * time.Millisecond) // Rate limiting
 	}
 
-	log.Printf("Manga crawling completed: %d processed, %d success, %d failed",
-		totalProcessed, totalSuccess, totalFailed)
-	return nil
+	c.finishCheckpoint(ctx, checkpoint)
+	jobEvent(ctx, EventInfo, fmt.Sprintf("Manga crawling completed: %d processed, %d success, %d failed",
+		checkpoint.TotalProcessed, checkpoint.SuccessCount, checkpoint.ErrorCount), nil)
+	return checkpoint, stop, nil
 }
 
-// CrawlAllChapters crawls chapters for all manga in database
-func (c *Crawler) CrawlAllChapters() error {
+// CrawlAllChapters crawls chapters for all manga in database, in external ID
+// order. Progress is checkpointed after every manga when ctx carries a job ID.
+func (c *Crawler) CrawlAllChapters(ctx context.Context) error {
+	return c.crawlAllChapters(ctx, nil)
+}
+
+// crawlAllChapters crawls chapters for every manga after resume.LastMangaID
+// (all manga if resume is nil)
+func (c *Crawler) crawlAllChapters(ctx context.Context, resume *CrawlCheckpoint) error {
 	log.Println("Starting to crawl chapters for all manga...")
 
-	// Get all manga IDs from database
-	mangaIDs, err := c.getAllMangaIDs()
+	checkpoint := c.startCheckpoint(ctx, "chapters", resume)
+
+	// Get remaining manga IDs from database
+	mangaIDs, err := c.getAllMangaIDs(ctx, checkpoint.LastMangaID)
 	if err != nil {
 		return fmt.Errorf("failed to get manga IDs: %w", err)
 	}
 
-	log.Printf("Found %d manga to process chapters for", len(mangaIDs))
-
-	totalProcessed := 0
-	totalSuccess := 0
-	totalFailed := 0
+	if checkpoint.LastMangaID != "" {
+		log.Printf("Resuming after manga %s", checkpoint.LastMangaID)
+	}
+	jobEvent(ctx, EventInfo, fmt.Sprintf("Found %d manga to process chapters for", len(mangaIDs)), nil)
+	checkpoint.EstimatedTotal = checkpoint.TotalProcessed + len(mangaIDs)
 
 	for i, mangaID := range mangaIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		log.Printf("Processing chapters for manga %d/%d (ID: %s)...", i+1, len(mangaIDs), mangaID)
 
-		if err := c.CrawlChaptersForManga(mangaID); err != nil {
-			log.Printf("ERROR: Failed to crawl chapters for manga %s: %v", mangaID, err)
-			totalFailed++
+		if result, err := c.crawlChaptersForManga(ctx, mangaID, c.config.NewChaptersOnly, nil); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			c.recordFailure(ctx, EntityManga, mangaID, err)
+			checkpoint.ErrorCount++
 		} else {
-			log.Printf("SUCCESS: Crawled chapters for manga %s", mangaID)
-			totalSuccess++
+			log.Printf("SUCCESS: Crawled chapters for manga %s (%d new)", mangaID, len(result.Inserted))
+			checkpoint.SuccessCount++
 		}
-		totalProcessed++
-
-		// Rate limiting
-		time.Sleep(500 * time.Millisecond)
+		checkpoint.TotalProcessed++
+		checkpoint.LastMangaID = mangaID
+		c.saveProgress(ctx, checkpoint)
 	}
 
-	log.Printf("Chapter crawling completed: %d manga processed, %d success, %d failed",
-		totalProcessed, totalSuccess, totalFailed)
+	c.finishCheckpoint(ctx, checkpoint)
+	jobEvent(ctx, EventInfo, fmt.Sprintf("Chapter crawling completed: %d manga processed, %d success, %d failed",
+		checkpoint.TotalProcessed, checkpoint.SuccessCount, checkpoint.ErrorCount), nil)
 	return nil
 }
 
-// CrawlChaptersForManga crawls chapters for specific manga
-func (c *Crawler) CrawlChaptersForManga(mangaID string) error {
+// ChapterCrawl is the outcome of crawling the chapter list of one manga
+type ChapterCrawl struct {
+	MangaID      string   `json:"manga_id"`
+	Pages        int      `json:"pages"`         // chapter list pages fetched
+	Saved        int      `json:"saved"`         // chapters inserted or updated
+	Inserted     []string `json:"inserted"`      // external IDs of new chapters, newest first
+	StoppedEarly bool     `json:"stopped_early"` // stopped at a page of known chapters
+}
+
+// CrawlChaptersForManga crawls chapters for specific manga (only the new ones
+// if Config.NewChaptersOnly is set)
+func (c *Crawler) CrawlChaptersForManga(ctx context.Context, mangaID string) error {
+	_, err := c.crawlChaptersForManga(ctx, mangaID, c.config.NewChaptersOnly, nil)
+	return err
+}
+
+// CrawlNewChapters crawls the chapter list of a manga newest first and stops
+// after the first page whose chapters are all known, returning the chapters
+// it inserted (in a dry run, the ones it would insert)
+func (c *Crawler) CrawlNewChapters(ctx context.Context, mangaID string) (*ChapterCrawl, error) {
+	return c.crawlChaptersForManga(ctx, mangaID, true, nil)
+}
+
+// crawlChaptersForManga crawls chapters for a manga, passing each saved page
+// of chapters to emit (if not nil) so the pipeline can queue them for pages.
+// With newOnly it stops after a page whose chapter IDs all exist already;
+// a gap further down the list is left to a full crawl.
+func (c *Crawler) crawlChaptersForManga(ctx context.Context, mangaID string, newOnly bool, emit func([]ExternalChapter) error) (*ChapterCrawl, error) {
 	log.Printf("Starting to crawl chapters for manga: %s", mangaID)
+	result := &ChapterCrawl{MangaID: mangaID, Inserted: []string{}}
 	page := 1
-	totalChapters := 0
 
 	for {
-		url := fmt.Sprintf("%s/chapter/%s/list?page=%d&page_size=24&sort_by=chapter_number&sort_order=desc",
-			c.config.BaseURL, mangaID, page)
-
-		log.Printf("Fetching chapters from URL: %s", url)
+		log.Printf("Fetching chapters page %d for manga %s from %s", page, mangaID, c.source.Name())
 
-		var response ChaptersResponse
-		if err := c.fetchJSON(url, &response); err != nil {
-			log.Printf("ERROR: Failed to fetch from URL %s: %v", url, err)
-			return fmt.Errorf("failed to fetch chapters for manga %s page %d: %w", mangaID, page, err)
+		list, err := c.source.ListChapters(ctx, mangaID, page)
+		if err != nil {
+			log.Printf("ERROR: Failed to fetch chapters page %d for manga %s: %v", page, mangaID, err)
+			return nil, fmt.Errorf("failed to fetch chapters for manga %s page %d: %w", mangaID, page, err)
 		}
+		result.Pages++
 
-		log.Printf("API Response retcode: %d, message: %s", response.RetCode, response.Message)
-
-		// Chapters are directly in response.Data
-		chapters := response.Data
+		chapters := list.Chapters
 
 		log.Printf("Found %d chapters on page %d for manga %s", len(chapters), page, mangaID)
 
@@ -684,68 +827,123 @@ func (c *Crawler) CrawlChaptersForManga(mangaID string) error {
 			log.Printf("Found %d chapters on page %d for manga %s", len(chapters), page, mangaID)
 		}
 
-		if !c.config.DryRun {
-			if err := c.saveChaptersList(chapters, mangaID); err != nil {
-				return fmt.Errorf("failed to save chapters: %w", err)
+		// Which chapters exist already (or were quarantined): to stop early, and
+		// to tell what a dry run would insert
+		var known map[string]bool
+		allKnown := false
+		if newOnly || c.config.DryRun {
+			ids := make([]string, len(chapters))
+			for i, chapter := range chapters {
+				ids[i] = chapter.ID
+			}
+			if known, err = c.knownChapterIDs(ctx, ids); err != nil {
+				return nil, err
+			}
+			allKnown = true
+			for _, id := range ids {
+				allKnown = allKnown && known[id]
+			}
+		}
+
+		var inserted []string
+		if c.config.DryRun {
+			for _, chapter := range chapters {
+				if !known[chapter.ID] {
+					inserted = append(inserted, chapter.ID)
+				}
+			}
+		} else {
+			if chapters, inserted, err = c.saveChaptersList(ctx, chapters, mangaID); err != nil {
+				return nil, fmt.Errorf("failed to save chapters: %w", err)
 			}
 		}
 
-		totalChapters += len(chapters)
+		if emit != nil {
+			if err := emit(chapters); err != nil {
+				return nil, err
+			}
+		}
+
+		result.Saved += len(chapters)
+		result.Inserted = append(result.Inserted, inserted...)
 		page++
 
-		// Check if we've reached the last page using pagination metadata from Meta
-		if response.Meta.TotalPage != nil && page > *response.Meta.TotalPage {
-			log.Printf("Reached last page (%d/%d), breaking loop", page-1, *response.Meta.TotalPage)
+		if newOnly && allKnown {
+			log.Printf("Every chapter on page %d is known, stopping", page-1)
+			result.StoppedEarly = true
 			break
 		}
 
-		// Rate limiting
-		time.Sleep(200 * time.Millisecond)
+		// Check if we've reached the last page reported by the source
+		if list.TotalPage > 0 && page > list.TotalPage {
+			log.Printf("Reached last page (%d/%d), breaking loop", page-1, list.TotalPage)
+			break
+		}
 	}
 
-	if c.config.Verbose {
-		log.Printf("Crawled %d chapters for manga %s", totalChapters, mangaID)
+	if c.config.Verbose || len(result.Inserted) > 0 {
+		log.Printf("Crawled %d chapters for manga %s (%d new)", result.Saved, mangaID, len(result.Inserted))
 	}
-	return nil
+	return result, nil
+}
+
+// CrawlAllPages crawls pages for all chapters that have none yet, in external
+// ID order. Progress is checkpointed every Config.BatchSize chapters when ctx
+// carries a job ID (chapters that got their pages are not crawled again anyway).
+func (c *Crawler) CrawlAllPages(ctx context.Context) error {
+	return c.crawlAllPages(ctx, nil)
 }
 
-// CrawlAllPages crawls pages for all chapters
-func (c *Crawler) CrawlAllPages() error {
+// crawlAllPages crawls pages for every chapter after resume.LastChapterID
+// (all chapters if resume is nil)
+func (c *Crawler) crawlAllPages(ctx context.Context, resume *CrawlCheckpoint) error {
 	log.Println("Starting to crawl pages for all chapters...")
 
-	// Get all chapter IDs from database
-	chapterIDs, err := c.getAllChapterIDs()
+	checkpoint := c.startCheckpoint(ctx, "pages", resume)
+
+	// Get remaining chapter IDs from database
+	chapterIDs, err := c.getAllChapterIDs(ctx, checkpoint.LastChapterID)
 	if err != nil {
 		return fmt.Errorf("failed to get chapter IDs: %w", err)
 	}
 
-	log.Printf("Found %d chapters to process pages for", len(chapterIDs))
-
-	totalProcessed := 0
-	totalSuccess := 0
-	totalFailed := 0
+	if checkpoint.LastChapterID != "" {
+		log.Printf("Resuming after chapter %s", checkpoint.LastChapterID)
+	}
+	jobEvent(ctx, EventInfo, fmt.Sprintf("Found %d chapters to process pages for", len(chapterIDs)), nil)
+	checkpoint.EstimatedTotal = checkpoint.TotalProcessed + len(chapterIDs)
 
 	for i, chapterID := range chapterIDs {
+		if err := ctx.Err(); err != nil {
+			c.saveProgress(ctx, checkpoint)
+			return err
+		}
+
 		if i%100 == 0 {
 			log.Printf("Processing pages for chapter %d/%d...", i+1, len(chapterIDs))
 		}
 
-		if err := c.crawlPagesForChapter(chapterID); err != nil {
-			if c.config.Verbose {
-				log.Printf("Failed to crawl pages for chapter %s: %v", chapterID, err)
+		if err := c.crawlPagesForChapter(ctx, chapterID); err != nil {
+			if ctx.Err() != nil {
+				c.saveProgress(ctx, checkpoint)
+				return ctx.Err()
 			}
-			totalFailed++
+			c.recordFailure(ctx, EntityChapter, chapterID, err)
+			checkpoint.ErrorCount++
 		} else {
-			totalSuccess++
+			checkpoint.SuccessCount++
 		}
-		totalProcessed++
+		checkpoint.TotalProcessed++
+		checkpoint.LastChapterID = chapterID
 
-		// Rate limiting
-		time.Sleep(100 * time.Millisecond)
+		if checkpoint.TotalProcessed%c.batchSize() == 0 {
+			c.saveProgress(ctx, checkpoint)
+		}
 	}
 
-	log.Printf("Pages crawling completed: %d chapters processed, %d success, %d failed",
-		totalProcessed, totalSuccess, totalFailed)
+	c.finishCheckpoint(ctx, checkpoint)
+	jobEvent(ctx, EventInfo, fmt.Sprintf("Pages crawling completed: %d chapters processed, %d success, %d failed",
+		checkpoint.TotalProcessed, checkpoint.SuccessCount, checkpoint.ErrorCount), nil)
 	return nil
 }
 
@@ -0,0 +1,262 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"baca-komik-api/database"
+	"baca-komik-api/internal/crawler/crawltest"
+	"baca-komik-api/internal/crawler/fakeapi"
+)
+
+// fixtureMangaID is the fixture manga with 30 chapters
+const fixtureMangaID = "6513270e-269e-4d37-b2a7-4de452e6b438"
+
+// newFakeCrawler returns a crawler reading from srv with fast retries. It is
+// a dry run when db is nil.
+func newFakeCrawler(srv *fakeapi.Server, db *database.DB) *Crawler {
+	return New(db, &Config{
+		BaseURL:           srv.BaseURL(),
+		DryRun:            db == nil,
+		RequestsPerSecond: 1000,
+		Retry:             RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+	})
+}
+
+func TestCrawlMangaFakeAPI(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(srv *fakeapi.Server)
+		endPage int
+		hits    int // manga list requests
+		success int
+		errors  int
+		reason  string // failure reason expected once
+	}{
+		{
+			name:    "paginates to the last page",
+			setup:   func(srv *fakeapi.Server) { srv.SetPageSize(1) },
+			endPage: -1,
+			hits:    3,
+			success: 3,
+		},
+		{
+			name: "stops on an empty page without total_page",
+			setup: func(srv *fakeapi.Server) {
+				srv.SetPageSize(1)
+				srv.HideTotalPage(true)
+			},
+			endPage: -1,
+			hits:    4,
+			success: 3,
+		},
+		{
+			name:    "stops at end_page",
+			setup:   func(srv *fakeapi.Server) { srv.SetPageSize(1) },
+			endPage: 2,
+			hits:    2,
+			success: 2,
+		},
+		{
+			name: "retries 429s",
+			setup: func(srv *fakeapi.Server) {
+				srv.Inject(fakeapi.Fault{PathPrefix: "/manga/list", Status: 429, Times: 2})
+			},
+			endPage: -1,
+			hits:    3,
+			success: 3,
+		},
+		{
+			name: "skips a page still 429 after every retry",
+			setup: func(srv *fakeapi.Server) {
+				srv.SetPageSize(1)
+				srv.Inject(fakeapi.Fault{PathPrefix: "/manga/list", Status: 429, Times: 3})
+			},
+			endPage: -1,
+			hits:    5,
+			success: 2,
+			errors:  1,
+			reason:  "http_429",
+		},
+		{
+			name: "skips a page of malformed JSON",
+			setup: func(srv *fakeapi.Server) {
+				srv.SetPageSize(1)
+				srv.Inject(fakeapi.Fault{PathPrefix: "/manga/list", Malformed: true, Times: 1})
+			},
+			endPage: -1,
+			hits:    3,
+			success: 2,
+			errors:  1,
+			reason:  "error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := fakeapi.New(fakeapi.DefaultFixtures())
+			defer srv.Close()
+			tt.setup(srv)
+
+			c := newFakeCrawler(srv, nil)
+			checkpoint, _, err := c.crawlManga(context.Background(), 1, tt.endPage, nil, nil)
+			if err != nil {
+				t.Fatalf("crawlManga: %v", err)
+			}
+
+			if got := srv.Hits("/manga/list"); got != tt.hits {
+				t.Errorf("manga list requests = %d, want %d", got, tt.hits)
+			}
+			if checkpoint.SuccessCount != tt.success || checkpoint.ErrorCount != tt.errors {
+				t.Errorf("success/errors = %d/%d, want %d/%d",
+					checkpoint.SuccessCount, checkpoint.ErrorCount, tt.success, tt.errors)
+			}
+			if tt.reason != "" {
+				if got := c.Failures(10).ByReason[tt.reason]; got != 1 {
+					t.Errorf("failures with reason %s = %d, want 1", tt.reason, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCrawlChaptersForMangaFakeAPI(t *testing.T) {
+	db := crawltest.DB(t)
+	fixtures := fakeapi.DefaultFixtures()
+	mangaIDs := crawltest.MangaIDs(t, fixtures)
+	crawltest.DeleteManga(t, db, mangaIDs)
+	t.Cleanup(func() { crawltest.DeleteManga(t, db, mangaIDs) })
+
+	srv := fakeapi.New(fixtures)
+	defer srv.Close()
+	ctx := context.Background()
+	c := newFakeCrawler(srv, db)
+
+	if err := c.CrawlManga(ctx, 1, -1); err != nil {
+		t.Fatalf("CrawlManga: %v", err)
+	}
+	for _, id := range mangaIDs {
+		if !crawltest.MangaExists(t, db, id) {
+			t.Fatalf("manga %s was not saved", id)
+		}
+	}
+
+	listPath := "/chapter/" + fixtureMangaID + "/list"
+	srv.SetPageSize(10)
+
+	t.Run("paginates the chapter list", func(t *testing.T) {
+		result, err := c.crawlChaptersForManga(ctx, fixtureMangaID, false, nil)
+		if err != nil {
+			t.Fatalf("crawlChaptersForManga: %v", err)
+		}
+		if result.Pages != 3 || result.Saved != 30 || len(result.Inserted) != 30 {
+			t.Errorf("pages/saved/inserted = %d/%d/%d, want 3/30/30", result.Pages, result.Saved, len(result.Inserted))
+		}
+		if got := crawltest.CountChapters(t, db, fixtureMangaID); got != 30 {
+			t.Errorf("stored chapters = %d, want 30", got)
+		}
+	})
+
+	t.Run("stops on an empty page without total_page", func(t *testing.T) {
+		srv.HideTotalPage(true)
+		defer srv.HideTotalPage(false)
+
+		before := srv.Hits(listPath)
+		if err := c.CrawlChaptersForManga(ctx, fixtureMangaID); err != nil {
+			t.Fatalf("CrawlChaptersForManga: %v", err)
+		}
+		if got := srv.Hits(listPath) - before; got != 4 {
+			t.Errorf("chapter list requests = %d, want 4", got)
+		}
+	})
+
+	t.Run("new chapters only stops at a known page", func(t *testing.T) {
+		result, err := c.CrawlNewChapters(ctx, fixtureMangaID)
+		if err != nil {
+			t.Fatalf("CrawlNewChapters: %v", err)
+		}
+		if result.Pages != 1 || !result.StoppedEarly || len(result.Inserted) != 0 {
+			t.Errorf("pages/stopped early/inserted = %d/%v/%d, want 1/true/0",
+				result.Pages, result.StoppedEarly, len(result.Inserted))
+		}
+	})
+
+	t.Run("retries 429s", func(t *testing.T) {
+		srv.Inject(fakeapi.Fault{PathPrefix: listPath, Status: 429, Times: 2})
+		before := srv.Hits(listPath)
+		if _, err := c.CrawlNewChapters(ctx, fixtureMangaID); err != nil {
+			t.Fatalf("CrawlNewChapters: %v", err)
+		}
+		if got := srv.Hits(listPath) - before; got != 3 {
+			t.Errorf("chapter list requests = %d, want 3", got)
+		}
+	})
+
+	t.Run("fails on malformed JSON", func(t *testing.T) {
+		srv.Inject(fakeapi.Fault{PathPrefix: listPath, Malformed: true, Times: 1})
+		if err := c.CrawlChaptersForManga(ctx, fixtureMangaID); err == nil {
+			t.Fatal("CrawlChaptersForManga succeeded on malformed JSON")
+		}
+		if got := crawltest.CountChapters(t, db, fixtureMangaID); got != 30 {
+			t.Errorf("stored chapters = %d, want 30", got)
+		}
+	})
+}
+
+func TestCrawlNewChaptersStopsAtQuarantinedChapter(t *testing.T) {
+	db := crawltest.DB(t)
+	fixtures := fakeapi.Synthetic(fakeapi.SyntheticSpec{Manga: 1, ChaptersPerManga: 10, PagesPerChapter: 1}).(fstest.MapFS)
+	mangaIDs := crawltest.MangaIDs(t, fixtures)
+	mangaID := mangaIDs[0]
+
+	// The newest chapter claims another manga, so it is quarantined
+	name := "chapters/" + mangaID + ".json"
+	var chapters []map[string]interface{}
+	if err := json.Unmarshal(fixtures[name].Data, &chapters); err != nil {
+		t.Fatalf("failed to parse %s: %v", name, err)
+	}
+	invalidID := chapters[0]["chapter_id"].(string)
+	chapters[0]["manga_id"] = "another-manga"
+	data, err := json.Marshal(chapters)
+	if err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	fixtures[name] = &fstest.MapFile{Data: data}
+
+	deleteAll := func() {
+		crawltest.DeleteManga(t, db, mangaIDs)
+		if _, err := db.Pool.Exec(context.Background(),
+			`DELETE FROM "mCrawlQuarantine" WHERE entity_type = $1 AND external_id = $2`, PayloadChapter, invalidID); err != nil {
+			t.Fatalf("failed to delete quarantined chapter: %v", err)
+		}
+	}
+	deleteAll()
+	t.Cleanup(deleteAll)
+
+	srv := fakeapi.New(fixtures)
+	defer srv.Close()
+	srv.SetPageSize(5)
+	ctx := context.Background()
+	c := newFakeCrawler(srv, db)
+
+	if err := c.CrawlManga(ctx, 1, -1); err != nil {
+		t.Fatalf("CrawlManga: %v", err)
+	}
+	if err := c.CrawlChaptersForManga(ctx, mangaID); err != nil {
+		t.Fatalf("CrawlChaptersForManga: %v", err)
+	}
+	if got := crawltest.CountChapters(t, db, mangaID); got != 9 {
+		t.Fatalf("stored chapters = %d, want 9", got)
+	}
+
+	result, err := c.CrawlNewChapters(ctx, mangaID)
+	if err != nil {
+		t.Fatalf("CrawlNewChapters: %v", err)
+	}
+	if result.Pages != 1 || !result.StoppedEarly {
+		t.Errorf("pages/stopped early = %d/%v, want 1/true", result.Pages, result.StoppedEarly)
+	}
+}
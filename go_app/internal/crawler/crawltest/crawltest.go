@@ -0,0 +1,110 @@
+// Package crawltest holds database helpers for tests that run the crawler
+// and the auto-updater against the fake upstream (see fakeapi).
+//
+// Tests that write go to the database in TEST_DATABASE_URL, which must be a
+// disposable database with the app schema and every file in migrations/
+// applied. Without it they are skipped.
+package crawltest
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"testing"
+
+	"baca-komik-api/database"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DB connects to TEST_DATABASE_URL, or skips the test if it is not set
+func DB(t testing.TB) *database.DB {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return &database.DB{Pool: pool}
+}
+
+// MangaIDs returns the upstream IDs of the manga in a fixture set's manga.json
+func MangaIDs(t testing.TB, fixtures fs.FS) []string {
+	t.Helper()
+
+	data, err := fs.ReadFile(fixtures, "manga.json")
+	if err != nil {
+		t.Fatalf("failed to read manga fixtures: %v", err)
+	}
+	var manga []struct {
+		ID string `json:"manga_id"`
+	}
+	if err := json.Unmarshal(data, &manga); err != nil {
+		t.Fatalf("failed to parse manga fixtures: %v", err)
+	}
+
+	ids := make([]string, len(manga))
+	for i, m := range manga {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+// DeleteManga deletes the comics with the given upstream IDs and everything
+// crawled for them, so each test starts from an empty catalog
+func DeleteManga(t testing.TB, db *database.DB, externalIDs []string) {
+	t.Helper()
+
+	const komik = `SELECT id FROM "mKomik" WHERE external_id = ANY($1)`
+	statements := []string{
+		`DELETE FROM "trChapter" WHERE id_chapter IN (SELECT id FROM "mChapter" WHERE id_komik IN (` + komik + `))`,
+		`DELETE FROM "mChapter" WHERE id_komik IN (` + komik + `)`,
+		`DELETE FROM "trGenre" WHERE id_komik IN (` + komik + `)`,
+		`DELETE FROM "trAuthor" WHERE id_komik IN (` + komik + `)`,
+		`DELETE FROM "trArtist" WHERE id_komik IN (` + komik + `)`,
+		`DELETE FROM "trFormat" WHERE id_komik IN (` + komik + `)`,
+		`DELETE FROM "trType" WHERE id_komik IN (` + komik + `)`,
+		`DELETE FROM "mChangeLog" WHERE komik_id IN (` + komik + `)`,
+		`DELETE FROM "mKomikDuplicate" WHERE external_id = ANY($1)`,
+		`DELETE FROM "mKomik" WHERE external_id = ANY($1)`,
+		`DELETE FROM "mRawPayload" WHERE external_id = ANY($1) OR payload->>'manga_id' = ANY($1)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Pool.Exec(context.Background(), stmt, externalIDs); err != nil {
+			t.Fatalf("failed to delete test manga: %v", err)
+		}
+	}
+}
+
+// CountChapters returns how many chapters are stored for a manga by upstream ID
+func CountChapters(t testing.TB, db *database.DB, mangaID string) int {
+	t.Helper()
+
+	var n int
+	if err := db.Pool.QueryRow(context.Background(), `
+		SELECT COUNT(*) FROM "mChapter" c
+		JOIN "mKomik" k ON k.id = c.id_komik
+		WHERE k.external_id = $1
+	`, mangaID).Scan(&n); err != nil {
+		t.Fatalf("failed to count chapters of %s: %v", mangaID, err)
+	}
+	return n
+}
+
+// MangaExists reports whether a manga is stored by upstream ID
+func MangaExists(t testing.TB, db *database.DB, mangaID string) bool {
+	t.Helper()
+
+	var exists bool
+	if err := db.Pool.QueryRow(context.Background(),
+		`SELECT EXISTS (SELECT 1 FROM "mKomik" WHERE external_id = $1)`, mangaID).Scan(&exists); err != nil {
+		t.Fatalf("failed to look up manga %s: %v", mangaID, err)
+	}
+	return exists
+}
@@ -5,30 +5,31 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 )
 
 // saveGenres saves genres to database
-func (c *Crawler) saveGenres(genres []ExternalGenre) error {
-	ctx := context.Background()
+func (c *Crawler) saveGenres(ctx context.Context, genres []ExternalGenre) error {
 	tx, err := c.db.Pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer tx.Rollback(ctx)
+	defer tx.Rollback(context.WithoutCancel(ctx))
 
 	for _, genre := range genres {
 		query := `
-			INSERT INTO "mGenre" (id, name, created_at, updated_at)
-			VALUES ($1, $2, NOW(), NOW())
+			INSERT INTO "mGenre" (id, name, slug, created_at, updated_at)
+			VALUES ($1, $2, NULLIF($3, ''), NOW(), NOW())
 			ON CONFLICT (name) DO UPDATE SET
 				name = EXCLUDED.name,
+				slug = COALESCE(EXCLUDED.slug, "mGenre".slug),
 				updated_at = NOW()
 		`
 
 		genreID := generateUUID()
-		if _, err := tx.Exec(ctx, query, genreID, genre.Name); err != nil {
+		if _, err := tx.Exec(ctx, query, genreID, genre.Name, genre.Slug); err != nil {
 			return fmt.Errorf("failed to insert genre %s: %w", genre.Name, err)
 		}
 	}
@@ -42,25 +43,25 @@ func (c *Crawler) saveGenres(genres []ExternalGenre) error {
 }
 
 // saveFormats saves formats to database
-func (c *Crawler) saveFormats(formats []ExternalFormat) error {
-	ctx := context.Background()
+func (c *Crawler) saveFormats(ctx context.Context, formats []ExternalFormat) error {
 	tx, err := c.db.Pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer tx.Rollback(ctx)
+	defer tx.Rollback(context.WithoutCancel(ctx))
 
 	for _, format := range formats {
 		query := `
-			INSERT INTO "mFormat" (id, name, created_at, updated_at)
-			VALUES ($1, $2, NOW(), NOW())
+			INSERT INTO "mFormat" (id, name, slug, created_at, updated_at)
+			VALUES ($1, $2, NULLIF($3, ''), NOW(), NOW())
 			ON CONFLICT (name) DO UPDATE SET
 				name = EXCLUDED.name,
+				slug = COALESCE(EXCLUDED.slug, "mFormat".slug),
 				updated_at = NOW()
 		`
 
 		formatID := generateUUID()
-		if _, err := tx.Exec(ctx, query, formatID, format.Name); err != nil {
+		if _, err := tx.Exec(ctx, query, formatID, format.Name, format.Slug); err != nil {
 			return fmt.Errorf("failed to insert format %s: %w", format.Name, err)
 		}
 	}
@@ -74,25 +75,25 @@ func (c *Crawler) saveFormats(formats []ExternalFormat) error {
 }
 
 // saveTypes saves types to database
-func (c *Crawler) saveTypes(types []ExternalType) error {
-	ctx := context.Background()
+func (c *Crawler) saveTypes(ctx context.Context, types []ExternalType) error {
 	tx, err := c.db.Pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer tx.Rollback(ctx)
+	defer tx.Rollback(context.WithoutCancel(ctx))
 
 	for _, typ := range types {
 		query := `
-			INSERT INTO "mType" (id, name, created_at, updated_at)
-			VALUES ($1, $2, NOW(), NOW())
+			INSERT INTO "mType" (id, name, slug, created_at, updated_at)
+			VALUES ($1, $2, NULLIF($3, ''), NOW(), NOW())
 			ON CONFLICT (name) DO UPDATE SET
 				name = EXCLUDED.name,
+				slug = COALESCE(EXCLUDED.slug, "mType".slug),
 				updated_at = NOW()
 		`
 
 		typeID := generateUUID()
-		if _, err := tx.Exec(ctx, query, typeID, typ.Name); err != nil {
+		if _, err := tx.Exec(ctx, query, typeID, typ.Name, typ.Slug); err != nil {
 			return fmt.Errorf("failed to insert type %s: %w", typ.Name, err)
 		}
 	}
@@ -106,25 +107,25 @@ func (c *Crawler) saveTypes(types []ExternalType) error {
 }
 
 // saveAuthors saves authors to database
-func (c *Crawler) saveAuthors(authors []ExternalAuthor) error {
-	ctx := context.Background()
+func (c *Crawler) saveAuthors(ctx context.Context, authors []ExternalAuthor) error {
 	tx, err := c.db.Pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer tx.Rollback(ctx)
+	defer tx.Rollback(context.WithoutCancel(ctx))
 
 	for _, author := range authors {
 		query := `
-			INSERT INTO "mAuthor" (id, name, created_at, updated_at)
-			VALUES ($1, $2, NOW(), NOW())
+			INSERT INTO "mAuthor" (id, name, slug, created_at, updated_at)
+			VALUES ($1, $2, NULLIF($3, ''), NOW(), NOW())
 			ON CONFLICT (name) DO UPDATE SET
 				name = EXCLUDED.name,
+				slug = COALESCE(EXCLUDED.slug, "mAuthor".slug),
 				updated_at = NOW()
 		`
 
 		authorID := generateUUID()
-		if _, err := tx.Exec(ctx, query, authorID, author.Name); err != nil {
+		if _, err := tx.Exec(ctx, query, authorID, author.Name, author.Slug); err != nil {
 			return fmt.Errorf("failed to insert author %s: %w", author.Name, err)
 		}
 	}
@@ -138,25 +139,25 @@ func (c *Crawler) saveAuthors(authors []ExternalAuthor) error {
 }
 
 // saveArtists saves artists to database
-func (c *Crawler) saveArtists(artists []ExternalArtist) error {
-	ctx := context.Background()
+func (c *Crawler) saveArtists(ctx context.Context, artists []ExternalArtist) error {
 	tx, err := c.db.Pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer tx.Rollback(ctx)
+	defer tx.Rollback(context.WithoutCancel(ctx))
 
 	for _, artist := range artists {
 		query := `
-			INSERT INTO "mArtist" (id, name, created_at, updated_at)
-			VALUES ($1, $2, NOW(), NOW())
+			INSERT INTO "mArtist" (id, name, slug, created_at, updated_at)
+			VALUES ($1, $2, NULLIF($3, ''), NOW(), NOW())
 			ON CONFLICT (name) DO UPDATE SET
 				name = EXCLUDED.name,
+				slug = COALESCE(EXCLUDED.slug, "mArtist".slug),
 				updated_at = NOW()
 		`
 
 		artistID := generateUUID()
-		if _, err := tx.Exec(ctx, query, artistID, artist.Name); err != nil {
+		if _, err := tx.Exec(ctx, query, artistID, artist.Name, artist.Slug); err != nil {
 			return fmt.Errorf("failed to insert artist %s: %w", artist.Name, err)
 		}
 	}
@@ -170,56 +171,77 @@ func (c *Crawler) saveArtists(artists []ExternalArtist) error {
 }
 
 // SaveMangaList saves manga list to database with duplicate detection (public method)
-func (c *Crawler) SaveMangaList(mangaList []ExternalManga) error {
-	return c.saveMangaList(mangaList)
+func (c *Crawler) SaveMangaList(ctx context.Context, mangaList []ExternalManga) error {
+	return c.saveMangaList(ctx, mangaList)
 }
 
 // saveMangaList saves manga list to database with duplicate detection
-func (c *Crawler) saveMangaList(mangaList []ExternalManga) error {
-	ctx := context.Background()
+func (c *Crawler) saveMangaList(ctx context.Context, mangaList []ExternalManga) error {
+	payloads := make([]rawPayload, len(mangaList))
+	for i, manga := range mangaList {
+		payloads[i] = rawPayload{externalID: manga.ID, data: manga.Raw}
+	}
+	c.archivePayloads(ctx, PayloadManga, payloads)
+	mangaList = c.validMangaList(ctx, mangaList)
+
 	tx, err := c.db.Pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer tx.Rollback(ctx)
+	defer tx.Rollback(context.WithoutCancel(ctx))
 
 	savedCount := 0
 	skippedCount := 0
 
 	for _, manga := range mangaList {
-		// Check for potential duplicates
-		duplicates, err := c.checkMangaDuplicates(ctx, tx, manga.Title, manga.ID)
-		if err != nil {
-			log.Printf("Warning: Failed to check duplicates for %s: %v", manga.Title, err)
+		// Convert release year from string to int
+		var releaseYear *int
+		if manga.ReleaseYear != nil && *manga.ReleaseYear != "" {
+			if year, err := strconv.Atoi(*manga.ReleaseYear); err == nil {
+				releaseYear = &year
+			}
+		}
+
+		// Manga merged into another comic during duplicate review stay merged
+		if mergedID, err := mergedMangaID(ctx, tx, manga.ID); err != nil {
+			return fmt.Errorf("failed to check merged manga %s: %w", manga.ID, err)
+		} else if mergedID != "" {
+			if c.config.Verbose {
+				log.Printf("Skipping merged manga: %s (merged into %s)", manga.Title, mergedID)
+			}
+			skippedCount++
+			continue
 		}
 
-		if len(duplicates) > 0 {
-			// Found potential duplicate
-			bestMatch := duplicates[0]
-			if bestMatch.SimilarityScore >= 0.9 {
-				log.Printf("Skipping duplicate manga: %s (matches existing: %s, score: %.2f)",
+		// Check if manga with this external_id already exists
+		var existingID string
+		checkQuery := `SELECT id FROM "mKomik" WHERE external_id = $1`
+		err = tx.QueryRow(ctx, checkQuery, manga.ID).Scan(&existingID)
+
+		// New manga: look for the same work stored under another title or source
+		var duplicates []DuplicateMatch
+		if err != nil {
+			duplicates, err = c.findMangaDuplicates(ctx, tx, manga, releaseYear)
+			if err != nil {
+				log.Printf("Warning: Failed to check duplicates for %s: %v", manga.Title, err)
+			}
+
+			// A near-certain match without an upstream ID is the same comic
+			// added by hand: map it instead of inserting a copy
+			if len(duplicates) > 0 && duplicates[0].ExternalID == "" &&
+				duplicates[0].SimilarityScore >= duplicateAutoMatchScore {
+				bestMatch := duplicates[0]
+				log.Printf("Mapping manga %s to existing comic: %s (score: %.2f)",
 					manga.Title, bestMatch.Title, bestMatch.SimilarityScore)
 
-				// Update external_id if not set
-				if bestMatch.ExternalID == "" {
-					updateQuery := `UPDATE "mKomik" SET external_id = $1, data_source = 'crawled_mapped', updated_at = NOW() WHERE id = $2`
-					if _, err := tx.Exec(ctx, updateQuery, manga.ID, bestMatch.ID); err != nil {
-						log.Printf("Warning: Failed to update external_id for %s: %v", bestMatch.ID, err)
-					} else {
-						log.Printf("Updated external_id for existing manga: %s", bestMatch.Title)
-					}
+				updateQuery := `UPDATE "mKomik" SET external_id = $1, data_source = 'crawled_mapped', updated_at = NOW() WHERE id = $2`
+				if _, err := tx.Exec(ctx, updateQuery, manga.ID, bestMatch.ID); err != nil {
+					return fmt.Errorf("failed to map manga %s to %s: %w", manga.ID, bestMatch.ID, err)
 				}
 				skippedCount++
 				continue
 			}
 		}
-		// Convert release year from string to int
-		var releaseYear *int
-		if manga.ReleaseYear != nil && *manga.ReleaseYear != "" {
-			if year, err := strconv.Atoi(*manga.ReleaseYear); err == nil {
-				releaseYear = &year
-			}
-		}
 
 		// Convert status from int to enum string
 		var statusStr string
@@ -249,35 +271,35 @@ func (c *Crawler) saveMangaList(mangaList []ExternalManga) error {
 			countryStr = manga.CountryID // Use as-is for others
 		}
 
-		// Check if manga with this external_id already exists
-		var existingID string
-		checkQuery := `SELECT id FROM "mKomik" WHERE external_id = $1`
-		err = tx.QueryRow(ctx, checkQuery, manga.ID).Scan(&existingID)
-
-		if err == nil {
-			// Manga exists, update it
+		if existingID != "" {
+			// Manga exists, update it and log the fields that changed
 			updateQuery := `
-				UPDATE "mKomik" SET
-					title = $2,
-					alternative_title = $3,
-					description = $4,
-					status = $5,
-					view_count = $6,
-					vote_count = $7,
-					bookmark_count = $8,
-					cover_image_url = $9,
-					rank = $10,
-					release_year = $11,
-					data_source = $12,
-					updated_at = NOW()
-				WHERE external_id = $1
-			`
+				WITH updated AS (
+					UPDATE "mKomik" cur SET
+						title = $2,
+						alternative_title = $3,
+						description = $4,
+						status = $5,
+						view_count = $6,
+						vote_count = $7,
+						bookmark_count = $8,
+						cover_image_url = $9,
+						rank = $10,
+						release_year = $11,
+						data_source = $12,
+						removed_at = NULL,
+						updated_at = NOW()
+					FROM "mKomik" prev
+					WHERE prev.id = cur.id AND cur.external_id = $1
+					` + changeReturning("id", trackedKomikFields) + `
+				)
+			` + insertChanges(ChangeKomik, trackedKomikFields, 13)
 
 			if _, err := tx.Exec(ctx, updateQuery,
 				manga.ID, manga.Title, manga.AlternativeTitle, manga.Description,
 				statusStr, manga.ViewCount, manga.VoteCount,
 				manga.BookmarkCount, manga.CoverImageURL,
-				manga.Rank, releaseYear, "crawled",
+				manga.Rank, releaseYear, "crawled", JobIDFromContext(ctx),
 			); err != nil {
 				return fmt.Errorf("failed to update manga %s: %w", manga.ID, err)
 			}
@@ -310,6 +332,15 @@ func (c *Crawler) saveMangaList(mangaList []ExternalManga) error {
 			if c.config.Verbose {
 				log.Printf("Inserted new manga: %s", manga.Title)
 			}
+
+			// Ambiguous candidates are left to an admin (see ResolveDuplicate)
+			for _, match := range duplicates {
+				log.Printf("Possible duplicate queued for review: %s ~ %s (score: %.2f)",
+					manga.Title, match.Title, match.SimilarityScore)
+				if err := c.queueDuplicateReview(ctx, tx, newID, manga, match); err != nil {
+					return err
+				}
+			}
 		}
 
 		savedCount++
@@ -340,6 +371,12 @@ func (c *Crawler) saveMangaList(mangaList []ExternalManga) error {
 					return fmt.Errorf("failed to save formats for manga %s: %w", manga.ID, err)
 				}
 			}
+
+			if len(manga.Taxonomy.Type) > 0 {
+				if err := c.saveMangaTypes(ctx, tx, actualID, manga.Taxonomy.Type); err != nil {
+					return fmt.Errorf("failed to save types for manga %s: %w", manga.ID, err)
+				}
+			}
 		}
 	}
 
@@ -347,59 +384,53 @@ func (c *Crawler) saveMangaList(mangaList []ExternalManga) error {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	log.Printf("Manga processing completed: %d saved, %d skipped (duplicates)", savedCount, skippedCount)
+	log.Printf("Manga processing completed: %d saved, %d skipped (mapped or merged)", savedCount, skippedCount)
 	return nil
 }
 
-// Duplicate detection structures
-type DuplicateMatch struct {
-	ID              string  `db:"id"`
-	Title           string  `db:"title"`
-	ExternalID      string  `db:"external_id"`
-	SimilarityScore float64 `db:"similarity_score"`
+// taxonomyTables are the master data tables ensureTaxonomy may write to
+var taxonomyTables = map[string]bool{
+	"mGenre": true, "mAuthor": true, "mArtist": true, "mFormat": true, "mType": true,
 }
 
-// checkMangaDuplicates checks for potential duplicate manga
-func (c *Crawler) checkMangaDuplicates(ctx context.Context, tx pgx.Tx, title, externalID string) ([]DuplicateMatch, error) {
-	query := `
-		SELECT
-			id,
-			title,
-			COALESCE(external_id, '') as external_id,
-			CASE
-				WHEN external_id = $2 AND $2 != '' THEN 1.0
-				WHEN LOWER(title) = LOWER($1) THEN 0.9
-				WHEN LOWER(title) LIKE '%' || LOWER($1) || '%' THEN 0.7
-				WHEN LOWER($1) LIKE '%' || LOWER(title) || '%' THEN 0.7
-				ELSE 0.0
-			END as similarity_score
-		FROM "mKomik"
-		WHERE
-			(external_id = $2 AND $2 != '')
-			OR LOWER(title) LIKE '%' || LOWER($1) || '%'
-			OR LOWER($1) LIKE '%' || LOWER(title) || '%'
-		ORDER BY similarity_score DESC
-		LIMIT 5
-	`
-
-	rows, err := tx.Query(ctx, query, title, externalID)
-	if err != nil {
-		return nil, err
+// ensureTaxonomy returns the ID of the genre, author, artist, format or type
+// (table) named name, creating it with the upstream slug if master data does
+// not have it yet, and filling in the slug if it was missing. Returns "" for
+// an empty name.
+func (c *Crawler) ensureTaxonomy(ctx context.Context, tx pgx.Tx, table, name, slug string) (string, error) {
+	if !taxonomyTables[table] {
+		return "", fmt.Errorf("unknown taxonomy table: %s", table)
 	}
-	defer rows.Close()
-
-	var matches []DuplicateMatch
-	for rows.Next() {
-		var match DuplicateMatch
-		if err := rows.Scan(&match.ID, &match.Title, &match.ExternalID, &match.SimilarityScore); err != nil {
-			return nil, err
-		}
-		if match.SimilarityScore > 0.5 { // Only include meaningful matches
-			matches = append(matches, match)
-		}
+	if name == "" {
+		return "", nil
 	}
 
-	return matches, rows.Err()
+	var id string
+	var existingSlug *string
+	err := tx.QueryRow(ctx, fmt.Sprintf(`SELECT id, slug FROM "%s" WHERE name = $1`, table), name).Scan(&id, &existingSlug)
+	if err == nil && (slug == "" || existingSlug != nil) {
+		return id, nil
+	}
+	if err != nil && err != pgx.ErrNoRows {
+		return "", fmt.Errorf("failed to look up %s %s: %w", table, name, err)
+	}
+	created := err == pgx.ErrNoRows
+
+	query := fmt.Sprintf(`
+		INSERT INTO "%[1]s" (id, name, slug, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), NOW(), NOW())
+		ON CONFLICT (name) DO UPDATE SET
+			slug = COALESCE("%[1]s".slug, EXCLUDED.slug),
+			updated_at = NOW()
+		RETURNING id
+	`, table)
+	if err := tx.QueryRow(ctx, query, generateUUID(), name, slug).Scan(&id); err != nil {
+		return "", fmt.Errorf("failed to save %s %s: %w", table, name, err)
+	}
+	if created && c.config.Verbose {
+		log.Printf("Created %s from manga taxonomy: %s", table, name)
+	}
+	return id, nil
 }
 
 // Helper functions for manga relationships
@@ -411,11 +442,12 @@ func (c *Crawler) saveMangaGenres(ctx context.Context, tx pgx.Tx, mangaID string
 
 	// Insert new relationships
 	for _, genre := range genres {
-		// Get genre ID by name
-		var genreID string
-		err := tx.QueryRow(ctx, `SELECT id FROM "mGenre" WHERE name = $1`, genre.Name).Scan(&genreID)
+		// Get genre ID by name, creating genres missing from master data
+		genreID, err := c.ensureTaxonomy(ctx, tx, "mGenre", genre.Name, genre.Slug)
 		if err != nil {
-			log.Printf("Warning: Genre not found: %s", genre.Name)
+			return err
+		}
+		if genreID == "" {
 			continue
 		}
 
@@ -435,11 +467,12 @@ func (c *Crawler) saveMangaAuthors(ctx context.Context, tx pgx.Tx, mangaID strin
 
 	// Insert new relationships
 	for _, author := range authors {
-		// Get author ID by name
-		var authorID string
-		err := tx.QueryRow(ctx, `SELECT id FROM "mAuthor" WHERE name = $1`, author.Name).Scan(&authorID)
+		// Get author ID by name, creating authors missing from master data
+		authorID, err := c.ensureTaxonomy(ctx, tx, "mAuthor", author.Name, author.Slug)
 		if err != nil {
-			log.Printf("Warning: Author not found: %s", author.Name)
+			return err
+		}
+		if authorID == "" {
 			continue
 		}
 
@@ -459,11 +492,12 @@ func (c *Crawler) saveMangaArtists(ctx context.Context, tx pgx.Tx, mangaID strin
 
 	// Insert new relationships
 	for _, artist := range artists {
-		// Get artist ID by name
-		var artistID string
-		err := tx.QueryRow(ctx, `SELECT id FROM "mArtist" WHERE name = $1`, artist.Name).Scan(&artistID)
+		// Get artist ID by name, creating artists missing from master data
+		artistID, err := c.ensureTaxonomy(ctx, tx, "mArtist", artist.Name, artist.Slug)
 		if err != nil {
-			log.Printf("Warning: Artist not found: %s", artist.Name)
+			return err
+		}
+		if artistID == "" {
 			continue
 		}
 
@@ -483,11 +517,12 @@ func (c *Crawler) saveMangaFormats(ctx context.Context, tx pgx.Tx, mangaID strin
 
 	// Insert new relationships
 	for _, format := range formats {
-		// Get format ID by name
-		var formatID string
-		err := tx.QueryRow(ctx, `SELECT id FROM "mFormat" WHERE name = $1`, format.Name).Scan(&formatID)
+		// Get format ID by name, creating formats missing from master data
+		formatID, err := c.ensureTaxonomy(ctx, tx, "mFormat", format.Name, format.Slug)
 		if err != nil {
-			log.Printf("Warning: Format not found: %s", format.Name)
+			return err
+		}
+		if formatID == "" {
 			continue
 		}
 
@@ -499,22 +534,220 @@ func (c *Crawler) saveMangaFormats(ctx context.Context, tx pgx.Tx, mangaID strin
 	return nil
 }
 
-// saveChaptersList saves chapters to database
-func (c *Crawler) saveChaptersList(chapters []ExternalChapter, mangaID string) error {
-	ctx := context.Background()
+func (c *Crawler) saveMangaTypes(ctx context.Context, tx pgx.Tx, mangaID string, types []ExternalType) error {
+	// Delete existing relationships
+	if _, err := tx.Exec(ctx, `DELETE FROM "trType" WHERE id_komik = $1`, mangaID); err != nil {
+		return err
+	}
+
+	// Insert new relationships
+	for _, typ := range types {
+		// Get type ID by name, creating types missing from master data
+		typeID, err := c.ensureTaxonomy(ctx, tx, "mType", typ.Name, typ.Slug)
+		if err != nil {
+			return err
+		}
+		if typeID == "" {
+			continue
+		}
+
+		query := `INSERT INTO "trType" (id_komik, id_type) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+		if _, err := tx.Exec(ctx, query, mangaID, typeID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveChaptersList saves chapters to database and returns the ones saved
+// (invalid chapters are quarantined instead) and the external IDs of those
+// that were new, in list order
+func (c *Crawler) saveChaptersList(ctx context.Context, chapters []ExternalChapter, mangaID string) ([]ExternalChapter, []string, error) {
+	payloads := make([]rawPayload, len(chapters))
+	for i, chapter := range chapters {
+		payloads[i] = rawPayload{externalID: chapter.ID, data: chapter.Raw}
+	}
+	c.archivePayloads(ctx, PayloadChapter, payloads)
+	chapters = c.validChapters(ctx, chapters, mangaID)
+
 	tx, err := c.db.Pool.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	// Get internal manga ID (following merges of duplicate manga)
+	internalMangaID, err := internalMangaID(ctx, tx, mangaID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get internal manga ID for %s: %w", mangaID, err)
+	}
+
+	var inserted map[string]bool
+	if c.config.RowByRowWrites {
+		inserted, err = upsertChaptersRowByRow(ctx, tx, internalMangaID, chapters)
+	} else {
+		inserted, err = upsertChapters(ctx, tx, internalMangaID, chapters)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	insertedIDs := []string{}
+	for _, chapter := range chapters {
+		if inserted[chapter.ID] {
+			insertedIDs = append(insertedIDs, chapter.ID)
+		}
+	}
+	return chapters, insertedIDs, nil
+}
+
+// knownChapterIDs returns which of externalIDs are already in "mChapter", or
+// were quarantined (so an invalid chapter does not look new on every crawl)
+func (c *Crawler) knownChapterIDs(ctx context.Context, externalIDs []string) (map[string]bool, error) {
+	rows, err := c.db.Pool.Query(ctx, `
+		SELECT external_id FROM "mChapter" WHERE external_id = ANY($1)
+		UNION
+		SELECT external_id FROM "mCrawlQuarantine" WHERE entity_type = $2 AND external_id = ANY($1)
+	`, externalIDs, PayloadChapter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up known chapters: %w", err)
+	}
+	defer rows.Close()
+
+	known := make(map[string]bool, len(externalIDs))
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		known[id] = true
+	}
+	return known, rows.Err()
+}
+
+// upsertChapters updates the chapters of a manga that already exist (matched
+// by external_id, or else by chapter number) and inserts the rest, in a single
+// statement, returning the external IDs inserted. If a page lists a chapter
+// number twice the last one wins, as it did when chapters were saved one at a
+// time. Changed fields of updated chapters are logged to "mChangeLog".
+func upsertChapters(ctx context.Context, tx pgx.Tx, internalMangaID string, chapters []ExternalChapter) (map[string]bool, error) {
+	chapters = lastPerChapterNumber(chapters)
+	if len(chapters) == 0 {
+		return nil, nil
 	}
-	defer tx.Rollback(ctx)
 
-	// Get internal manga ID
-	var internalMangaID string
-	err = tx.QueryRow(ctx, `SELECT id FROM "mKomik" WHERE external_id = $1`, mangaID).Scan(&internalMangaID)
+	var (
+		newIDs      = make([]string, len(chapters))
+		externalIDs = make([]string, len(chapters))
+		numbers     = make([]float64, len(chapters))
+		titles      = make([]*string, len(chapters))
+		released    = make([]*time.Time, len(chapters))
+		views       = make([]*int, len(chapters))
+		thumbnails  = make([]*string, len(chapters))
+		created     = make([]*time.Time, len(chapters))
+	)
+	for i, chapter := range chapters {
+		newIDs[i] = generateUUID()
+		externalIDs[i] = chapter.ID
+		numbers[i] = chapter.ChapterNumber
+		titles[i] = chapter.ChapterTitle
+		released[i] = chapter.ReleaseDate
+		views[i] = chapter.ViewCount
+		thumbnails[i] = chapter.ThumbnailImageURL
+		created[i] = chapter.CreatedAt
+	}
+
+	rows, err := tx.Query(ctx, `
+		WITH input AS (
+			SELECT * FROM unnest(
+				$2::text[], $3::text[], $4::float8[], $5::text[],
+				$6::timestamp[], $7::int4[], $8::text[], $9::timestamp[]
+			) AS t(new_id, external_id, chapter_number, chapter_title,
+				release_date, view_count, thumbnail_image_url, created_date)
+		),
+		matched AS (
+			SELECT input.*, (
+				SELECT c.id FROM "mChapter" c
+				WHERE c.external_id = input.external_id
+				OR (c.id_komik = $1 AND c.chapter_number = input.chapter_number)
+				ORDER BY c.external_id IS NOT DISTINCT FROM input.external_id DESC
+				LIMIT 1
+			) AS existing_id
+			FROM input
+		),
+		updated AS (
+			UPDATE "mChapter" cur SET
+				chapter_number = m.chapter_number,
+				chapter_title = m.chapter_title,
+				release_date = m.release_date,
+				view_count = m.view_count,
+				thumbnail_image_url = m.thumbnail_image_url,
+				external_id = m.external_id,
+				removed_at = NULL,
+				updated_at = NOW()
+			FROM matched m, "mChapter" prev
+			WHERE cur.id = m.existing_id AND prev.id = cur.id
+			` + changeReturning("id_komik", trackedChapterFields) + `
+		),
+		changes AS (` + insertChanges(ChangeChapter, trackedChapterFields, 10) + `)
+		INSERT INTO "mChapter" (
+			id, id_komik, chapter_number, chapter_title, release_date,
+			view_count, thumbnail_image_url, created_date, external_id
+		)
+		SELECT new_id::uuid, $1, chapter_number, chapter_title, release_date,
+			view_count, thumbnail_image_url, created_date, external_id
+		FROM matched
+		WHERE existing_id IS NULL
+		RETURNING external_id
+	`, internalMangaID, newIDs, externalIDs, numbers, titles, released, views, thumbnails, created,
+		JobIDFromContext(ctx))
 	if err != nil {
-		return fmt.Errorf("failed to get internal manga ID for %s: %w", mangaID, err)
+		return nil, fmt.Errorf("failed to upsert %d chapters: %w", len(chapters), err)
+	}
+	defer rows.Close()
+
+	inserted := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to upsert %d chapters: %w", len(chapters), err)
+		}
+		inserted[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to upsert %d chapters: %w", len(chapters), err)
+	}
+	return inserted, nil
+}
+
+// lastPerChapterNumber drops chapters whose number appears again later in the list
+func lastPerChapterNumber(chapters []ExternalChapter) []ExternalChapter {
+	last := make(map[float64]int, len(chapters))
+	for i, chapter := range chapters {
+		last[chapter.ChapterNumber] = i
+	}
+	if len(last) == len(chapters) {
+		return chapters
 	}
 
+	unique := make([]ExternalChapter, 0, len(last))
+	for i, chapter := range chapters {
+		if last[chapter.ChapterNumber] == i {
+			unique = append(unique, chapter)
+		}
+	}
+	return unique
+}
+
+// upsertChaptersRowByRow saves chapters with a lookup and an insert or update
+// per chapter, returning the external IDs inserted. Only used with
+// Config.RowByRowWrites, to benchmark against.
+func upsertChaptersRowByRow(ctx context.Context, tx pgx.Tx, internalMangaID string, chapters []ExternalChapter) (map[string]bool, error) {
+	inserted := make(map[string]bool)
 	for _, chapter := range chapters {
 		// Check if chapter already exists by external_id OR by (id_komik, chapter_number)
 		var existingID string
@@ -526,27 +759,33 @@ func (c *Crawler) saveChaptersList(chapters []ExternalChapter, mangaID string) e
 		err := tx.QueryRow(ctx, checkQuery, chapter.ID, internalMangaID, chapter.ChapterNumber).Scan(&existingID)
 
 		if err != nil && err != pgx.ErrNoRows {
-			return fmt.Errorf("failed to check existing chapter %s: %w", chapter.ID, err)
+			return nil, fmt.Errorf("failed to check existing chapter %s: %w", chapter.ID, err)
 		}
 
 		if existingID != "" {
-			// Update existing chapter
+			// Update existing chapter and log the fields that changed
 			updateQuery := `
-				UPDATE "mChapter" SET
-					chapter_number = $1,
-					chapter_title = $2,
-					release_date = $3,
-					view_count = $4,
-					thumbnail_image_url = $5,
-					external_id = $6,
-					updated_at = NOW()
-				WHERE id = $7
-			`
+				WITH updated AS (
+					UPDATE "mChapter" cur SET
+						chapter_number = $1,
+						chapter_title = $2,
+						release_date = $3,
+						view_count = $4,
+						thumbnail_image_url = $5,
+						external_id = $6,
+						removed_at = NULL,
+						updated_at = NOW()
+					FROM "mChapter" prev
+					WHERE prev.id = cur.id AND cur.id = $7
+					` + changeReturning("id_komik", trackedChapterFields) + `
+				)
+			` + insertChanges(ChangeChapter, trackedChapterFields, 8)
 			if _, err := tx.Exec(ctx, updateQuery,
 				chapter.ChapterNumber, chapter.ChapterTitle, chapter.ReleaseDate,
 				chapter.ViewCount, chapter.ThumbnailImageURL, chapter.ID, existingID,
+				JobIDFromContext(ctx),
 			); err != nil {
-				return fmt.Errorf("failed to update chapter %s: %w", chapter.ID, err)
+				return nil, fmt.Errorf("failed to update chapter %s: %w", chapter.ID, err)
 			}
 		} else {
 			// Insert new chapter
@@ -563,35 +802,23 @@ func (c *Crawler) saveChaptersList(chapters []ExternalChapter, mangaID string) e
 				chapter.ReleaseDate, chapter.ViewCount, chapter.ThumbnailImageURL,
 				chapter.CreatedAt, chapter.ID,
 			); err != nil {
-				return fmt.Errorf("failed to insert chapter %s: %w", chapter.ID, err)
+				return nil, fmt.Errorf("failed to insert chapter %s: %w", chapter.ID, err)
 			}
+			inserted[chapter.ID] = true
 		}
 	}
-
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
-	return nil
+	return inserted, nil
 }
 
 // CrawlPagesForChapter crawls and saves pages for a specific chapter (public method)
-func (c *Crawler) CrawlPagesForChapter(chapterID string) error {
-	return c.crawlPagesForChapter(chapterID)
+func (c *Crawler) CrawlPagesForChapter(ctx context.Context, chapterID string) error {
+	return c.crawlPagesForChapter(ctx, chapterID)
 }
 
 // crawlPagesForChapter crawls and saves pages for a specific chapter
-func (c *Crawler) crawlPagesForChapter(chapterID string) error {
-	url := fmt.Sprintf("%s/chapter/detail/%s", c.config.BaseURL, chapterID)
-
-	var response struct {
-		RetCode int     `json:"retcode"`
-		Message string  `json:"message"`
-		Meta    APIMeta `json:"meta"`
-		Data    ExternalChapterDetail `json:"data"`
-	}
-
-	if err := c.fetchJSON(url, &response); err != nil {
+func (c *Crawler) crawlPagesForChapter(ctx context.Context, chapterID string) error {
+	detail, err := c.source.GetChapterDetail(ctx, chapterID)
+	if err != nil {
 		return fmt.Errorf("failed to fetch chapter detail: %w", err)
 	}
 
@@ -601,54 +828,100 @@ func (c *Crawler) crawlPagesForChapter(chapterID string) error {
 	}
 
 	// Save chapter pages data
-	return c.saveChapterPages(chapterID, &response.Data)
+	return c.saveChapterPages(ctx, chapterID, detail)
 }
 
-// saveChapterPages saves chapter pages data to trChapter table
-func (c *Crawler) saveChapterPages(externalChapterID string, detail *ExternalChapterDetail) error {
-	ctx := context.Background()
+// saveChapterPages saves chapter pages data to trChapter table. An invalid
+// chapter detail is quarantined and its stored pages are left as they are.
+// Pages whose page set hash is unchanged are not rewritten; changed ones
+// start a new page set version and the previous pages are kept.
+func (c *Crawler) saveChapterPages(ctx context.Context, externalChapterID string, detail *ExternalChapterDetail) error {
+	c.archivePayloads(ctx, PayloadChapterDetail, []rawPayload{{externalID: externalChapterID, data: detail.Raw}})
+	if reason := validateChapterDetail(detail, externalChapterID); reason != "" {
+		c.quarantine(ctx, PayloadChapterDetail, []invalidRecord{
+			{externalChapterID, reason, recordPayload(detail.Raw, detail)},
+		})
+		return nil
+	}
+
 	tx, err := c.db.Pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer tx.Rollback(ctx)
+	defer tx.Rollback(context.WithoutCancel(ctx))
 
-	// Get internal chapter ID
+	// Get internal chapter ID and its current page set, locked so concurrent
+	// crawls of the same chapter do not both supersede it
 	var internalChapterID string
-	err = tx.QueryRow(ctx, `SELECT id FROM "mChapter" WHERE external_id = $1`, externalChapterID).Scan(&internalChapterID)
+	var current currentPageSet
+	err = tx.QueryRow(ctx, `
+		SELECT id, page_set_hash, page_set_version, page_set_at
+		FROM "mChapter" WHERE external_id = $1
+		FOR UPDATE
+	`, externalChapterID).Scan(&internalChapterID, &current.hash, &current.version, &current.at)
 	if err != nil {
 		return fmt.Errorf("failed to get internal chapter ID for %s: %w", externalChapterID, err)
 	}
 
 	log.Printf("Found internal chapter ID %s for external ID %s", internalChapterID, externalChapterID)
 
-	// Delete existing pages for this chapter
-	deleteQuery := `DELETE FROM "trChapter" WHERE id_chapter = $1`
-	if _, err := tx.Exec(ctx, deleteQuery, internalChapterID); err != nil {
-		return fmt.Errorf("failed to delete existing pages: %w", err)
+	// Pages are stored as paths relative to their storage origin, which
+	// serves them at full and low quality; page_url keeps the full URL
+	originID, err := storageOriginID(ctx, tx, detail.BaseURL, detail.BaseURLLow)
+	if err != nil {
+		return err
 	}
-
-	// Insert each page as separate record in trChapter
-	insertQuery := `
-		INSERT INTO "trChapter" (
-			id_chapter, page_number, page_url
-		) VALUES ($1, $2, $3)
-	`
-
+	pagePaths := make([]string, len(detail.Chapter.Data))
+	pageURLs := make([]string, len(detail.Chapter.Data))
 	for i, filename := range detail.Chapter.Data {
-		pageNumber := i + 1
+		pagePaths[i] = detail.Chapter.Path + filename
+		pageURLs[i] = detail.BaseURL + pagePaths[i]
+	}
 
-		// Construct full image URL (use base URL, not low quality for pages)
-		pageURL := detail.BaseURL + detail.Chapter.Path + filename
+	hash := pageSetHash(detail.Chapter.Path, detail.Chapter.Data)
+	unchanged := current.hash != nil && *current.hash == hash
+	changed := false
+	if !unchanged {
+		changed, err = c.supersedePageSet(ctx, tx, internalChapterID, externalChapterID, current, pageURLs)
+		if err != nil {
+			return fmt.Errorf("failed to save pages for chapter %s: %w", externalChapterID, err)
+		}
+	}
 
-		if _, err := tx.Exec(ctx, insertQuery,
-			internalChapterID, pageNumber, pageURL,
-		); err != nil {
-			return fmt.Errorf("failed to insert page %d for chapter %s: %w", pageNumber, externalChapterID, err)
+	if changed {
+		if c.config.RowByRowWrites {
+			err = replacePagesRowByRow(ctx, tx, internalChapterID, originID, pagePaths, pageURLs)
+		} else {
+			err = replacePages(ctx, tx, internalChapterID, originID, pagePaths, pageURLs)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save pages for chapter %s: %w", externalChapterID, err)
+		}
+		log.Printf("Inserted %d pages for chapter %s (page set v%d)", len(detail.Chapter.Data), externalChapterID, current.version+1)
+	} else {
+		// Same pages: only follow a move to another storage origin, or fill
+		// in the origin of pages saved without one
+		moved, err := repointPages(ctx, tx, internalChapterID, originID, detail.BaseURL, pagePaths)
+		if err != nil {
+			return fmt.Errorf("failed to save pages for chapter %s: %w", externalChapterID, err)
+		}
+		if unchanged && moved == 0 {
+			log.Printf("Pages of chapter %s unchanged (page set v%d), skipping", externalChapterID, current.version)
+			return nil
+		}
+		if moved > 0 {
+			log.Printf("Pointed %d pages of chapter %s at %s", moved, externalChapterID, detail.BaseURL)
 		}
 	}
 
-	log.Printf("Inserted %d pages for chapter %s", len(detail.Chapter.Data), externalChapterID)
+	if !unchanged {
+		if _, err := tx.Exec(ctx, `
+			UPDATE "mChapter" SET page_set_hash = $2, page_set_version = $3, page_set_at = NOW()
+			WHERE id = $1
+		`, internalChapterID, hash, current.version+1); err != nil {
+			return fmt.Errorf("failed to save page set of chapter %s: %w", externalChapterID, err)
+		}
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
@@ -657,10 +930,106 @@ func (c *Crawler) saveChapterPages(externalChapterID string, detail *ExternalCha
 	return nil
 }
 
-// Helper functions to get IDs from database
-func (c *Crawler) getAllMangaIDs() ([]string, error) {
-	ctx := context.Background()
-	rows, err := c.db.Pool.Query(ctx, `SELECT external_id FROM "mKomik" WHERE external_id IS NOT NULL`)
+// storageOriginID returns the ID of the storage origin serving pages from
+// baseURL (and baseURLLow at low quality), adding it if it is new
+func storageOriginID(ctx context.Context, tx pgx.Tx, baseURL, baseURLLow string) (int, error) {
+	var id int
+	err := tx.QueryRow(ctx, `
+		SELECT id FROM "mStorageOrigin" WHERE base_url = $1 AND base_url_low = $2
+	`, baseURL, baseURLLow).Scan(&id)
+	if err == pgx.ErrNoRows {
+		err = tx.QueryRow(ctx, `
+			INSERT INTO "mStorageOrigin" (base_url, base_url_low) VALUES ($1, $2)
+			ON CONFLICT (base_url, base_url_low) DO UPDATE SET base_url = EXCLUDED.base_url
+			RETURNING id
+		`, baseURL, baseURLLow).Scan(&id)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get storage origin %s: %w", baseURL, err)
+	}
+	return id, nil
+}
+
+// replacePages replaces a chapter's pages with pagePaths on originID and
+// their full pageURLs (numbered from 1), sending the delete and a single
+// array insert in one batch
+func replacePages(ctx context.Context, tx pgx.Tx, internalChapterID string, originID int, pagePaths, pageURLs []string) error {
+	pageNumbers := make([]int32, len(pageURLs))
+	for i := range pageURLs {
+		pageNumbers[i] = int32(i + 1)
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(`DELETE FROM "trChapter" WHERE id_chapter = $1`, internalChapterID)
+	batch.Queue(`
+		INSERT INTO "trChapter" (id_chapter, page_number, page_url, page_path, id_storage_origin)
+		SELECT $1, page_number, page_url, page_path, $2
+		FROM unnest($3::int4[], $4::text[], $5::text[]) AS t(page_number, page_url, page_path)
+	`, internalChapterID, originID, pageNumbers, pageURLs, pagePaths)
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to replace pages: %w", err)
+	}
+	return nil
+}
+
+// replacePagesRowByRow replaces a chapter's pages with one insert per page.
+// Only used with Config.RowByRowWrites, to benchmark against.
+func replacePagesRowByRow(ctx context.Context, tx pgx.Tx, internalChapterID string, originID int, pagePaths, pageURLs []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM "trChapter" WHERE id_chapter = $1`, internalChapterID); err != nil {
+		return fmt.Errorf("failed to delete existing pages: %w", err)
+	}
+
+	insertQuery := `
+		INSERT INTO "trChapter" (
+			id_chapter, page_number, page_url, page_path, id_storage_origin
+		) VALUES ($1, $2, $3, $4, $5)
+	`
+	for i, pageURL := range pageURLs {
+		if _, err := tx.Exec(ctx, insertQuery, internalChapterID, i+1, pageURL, pagePaths[i], originID); err != nil {
+			return fmt.Errorf("failed to insert page %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// repointPages points a chapter's stored pages at originID and pagePaths
+// where they differ, rewriting page_url with baseURL. Returns how many pages
+// were updated (none when the pages already match).
+func repointPages(ctx context.Context, tx pgx.Tx, internalChapterID string, originID int, baseURL string, pagePaths []string) (int64, error) {
+	pageNumbers := make([]int32, len(pagePaths))
+	for i := range pagePaths {
+		pageNumbers[i] = int32(i + 1)
+	}
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE "trChapter" t SET
+			page_path = p.page_path,
+			id_storage_origin = $2,
+			page_url = $3 || p.page_path
+		FROM unnest($4::int4[], $5::text[]) AS p(page_number, page_path)
+		WHERE t.id_chapter = $1 AND t.page_number = p.page_number
+		AND (t.id_storage_origin IS DISTINCT FROM $2 OR t.page_path IS DISTINCT FROM p.page_path)
+	`, internalChapterID, originID, baseURL, pageNumbers, pagePaths)
+	if err != nil {
+		return 0, fmt.Errorf("failed to repoint pages: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// Helper functions to get IDs from database, ordered by external ID and
+// starting after afterID ("" = from the beginning) so crawls can resume.
+// Manga and chapters marked removed upstream are skipped.
+func (c *Crawler) getAllMangaIDs(ctx context.Context, afterID string) ([]string, error) {
+	query := `
+		SELECT external_id
+		FROM "mKomik"
+		WHERE external_id IS NOT NULL
+		AND removed_at IS NULL
+		AND external_id > $1
+		ORDER BY external_id
+	`
+	rows, err := c.db.Pool.Query(ctx, query, afterID)
 	if err != nil {
 		return nil, err
 	}
@@ -678,16 +1047,20 @@ func (c *Crawler) getAllMangaIDs() ([]string, error) {
 	return ids, rows.Err()
 }
 
-func (c *Crawler) getAllChapterIDs() ([]string, error) {
-	ctx := context.Background()
+func (c *Crawler) getAllChapterIDs(ctx context.Context, afterID string) ([]string, error) {
 	query := `
 		SELECT mc.external_id
 		FROM "mChapter" mc
+		JOIN "mKomik" k ON k.id = mc.id_komik
 		LEFT JOIN "trChapter" tc ON mc.id = tc.id_chapter
 		WHERE mc.external_id IS NOT NULL
+		AND mc.removed_at IS NULL
+		AND k.removed_at IS NULL
 		AND tc.id_chapter IS NULL
+		AND mc.external_id > $1
+		ORDER BY mc.external_id
 	`
-	rows, err := c.db.Pool.Query(ctx, query)
+	rows, err := c.db.Pool.Query(ctx, query, afterID)
 	if err != nil {
 		return nil, err
 	}
@@ -705,4 +1078,17 @@ func (c *Crawler) getAllChapterIDs() ([]string, error) {
 	return ids, rows.Err()
 }
 
-
+// chapterHasPages reports whether a chapter (by external ID) already has pages saved
+func (c *Crawler) chapterHasPages(ctx context.Context, externalChapterID string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM "mChapter" mc
+			JOIN "trChapter" tc ON mc.id = tc.id_chapter
+			WHERE mc.external_id = $1
+		)
+	`
+	var exists bool
+	err := c.db.Pool.QueryRow(ctx, query, externalChapterID).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,216 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"baca-komik-api/database"
+	"baca-komik-api/internal/crawler/crawltest"
+	"baca-komik-api/internal/crawler/fakeapi"
+)
+
+// writeModes are the two ways chapters and pages are written
+var writeModes = []struct {
+	name     string
+	rowByRow bool
+}{
+	{"row-by-row", true},
+	{"set-based", false},
+}
+
+// writeFixtures is a synthetic upstream crawled twice: before, then after
+// every chapter was retitled and given more pages
+type writeFixtures struct {
+	before, after fs.FS
+	mangaIDs      []string
+	chapterIDs    []string
+}
+
+func newWriteFixtures(tb testing.TB, spec fakeapi.SyntheticSpec) writeFixtures {
+	tb.Helper()
+
+	before := fakeapi.Synthetic(spec)
+	revised := spec
+	revised.PagesPerChapter += 2
+	after := fakeapi.Synthetic(revised).(fstest.MapFS)
+
+	f := writeFixtures{before: before, after: after, mangaIDs: crawltest.MangaIDs(tb, before)}
+	for _, mangaID := range f.mangaIDs {
+		name := "chapters/" + mangaID + ".json"
+		var chapters []map[string]interface{}
+		if err := json.Unmarshal(after[name].Data, &chapters); err != nil {
+			tb.Fatalf("failed to parse %s: %v", name, err)
+		}
+		for _, chapter := range chapters {
+			chapter["chapter_title"] = fmt.Sprint(chapter["chapter_title"], " (revised)")
+			f.chapterIDs = append(f.chapterIDs, chapter["chapter_id"].(string))
+		}
+		data, err := json.Marshal(chapters)
+		if err != nil {
+			tb.Fatalf("failed to write %s: %v", name, err)
+		}
+		after[name] = &fstest.MapFile{Data: data}
+	}
+	return f
+}
+
+// crawlChapters crawls the chapter lists of mangaIDs, then the pages of chapterIDs
+func crawlChapters(ctx context.Context, c *Crawler, mangaIDs, chapterIDs []string) error {
+	for _, id := range mangaIDs {
+		if err := c.CrawlChaptersForManga(ctx, id); err != nil {
+			return fmt.Errorf("chapters of %s: %w", id, err)
+		}
+	}
+	for _, id := range chapterIDs {
+		if err := c.CrawlPagesForChapter(ctx, id); err != nil {
+			return fmt.Errorf("pages of %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// snapshotChapters returns the chapter, page, page set and change log rows
+// stored for mangaIDs, keyed by upstream IDs rather than generated ones
+func snapshotChapters(tb testing.TB, db *database.DB, mangaIDs []string) map[string][]string {
+	tb.Helper()
+
+	const chapters = `
+		FROM "mChapter" c JOIN "mKomik" k ON k.id = c.id_komik
+		WHERE k.external_id = ANY($1)`
+	queries := map[string]string{
+		"mChapter": `
+			SELECT k.external_id, c.external_id, c.chapter_number, c.chapter_title, c.release_date,
+				c.view_count, c.thumbnail_image_url, c.created_date, c.removed_at,
+				c.page_set_hash, c.page_set_version
+			` + chapters + `
+			ORDER BY 1, 2`,
+		"trChapter": `
+			SELECT c.external_id, t.page_number, t.page_url, t.page_path, o.base_url, o.base_url_low
+			` + chapters + `
+			JOIN "trChapter" t ON t.id_chapter = c.id
+			LEFT JOIN "mStorageOrigin" o ON o.id = t.id_storage_origin
+			ORDER BY 1, 2`,
+		"mChapterPageSet": `
+			SELECT c.external_id, p.version, p.content_hash, p.page_urls
+			` + chapters + `
+			JOIN "mChapterPageSet" p ON p.id_chapter = c.id
+			ORDER BY 1, 2`,
+		"mChangeLog": `
+			SELECT c.external_id, l.field, l.old_value, l.new_value
+			` + chapters + `
+			JOIN "mChangeLog" l ON l.entity_type = 'chapter' AND l.entity_id = c.id
+			ORDER BY 1, 2`,
+	}
+
+	snapshot := make(map[string][]string, len(queries))
+	for table, query := range queries {
+		rows, err := db.Pool.Query(context.Background(), query, mangaIDs)
+		if err != nil {
+			tb.Fatalf("failed to read %s: %v", table, err)
+		}
+		for rows.Next() {
+			values, err := rows.Values()
+			if err != nil {
+				tb.Fatalf("failed to read %s: %v", table, err)
+			}
+			snapshot[table] = append(snapshot[table], fmt.Sprint(values...))
+		}
+		if err := rows.Err(); err != nil {
+			tb.Fatalf("failed to read %s: %v", table, err)
+		}
+		rows.Close()
+	}
+	return snapshot
+}
+
+func TestRowByRowAndSetBasedWritesMatch(t *testing.T) {
+	db := crawltest.DB(t)
+	f := newWriteFixtures(t, fakeapi.SyntheticSpec{Manga: 2, ChaptersPerManga: 25, PagesPerChapter: 4})
+	t.Cleanup(func() { crawltest.DeleteManga(t, db, f.mangaIDs) })
+
+	before := fakeapi.New(f.before)
+	defer before.Close()
+	after := fakeapi.New(f.after)
+	defer after.Close()
+	before.SetPageSize(10)
+	after.SetPageSize(10)
+	ctx := context.Background()
+
+	snapshots := make([]map[string][]string, len(writeModes))
+	for i, mode := range writeModes {
+		crawltest.DeleteManga(t, db, f.mangaIDs)
+		if err := newFakeCrawler(before, db).CrawlManga(ctx, 1, -1); err != nil {
+			t.Fatalf("%s: CrawlManga: %v", mode.name, err)
+		}
+
+		// Inserts, then updates and new page sets
+		for _, srv := range []*fakeapi.Server{before, after} {
+			c := newFakeCrawler(srv, db)
+			c.config.RowByRowWrites = mode.rowByRow
+			if err := crawlChapters(ctx, c, f.mangaIDs, f.chapterIDs); err != nil {
+				t.Fatalf("%s: %v", mode.name, err)
+			}
+		}
+		snapshots[i] = snapshotChapters(t, db, f.mangaIDs)
+	}
+
+	rowByRow, setBased := snapshots[0], snapshots[1]
+	if n := len(rowByRow["mChapter"]); n != len(f.chapterIDs) {
+		t.Fatalf("stored chapters = %d, want %d", n, len(f.chapterIDs))
+	}
+	if len(rowByRow["mChangeLog"]) == 0 || len(rowByRow["mChapterPageSet"]) == 0 {
+		t.Fatal("second crawl logged no chapter changes or superseded no page sets")
+	}
+	for table := range rowByRow {
+		if !reflect.DeepEqual(rowByRow[table], setBased[table]) {
+			t.Errorf("%s rows differ:\nrow-by-row:\n  %s\nset-based:\n  %s", table,
+				strings.Join(rowByRow[table], "\n  "), strings.Join(setBased[table], "\n  "))
+		}
+	}
+}
+
+// BenchmarkChapterWrites times crawling chapter lists (inserts, then
+// updates) and pages with each write mode, against the fake upstream.
+// Run with TEST_DATABASE_URL set:
+//
+//	go test -run '^$' -bench ChapterWrites ./internal/crawler
+func BenchmarkChapterWrites(b *testing.B) {
+	db := crawltest.DB(b)
+	f := newWriteFixtures(b, fakeapi.SyntheticSpec{Manga: 2, ChaptersPerManga: 100, PagesPerChapter: 20})
+	b.Cleanup(func() { crawltest.DeleteManga(b, db, f.mangaIDs) })
+
+	srv := fakeapi.New(f.before)
+	defer srv.Close()
+	ctx := context.Background()
+
+	for _, mode := range writeModes {
+		b.Run(mode.name, func(b *testing.B) {
+			c := newFakeCrawler(srv, db)
+			c.config.RowByRowWrites = mode.rowByRow
+
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				crawltest.DeleteManga(b, db, f.mangaIDs)
+				if err := c.CrawlManga(ctx, 1, -1); err != nil {
+					b.Fatalf("CrawlManga: %v", err)
+				}
+				b.StartTimer()
+
+				for _, id := range f.mangaIDs {
+					if err := c.CrawlChaptersForManga(ctx, id); err != nil {
+						b.Fatalf("chapters of %s: %v", id, err)
+					}
+				}
+				if err := crawlChapters(ctx, c, f.mangaIDs, f.chapterIDs); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Dead-letter states
+const (
+	FailurePending  = "pending"  // waiting to be retried
+	FailureIgnored  = "ignored"  // marked as not worth retrying
+	FailureResolved = "resolved" // succeeded on retry
+)
+
+// ValidEntity reports whether entity is a dead-letter entity type
+func ValidEntity(entity string) bool {
+	switch entity {
+	case EntityMangaPage, EntityManga, EntityChapter:
+		return true
+	}
+	return false
+}
+
+// DeadLetter is an item that failed for good, kept in "mCrawlFailure" until
+// it is retried successfully or ignored
+type DeadLetter struct {
+	ID            int64      `json:"id"`
+	Entity        string     `json:"entity"`
+	ExternalID    string     `json:"external_id"`
+	JobID         string     `json:"job_id,omitempty"`
+	Reason        string     `json:"reason"`
+	Error         string     `json:"error"`
+	Attempts      int        `json:"attempts"`
+	Status        string     `json:"status"`
+	FirstFailedAt time.Time  `json:"first_failed_at"`
+	LastAttemptAt time.Time  `json:"last_attempt_at"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty"`
+}
+
+// DeadLetterFilter selects dead letters; empty fields match everything
+type DeadLetterFilter struct {
+	Status string
+	Entity string
+	Limit  int
+	Offset int
+}
+
+const deadLetterColumns = `
+	id, entity_type, external_id, COALESCE(job_id, ''), reason, error_message,
+	attempt_count, status, first_failed_at, last_attempt_at, resolved_at
+`
+
+// scanDeadLetter scans a row selected with deadLetterColumns
+func scanDeadLetter(row pgx.Row) (*DeadLetter, error) {
+	var d DeadLetter
+	if err := row.Scan(&d.ID, &d.Entity, &d.ExternalID, &d.JobID, &d.Reason, &d.Error,
+		&d.Attempts, &d.Status, &d.FirstFailedAt, &d.LastAttemptAt, &d.ResolvedAt); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// saveDeadLetter records a failed item, adding its attempts to any earlier
+// failures of the same item. An ignored item stays ignored.
+func (c *Crawler) saveDeadLetter(ctx context.Context, failure ItemFailure) error {
+	_, err := c.db.Pool.Exec(context.WithoutCancel(ctx), `
+		INSERT INTO "mCrawlFailure" (entity_type, external_id, job_id, reason, error_message, attempt_count)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6)
+		ON CONFLICT (entity_type, external_id) DO UPDATE SET
+			job_id = EXCLUDED.job_id,
+			reason = EXCLUDED.reason,
+			error_message = EXCLUDED.error_message,
+			attempt_count = "mCrawlFailure".attempt_count + EXCLUDED.attempt_count,
+			status = CASE WHEN "mCrawlFailure".status = 'ignored' THEN 'ignored' ELSE 'pending' END,
+			last_attempt_at = NOW(),
+			resolved_at = NULL
+	`, failure.Entity, failure.ExternalID, JobIDFromContext(ctx), failure.Reason, failure.Error, failure.Attempts)
+	if err != nil {
+		return fmt.Errorf("failed to save dead letter %s %s: %w", failure.Entity, failure.ExternalID, err)
+	}
+	return nil
+}
+
+// ListDeadLetters returns dead letters matching filter, most recently attempted
+// first, and how many match in total
+func (c *Crawler) ListDeadLetters(ctx context.Context, filter DeadLetterFilter) ([]DeadLetter, int, error) {
+	var total int
+	if err := c.db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM "mCrawlFailure"
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR entity_type = $2)
+	`, filter.Status, filter.Entity).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count dead letters: %w", err)
+	}
+
+	rows, err := c.db.Pool.Query(ctx, `
+		SELECT `+deadLetterColumns+`
+		FROM "mCrawlFailure"
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR entity_type = $2)
+		ORDER BY last_attempt_at DESC, id DESC
+		LIMIT $3 OFFSET $4
+	`, filter.Status, filter.Entity, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	items := []DeadLetter{}
+	for rows.Next() {
+		d, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		items = append(items, *d)
+	}
+	return items, total, rows.Err()
+}
+
+// DeadLetterSummary summarizes the pending dead letters last failed by jobID
+// (every pending one if jobID is empty), with up to limit most recent first.
+// Unlike Failures it covers every worker and survives restarts.
+func (c *Crawler) DeadLetterSummary(ctx context.Context, jobID string, limit int) (FailureSummary, error) {
+	summary := FailureSummary{ByReason: make(map[string]int), Recent: []ItemFailure{}}
+
+	rows, err := c.db.Pool.Query(ctx, `
+		SELECT reason, COUNT(*) FROM "mCrawlFailure"
+		WHERE status = $1 AND ($2 = '' OR job_id = $2)
+		GROUP BY reason
+	`, FailurePending, jobID)
+	if err != nil {
+		return summary, fmt.Errorf("failed to count dead letters: %w", err)
+	}
+	for rows.Next() {
+		var reason string
+		var n int
+		if err := rows.Scan(&reason, &n); err != nil {
+			rows.Close()
+			return summary, fmt.Errorf("failed to count dead letters: %w", err)
+		}
+		summary.ByReason[reason] = n
+		summary.Total += n
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return summary, fmt.Errorf("failed to count dead letters: %w", err)
+	}
+
+	rows, err = c.db.Pool.Query(ctx, `
+		SELECT entity_type, external_id, reason, error_message, attempt_count, last_attempt_at
+		FROM "mCrawlFailure"
+		WHERE status = $1 AND ($2 = '' OR job_id = $2)
+		ORDER BY last_attempt_at DESC, id DESC
+		LIMIT $3
+	`, FailurePending, jobID, limit)
+	if err != nil {
+		return summary, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var f ItemFailure
+		if err := rows.Scan(&f.Entity, &f.ExternalID, &f.Reason, &f.Error, &f.Attempts, &f.FailedAt); err != nil {
+			return summary, fmt.Errorf("failed to list dead letters: %w", err)
+		}
+		summary.Recent = append(summary.Recent, f)
+	}
+	return summary, rows.Err()
+}
+
+// IgnoreDeadLetters marks dead letters as ignored so "retry all" skips them.
+// Resolved ones are left alone. Returns how many were marked.
+func (c *Crawler) IgnoreDeadLetters(ctx context.Context, ids []int64) (int, error) {
+	result, err := c.db.Pool.Exec(ctx, `
+		UPDATE "mCrawlFailure" SET status = 'ignored'
+		WHERE id = ANY($1) AND status <> 'resolved'
+	`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to ignore dead letters: %w", err)
+	}
+	return int(result.RowsAffected()), nil
+}
+
+// resolveDeadLetter marks a dead letter as resolved after a successful retry
+func (c *Crawler) resolveDeadLetter(ctx context.Context, id int64) error {
+	if _, err := c.db.Pool.Exec(ctx, `
+		UPDATE "mCrawlFailure" SET status = 'resolved', resolved_at = NOW(), last_attempt_at = NOW()
+		WHERE id = $1
+	`, id); err != nil {
+		return fmt.Errorf("failed to resolve dead letter %d: %w", id, err)
+	}
+	return nil
+}
+
+// retryCandidates returns the dead letters a retry covers: the given IDs
+// (pending or ignored), or every pending one of entity when ids is empty
+func (c *Crawler) retryCandidates(ctx context.Context, ids []int64, entity string) ([]DeadLetter, error) {
+	query := `
+		SELECT ` + deadLetterColumns + `
+		FROM "mCrawlFailure"
+		WHERE status = 'pending' AND ($1 = '' OR entity_type = $1)
+		ORDER BY id
+	`
+	args := []interface{}{entity}
+	if len(ids) > 0 {
+		query = `
+			SELECT ` + deadLetterColumns + `
+			FROM "mCrawlFailure"
+			WHERE id = ANY($1) AND status <> 'resolved'
+			ORDER BY id
+		`
+		args = []interface{}{ids}
+	}
+
+	rows, err := c.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	items := []DeadLetter{}
+	for rows.Next() {
+		d, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *d)
+	}
+	return items, rows.Err()
+}
+
+// RetryDeadLetters crawls failed items again: the given dead letter IDs, or
+// every pending one (of entity, if set) when ids is empty. Items that succeed
+// are marked resolved; items that fail again get another attempt recorded.
+func (c *Crawler) RetryDeadLetters(ctx context.Context, ids []int64, entity string) error {
+	items, err := c.retryCandidates(ctx, ids, entity)
+	if err != nil {
+		return err
+	}
+	jobEvent(ctx, EventInfo, fmt.Sprintf("Retrying %d failed item(s)...", len(items)), nil)
+
+	progress := c.startCheckpoint(ctx, "retry_failures", nil)
+	progress.EstimatedTotal = len(items)
+
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := c.retryItem(ctx, item); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			c.recordFailure(ctx, item.Entity, item.ExternalID, err)
+			progress.ErrorCount++
+		} else {
+			if !c.config.DryRun {
+				if err := c.resolveDeadLetter(ctx, item.ID); err != nil {
+					log.Printf("Warning: %v", err)
+				}
+			}
+			progress.SuccessCount++
+		}
+		progress.TotalProcessed++
+		c.reportProgress(ctx, progress)
+	}
+
+	jobEvent(ctx, EventInfo, fmt.Sprintf("Retry completed: %d processed, %d resolved, %d failed again",
+		progress.TotalProcessed, progress.SuccessCount, progress.ErrorCount), nil)
+	return nil
+}
+
+// retryItem crawls one dead letter's item again
+func (c *Crawler) retryItem(ctx context.Context, item DeadLetter) error {
+	switch item.Entity {
+	case EntityMangaPage:
+		page, err := strconv.Atoi(item.ExternalID)
+		if err != nil {
+			return fmt.Errorf("invalid manga page %q: %w", item.ExternalID, err)
+		}
+		return c.crawlMangaPage(ctx, page)
+	case EntityManga:
+		// Always the whole list: the failed crawl may have left a gap past known chapters
+		_, err := c.crawlChaptersForManga(ctx, item.ExternalID, false, nil)
+		return err
+	case EntityChapter:
+		return c.crawlPagesForChapter(ctx, item.ExternalID)
+	default:
+		return fmt.Errorf("unknown entity type: %s", item.Entity)
+	}
+}
+
+// crawlMangaPage crawls and saves a single manga list page
+func (c *Crawler) crawlMangaPage(ctx context.Context, page int) error {
+	result, err := c.source.ListManga(ctx, page)
+	if err != nil {
+		return err
+	}
+
+	if c.config.DryRun {
+		log.Printf("DRY RUN: Would save %d manga from page %d", len(result.Manga), page)
+		return nil
+	}
+	return c.saveMangaList(ctx, result.Manga)
+}
@@ -0,0 +1,492 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Upstream endpoints that can be sampled for drift checks
+const (
+	EndpointMangaList     = "manga_list"
+	EndpointChapterList   = "chapter_list"
+	EndpointChapterDetail = "chapter_detail"
+	EndpointGenreList     = "genre_list"
+	EndpointFormatList    = "format_list"
+	EndpointTypeList      = "type_list"
+	EndpointAuthorList    = "author_list"
+	EndpointArtistList    = "artist_list"
+)
+
+// Kinds of drift
+const (
+	DriftAdded   = "added"   // upstream sends a field we do not read
+	DriftRemoved = "removed" // upstream no longer sends a field we read
+	DriftRetyped = "retyped" // upstream sends a field with another JSON type
+)
+
+// driftRetention is how long drift check results are kept
+const driftRetention = 90 * 24 * time.Hour
+
+// Sampler is implemented by sources that can return raw responses for drift checks
+type Sampler interface {
+	// Sample returns the raw first page of endpoint; id is the manga ID of a
+	// chapter list or the chapter ID of a chapter detail
+	Sample(ctx context.Context, endpoint, id string) ([]byte, error)
+}
+
+// DriftTarget is a response shape to check: a sample of Endpoint is compared
+// against the JSON fields of Expected, the struct it is decoded into
+type DriftTarget struct {
+	Name     string      `json:"name"`
+	Endpoint string      `json:"endpoint"`
+	Expected interface{} `json:"-"`
+}
+
+// DefaultDriftTargets returns the responses the crawler decodes
+func DefaultDriftTargets() []DriftTarget {
+	return []DriftTarget{
+		{Name: "crawler manga list", Endpoint: EndpointMangaList, Expected: MangaListResponse{}},
+		{Name: "crawler chapter list", Endpoint: EndpointChapterList, Expected: ChaptersResponse{}},
+		{Name: "crawler chapter detail", Endpoint: EndpointChapterDetail, Expected: ChapterDetailResponse{}},
+		{Name: "crawler genres", Endpoint: EndpointGenreList, Expected: DataResponse[[]ExternalGenre]{}},
+		{Name: "crawler formats", Endpoint: EndpointFormatList, Expected: DataResponse[[]ExternalFormat]{}},
+		{Name: "crawler types", Endpoint: EndpointTypeList, Expected: DataResponse[[]ExternalType]{}},
+		{Name: "crawler authors", Endpoint: EndpointAuthorList, Expected: DataResponse[[]ExternalAuthor]{}},
+		{Name: "crawler artists", Endpoint: EndpointArtistList, Expected: DataResponse[[]ExternalArtist]{}},
+	}
+}
+
+// FieldDrift is one difference between a sample and the expected fields.
+// Path is the JSON path, e.g. "data[].manga_id".
+type FieldDrift struct {
+	Path     string `json:"path"`
+	Change   string `json:"change"`
+	Expected string `json:"expected,omitempty"`
+	Observed string `json:"observed,omitempty"`
+}
+
+// DriftResult is the outcome of checking one target
+type DriftResult struct {
+	Target   string       `json:"target"`
+	Endpoint string       `json:"endpoint"`
+	Error    string       `json:"error,omitempty"`
+	Changes  []FieldDrift `json:"changes"`
+}
+
+// DriftReport is the outcome of a drift check. Drifted is set when any target
+// has changes; targets that could not be sampled only carry an error.
+type DriftReport struct {
+	ID        int64         `json:"id,omitempty"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Drifted   bool          `json:"drifted"`
+	Results   []DriftResult `json:"results"`
+}
+
+// CheckSchemaDrift samples the endpoint of every target once, compares each
+// sample against the target's expected fields, logs what drifted and stores
+// the report in "mSchemaDrift"
+func (c *Crawler) CheckSchemaDrift(ctx context.Context, targets []DriftTarget) (*DriftReport, error) {
+	sampler, ok := c.source.(Sampler)
+	if !ok {
+		return nil, fmt.Errorf("source %s does not support drift checks", c.source.Name())
+	}
+
+	samples := &driftSamples{sampler: sampler, bodies: map[string][]byte{}, errs: map[string]error{}}
+	report := &DriftReport{CheckedAt: time.Now(), Results: []DriftResult{}}
+	for _, target := range targets {
+		result := DriftResult{Target: target.Name, Endpoint: target.Endpoint, Changes: []FieldDrift{}}
+		body, err := samples.get(ctx, target.Endpoint)
+		if err == nil {
+			var observed map[string]map[string]bool
+			if observed, err = observedSchema(body); err == nil {
+				result.Changes = diffSchema(expectedSchema(target.Expected), observed)
+			}
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			result.Error = err.Error()
+			log.Printf("Warning: drift check of %s skipped: %v", target.Name, err)
+		}
+
+		for _, change := range result.Changes {
+			log.Printf("⚠️ Schema drift in %s: %s %s (expected %q, observed %q)",
+				target.Name, change.Path, change.Change, change.Expected, change.Observed)
+		}
+		report.Drifted = report.Drifted || len(result.Changes) > 0
+		report.Results = append(report.Results, result)
+	}
+
+	if err := c.saveDriftReport(context.WithoutCancel(ctx), report); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return report, nil
+}
+
+// LatestDriftReport returns the last stored drift check, or nil if there is none
+func (c *Crawler) LatestDriftReport(ctx context.Context) (*DriftReport, error) {
+	var id int64
+	var data []byte
+	err := c.db.Pool.QueryRow(ctx, `
+		SELECT id, report FROM "mSchemaDrift" ORDER BY checked_at DESC, id DESC LIMIT 1
+	`).Scan(&id, &data)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load drift report: %w", err)
+	}
+
+	var report DriftReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to decode drift report: %w", err)
+	}
+	report.ID = id
+	return &report, nil
+}
+
+// saveDriftReport stores report and prunes reports past driftRetention
+func (c *Crawler) saveDriftReport(ctx context.Context, report *DriftReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode drift report: %w", err)
+	}
+	if err := c.db.Pool.QueryRow(ctx, `
+		INSERT INTO "mSchemaDrift" (checked_at, drifted, report) VALUES ($1, $2, $3) RETURNING id
+	`, report.CheckedAt, report.Drifted, data).Scan(&report.ID); err != nil {
+		return fmt.Errorf("failed to save drift report: %w", err)
+	}
+	if _, err := c.db.Pool.Exec(ctx, `DELETE FROM "mSchemaDrift" WHERE checked_at < $1`,
+		time.Now().Add(-driftRetention)); err != nil {
+		return fmt.Errorf("failed to prune drift reports: %w", err)
+	}
+	return nil
+}
+
+// driftSamples fetches each endpoint at most once. Chapter lists are sampled
+// for the first listed manga, chapter details for the first listed chapter.
+type driftSamples struct {
+	sampler Sampler
+	bodies  map[string][]byte
+	errs    map[string]error
+}
+
+func (s *driftSamples) get(ctx context.Context, endpoint string) ([]byte, error) {
+	if err, ok := s.errs[endpoint]; ok {
+		return nil, err
+	}
+	if body, ok := s.bodies[endpoint]; ok {
+		return body, nil
+	}
+
+	body, err := s.fetch(ctx, endpoint)
+	if err != nil {
+		s.errs[endpoint] = err
+		return nil, err
+	}
+	s.bodies[endpoint] = body
+	return body, nil
+}
+
+func (s *driftSamples) fetch(ctx context.Context, endpoint string) ([]byte, error) {
+	id := ""
+	switch endpoint {
+	case EndpointChapterList, EndpointChapterDetail:
+		parent, key := EndpointMangaList, "manga_id"
+		if endpoint == EndpointChapterDetail {
+			parent, key = EndpointChapterList, "chapter_id"
+		}
+		body, err := s.get(ctx, parent)
+		if err != nil {
+			return nil, fmt.Errorf("no %s sample: %w", parent, err)
+		}
+		if id = firstListedID(body, key); id == "" {
+			return nil, fmt.Errorf("no %s found in the %s sample", key, parent)
+		}
+	}
+	return s.sampler.Sample(ctx, endpoint, id)
+}
+
+// firstListedID returns key of the first item listed under "data" (or
+// "data.data"), or "" if there is none
+func firstListedID(body []byte, key string) string {
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if json.Unmarshal(body, &response) != nil {
+		return ""
+	}
+
+	var items []map[string]interface{}
+	if json.Unmarshal(response.Data, &items) != nil {
+		var nested struct {
+			Data []map[string]interface{} `json:"data"`
+		}
+		if json.Unmarshal(response.Data, &nested) != nil {
+			return ""
+		}
+		items = nested.Data
+	}
+	for _, item := range items {
+		if id, ok := item[key].(string); ok && id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// fieldSpec is the JSON type a struct field decodes from. Optional fields
+// (omitempty) are not reported when missing; open ones (maps) take any keys.
+type fieldSpec struct {
+	kind     string
+	optional bool
+	open     bool
+}
+
+// JSON kinds; "integer" is a number without a fraction
+const (
+	kindString  = "string"
+	kindInteger = "integer"
+	kindNumber  = "number"
+	kindBoolean = "boolean"
+	kindObject  = "object"
+	kindArray   = "array"
+	kindNull    = "null"
+	kindAny     = "any"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// expectedSchema returns the JSON paths v decodes, with their specs
+func expectedSchema(v interface{}) map[string]fieldSpec {
+	fields := map[string]fieldSpec{}
+	addExpected(fields, "", reflect.TypeOf(v), false)
+	return fields
+}
+
+func addExpected(fields map[string]fieldSpec, path string, t reflect.Type, optional bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	spec := fieldSpec{kind: jsonKind(t), optional: optional, open: t.Kind() == reflect.Map}
+	if path != "" {
+		fields[path] = spec
+	}
+
+	switch {
+	case spec.kind == kindArray:
+		addExpected(fields, path+"[]", t.Elem(), false)
+	case spec.kind == kindObject && t.Kind() == reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" && opts == "" {
+				continue
+			}
+			if field.Anonymous && name == "" {
+				addExpected(fields, path, field.Type, optional)
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			addExpected(fields, joinPath(path, name), field.Type, strings.Contains(opts, "omitempty"))
+		}
+	}
+}
+
+// jsonKind returns the JSON kind a Go type decodes from
+func jsonKind(t reflect.Type) string {
+	switch {
+	case t == timeType:
+		return kindString
+	case t == rawMessageType:
+		return kindAny
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return kindString
+	case reflect.Bool:
+		return kindBoolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return kindInteger
+	case reflect.Float32, reflect.Float64:
+		return kindNumber
+	case reflect.Struct, reflect.Map:
+		return kindObject
+	case reflect.Slice, reflect.Array:
+		return kindArray
+	default:
+		return kindAny
+	}
+}
+
+// observedSchema returns the JSON paths of a sample with every kind seen at
+// each; array items are merged under "path[]"
+func observedSchema(body []byte) (map[string]map[string]bool, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("sample is not JSON: %w", err)
+	}
+
+	fields := map[string]map[string]bool{}
+	addObserved(fields, "", value)
+	return fields, nil
+}
+
+func addObserved(fields map[string]map[string]bool, path string, value interface{}) {
+	if path != "" {
+		if fields[path] == nil {
+			fields[path] = map[string]bool{}
+		}
+		fields[path][valueKind(value)] = true
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			addObserved(fields, joinPath(path, key), child)
+		}
+	case []interface{}:
+		for _, item := range v {
+			addObserved(fields, path+"[]", item)
+		}
+	}
+}
+
+// valueKind returns the JSON kind of a decoded value
+func valueKind(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return kindNull
+	case string:
+		return kindString
+	case bool:
+		return kindBoolean
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			return kindNumber
+		}
+		return kindInteger
+	case map[string]interface{}:
+		return kindObject
+	default:
+		return kindArray
+	}
+}
+
+// diffSchema compares observed paths against expected ones. Fields are only
+// compared under objects and arrays the sample actually has (an empty list
+// says nothing about its items, a retyped object nothing about its fields),
+// null matches any kind, and an added object is reported once rather than
+// per nested field.
+func diffSchema(expected map[string]fieldSpec, observed map[string]map[string]bool) []FieldDrift {
+	drift := []FieldDrift{}
+	for path, spec := range expected {
+		if parent := parentPath(path); parent != "" && !observed[parent][containerKind(path)] {
+			continue
+		}
+		kinds, ok := observed[path]
+		if !ok {
+			// Untyped fields (interface{}, json.RawMessage) are not read, only kept
+			if !spec.optional && spec.kind != kindAny && !strings.HasSuffix(path, "[]") {
+				drift = append(drift, FieldDrift{Path: path, Change: DriftRemoved, Expected: spec.kind})
+			}
+			continue
+		}
+		for kind := range kinds {
+			if !kindMatches(spec.kind, kind) {
+				drift = append(drift, FieldDrift{Path: path, Change: DriftRetyped, Expected: spec.kind, Observed: joinKinds(kinds)})
+				break
+			}
+		}
+	}
+
+	for path, kinds := range observed {
+		if _, ok := expected[path]; ok {
+			continue
+		}
+		if parent := parentPath(path); parent != "" {
+			spec, ok := expected[parent]
+			if !ok || spec.open || spec.kind != containerKind(path) {
+				continue
+			}
+		}
+		drift = append(drift, FieldDrift{Path: path, Change: DriftAdded, Observed: joinKinds(kinds)})
+	}
+
+	sort.Slice(drift, func(i, j int) bool {
+		if drift[i].Path != drift[j].Path {
+			return drift[i].Path < drift[j].Path
+		}
+		return drift[i].Change < drift[j].Change
+	})
+	return drift
+}
+
+// containerKind returns the kind of the value holding path: an array for
+// "path[]", an object otherwise
+func containerKind(path string) string {
+	if strings.HasSuffix(path, "[]") {
+		return kindArray
+	}
+	return kindObject
+}
+
+// kindMatches reports whether a value of kind observed decodes into expected
+func kindMatches(expected, observed string) bool {
+	return expected == kindAny || observed == kindNull || expected == observed ||
+		(expected == kindNumber && observed == kindInteger)
+}
+
+// joinKinds lists the non-null kinds seen at a path, e.g. "integer|string"
+func joinKinds(kinds map[string]bool) string {
+	var list []string
+	for kind := range kinds {
+		if kind != kindNull {
+			list = append(list, kind)
+		}
+	}
+	if len(list) == 0 {
+		return kindNull
+	}
+	sort.Strings(list)
+	return strings.Join(list, "|")
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// parentPath returns the path of the object or array holding path
+func parentPath(path string) string {
+	if strings.HasSuffix(path, "[]") {
+		return strings.TrimSuffix(path, "[]")
+	}
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
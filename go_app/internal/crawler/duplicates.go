@@ -0,0 +1,480 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Duplicate review states
+const (
+	DuplicatePending  = "pending"  // waiting for an admin decision
+	DuplicateMerged   = "merged"   // the new comic was merged into the candidate
+	DuplicateLinked   = "linked"   // kept apart but linked as the same work
+	DuplicateDistinct = "distinct" // not the same comic
+)
+
+const (
+	// duplicateAutoMatchScore is the score at which a crawled manga is mapped
+	// onto an existing comic that has no upstream ID yet, without review
+	duplicateAutoMatchScore = 0.95
+	// duplicateReviewScore is the lowest score queued for review
+	duplicateReviewScore = 0.6
+	// duplicateCandidateSimilarity is the trigram similarity a title needs to
+	// be considered at all
+	duplicateCandidateSimilarity = 0.45
+	// minFuzzyTitleLength is the normalized title length below which titles
+	// only match exactly ("One" would otherwise match hundreds of comics)
+	minFuzzyTitleLength = 6
+)
+
+// ErrDuplicateNotFound is returned when resolving an unknown or already
+// resolved duplicate review
+var ErrDuplicateNotFound = errors.New("duplicate review not found or already resolved")
+
+// DuplicateMatch is an existing comic that a crawled manga may duplicate
+type DuplicateMatch struct {
+	ID              string  `db:"id"`
+	Title           string  `db:"title"`
+	ExternalID      string  `db:"external_id"`
+	ReleaseYear     *int    `db:"release_year"`
+	TitleSimilarity float64 `db:"title_similarity"` // normalized title vs title
+	AltSimilarity   float64 `db:"alt_similarity"`   // best of the alternative title pairs
+	AuthorCount     int     `db:"author_count"`
+	AuthorOverlap   int     `db:"author_overlap"`
+	SimilarityScore float64 `db:"similarity_score"`
+	Signals         map[string]interface{}
+}
+
+// DuplicateReview is a queued duplicate candidate pair
+type DuplicateReview struct {
+	ID             int64                  `json:"id"`
+	KomikID        *string                `json:"komik_id"` // nil once merged away
+	CandidateID    string                 `json:"candidate_id"`
+	ExternalID     string                 `json:"external_id"`
+	Title          string                 `json:"title"`
+	CandidateTitle string                 `json:"candidate_title"`
+	Score          float64                `json:"score"`
+	Signals        map[string]interface{} `json:"signals"`
+	Status         string                 `json:"status"`
+	CreatedAt      time.Time              `json:"created_at"`
+	ResolvedAt     *time.Time             `json:"resolved_at,omitempty"`
+	ResolvedBy     string                 `json:"resolved_by,omitempty"`
+}
+
+// normalizeTitle mirrors the normalize_title SQL function: lowercase, with
+// runs of anything but letters and digits collapsed to single spaces
+func normalizeTitle(title string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// checkMangaDuplicates returns existing comics (other than the manga's own
+// row) that may be the same work, best first. Candidates are found by
+// trigram similarity of normalized titles, including alternative titles, and
+// scored with release year and author overlap (see scoreDuplicate).
+func (c *Crawler) checkMangaDuplicates(ctx context.Context, tx pgx.Tx, manga ExternalManga, releaseYear *int) ([]DuplicateMatch, error) {
+	altTitle := ""
+	if manga.AlternativeTitle != nil {
+		altTitle = *manga.AlternativeTitle
+	}
+	authors := []string{}
+	if manga.Taxonomy != nil {
+		for _, author := range manga.Taxonomy.Author {
+			authors = append(authors, author.Name)
+		}
+	}
+
+	// Short titles only match exactly. The threshold is set for the %
+	// operator, which (unlike comparing similarity()) uses the trigram indexes.
+	threshold := duplicateCandidateSimilarity
+	if utf8.RuneCountInString(normalizeTitle(manga.Title)) < minFuzzyTitleLength {
+		threshold = 1
+	}
+	if _, err := tx.Exec(ctx, `SELECT set_config('pg_trgm.similarity_threshold', $1, true)`,
+		fmt.Sprint(threshold)); err != nil {
+		return nil, err
+	}
+
+	query := `
+		WITH input AS (
+			SELECT normalize_title($1) AS title, normalize_title($2) AS alt
+		)
+		SELECT
+			k.id,
+			k.title,
+			COALESCE(k.external_id, ''),
+			k.release_year,
+			similarity(normalize_title(k.title), input.title) AS title_similarity,
+			GREATEST(
+				similarity(normalize_title(k.alternative_title), input.title),
+				CASE WHEN input.alt <> '' THEN similarity(normalize_title(k.title), input.alt) ELSE 0 END,
+				CASE WHEN input.alt <> '' THEN similarity(normalize_title(k.alternative_title), input.alt) ELSE 0 END
+			) AS alt_similarity,
+			(SELECT COUNT(*) FROM "trAuthor" ta WHERE ta.id_komik = k.id) AS author_count,
+			(SELECT COUNT(*) FROM "trAuthor" ta JOIN "mAuthor" a ON a.id = ta.id_author
+				WHERE ta.id_komik = k.id AND a.name = ANY($3)) AS author_overlap
+		FROM "mKomik" k, input
+		WHERE k.external_id IS DISTINCT FROM $4
+		AND (
+			normalize_title(k.title) % input.title
+			OR normalize_title(k.alternative_title) % input.title
+			OR (input.alt <> '' AND normalize_title(k.title) % input.alt)
+		)
+		ORDER BY title_similarity DESC
+		LIMIT 5
+	`
+
+	rows, err := tx.Query(ctx, query, manga.Title, altTitle, authors, manga.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []DuplicateMatch
+	for rows.Next() {
+		var match DuplicateMatch
+		if err := rows.Scan(&match.ID, &match.Title, &match.ExternalID, &match.ReleaseYear,
+			&match.TitleSimilarity, &match.AltSimilarity, &match.AuthorCount, &match.AuthorOverlap); err != nil {
+			return nil, err
+		}
+		scoreDuplicate(&match, releaseYear, len(authors))
+		if match.SimilarityScore >= duplicateReviewScore {
+			matches = append(matches, match)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].SimilarityScore > matches[j].SimilarityScore
+	})
+	return matches, nil
+}
+
+// scoreDuplicate combines a candidate's title similarity with release year
+// and author overlap into its SimilarityScore, recording each signal
+func scoreDuplicate(match *DuplicateMatch, releaseYear *int, authorCount int) {
+	score := match.TitleSimilarity
+	if match.AltSimilarity > score {
+		score = match.AltSimilarity
+	}
+	signals := map[string]interface{}{
+		"title_similarity":       match.TitleSimilarity,
+		"alternative_similarity": match.AltSimilarity,
+	}
+
+	// A matching year supports the title match; years far apart usually mean
+	// a different work with a similar name (remakes, sequels)
+	if releaseYear != nil && match.ReleaseYear != nil {
+		diff := *releaseYear - *match.ReleaseYear
+		if diff < 0 {
+			diff = -diff
+		}
+		signals["year_difference"] = diff
+		switch {
+		case diff == 0:
+			score += 0.1
+		case diff > 1:
+			score -= 0.3
+		}
+	}
+
+	if authorCount > 0 && match.AuthorCount > 0 {
+		signals["author_overlap"] = match.AuthorOverlap
+		if match.AuthorOverlap > 0 {
+			score += 0.1
+		} else {
+			score -= 0.2
+		}
+	}
+
+	if score > 1 {
+		score = 1
+	}
+	if score < 0 {
+		score = 0
+	}
+	match.SimilarityScore = score
+	match.Signals = signals
+}
+
+// findMangaDuplicates runs checkMangaDuplicates in a savepoint, so a failed
+// check (e.g. pg_trgm missing) does not abort the caller's transaction
+func (c *Crawler) findMangaDuplicates(ctx context.Context, tx pgx.Tx, manga ExternalManga, releaseYear *int) ([]DuplicateMatch, error) {
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := c.checkMangaDuplicates(ctx, sp, manga, releaseYear)
+	if err != nil {
+		sp.Rollback(ctx)
+		return nil, err
+	}
+	return matches, sp.Commit(ctx)
+}
+
+// queueDuplicateReview adds a candidate pair to the review queue. Pairs that
+// were already reviewed keep their decision.
+func (c *Crawler) queueDuplicateReview(ctx context.Context, tx pgx.Tx, komikID string, manga ExternalManga, match DuplicateMatch) error {
+	signals, err := json.Marshal(match.Signals)
+	if err != nil {
+		return fmt.Errorf("failed to marshal duplicate signals: %w", err)
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO "mKomikDuplicate" (komik_id, candidate_id, external_id, title, score, signals)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (external_id, candidate_id) DO UPDATE SET
+			komik_id = EXCLUDED.komik_id,
+			title = EXCLUDED.title,
+			score = EXCLUDED.score,
+			signals = EXCLUDED.signals
+		WHERE "mKomikDuplicate".status = 'pending'
+	`, komikID, match.ID, manga.ID, manga.Title, match.SimilarityScore, signals)
+	if err != nil {
+		return fmt.Errorf("failed to queue duplicate review for %s: %w", manga.ID, err)
+	}
+	return nil
+}
+
+// internalMangaID returns the comic ID of an upstream manga ID, following
+// merges: a manga merged into another comic resolves to that comic
+func internalMangaID(ctx context.Context, tx pgx.Tx, externalID string) (string, error) {
+	var id string
+	err := tx.QueryRow(ctx, `
+		SELECT id FROM "mKomik" WHERE external_id = $1
+		UNION ALL
+		SELECT candidate_id FROM "mKomikDuplicate" WHERE external_id = $1 AND status = 'merged'
+		LIMIT 1
+	`, externalID).Scan(&id)
+	return id, err
+}
+
+// mergedMangaID returns the comic an upstream manga ID was merged into, or ""
+func mergedMangaID(ctx context.Context, tx pgx.Tx, externalID string) (string, error) {
+	var id string
+	err := tx.QueryRow(ctx, `
+		SELECT candidate_id FROM "mKomikDuplicate"
+		WHERE external_id = $1 AND status = 'merged'
+		LIMIT 1
+	`, externalID).Scan(&id)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+	return id, err
+}
+
+// ListDuplicateReviews returns duplicate reviews with the given status ("" =
+// any), highest score first, and how many there are in total
+func (c *Crawler) ListDuplicateReviews(ctx context.Context, status string, limit, offset int) ([]DuplicateReview, int, error) {
+	var total int
+	if err := c.db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM "mKomikDuplicate" WHERE ($1 = '' OR status = $1)
+	`, status).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count duplicate reviews: %w", err)
+	}
+
+	rows, err := c.db.Pool.Query(ctx, `
+		SELECT d.id, d.komik_id, d.candidate_id, d.external_id, d.title, k.title,
+			d.score, d.signals, d.status, d.created_at, d.resolved_at, COALESCE(d.resolved_by, '')
+		FROM "mKomikDuplicate" d
+		JOIN "mKomik" k ON k.id = d.candidate_id
+		WHERE ($1 = '' OR d.status = $1)
+		ORDER BY d.score DESC, d.id
+		LIMIT $2 OFFSET $3
+	`, status, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list duplicate reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := []DuplicateReview{}
+	for rows.Next() {
+		var r DuplicateReview
+		var signals []byte
+		if err := rows.Scan(&r.ID, &r.KomikID, &r.CandidateID, &r.ExternalID, &r.Title, &r.CandidateTitle,
+			&r.Score, &signals, &r.Status, &r.CreatedAt, &r.ResolvedAt, &r.ResolvedBy); err != nil {
+			return nil, 0, err
+		}
+		if err := json.Unmarshal(signals, &r.Signals); err != nil {
+			return nil, 0, fmt.Errorf("failed to unmarshal duplicate signals: %w", err)
+		}
+		reviews = append(reviews, r)
+	}
+	return reviews, total, rows.Err()
+}
+
+// ResolveDuplicate records an admin decision on a pending duplicate review:
+//   - merged: the new comic's chapters, relations and user data move to the
+//     candidate and the new comic is deleted; its upstream ID keeps resolving
+//     to the candidate, so later crawls update the candidate's chapters
+//   - linked: both comics stay, sharing a work_id
+//   - distinct: nothing changes; the pair is not queued again
+//
+// resolvedBy is the admin's user ID, kept on the review.
+func (c *Crawler) ResolveDuplicate(ctx context.Context, id int64, decision, resolvedBy string) (*DuplicateReview, error) {
+	if decision != DuplicateMerged && decision != DuplicateLinked && decision != DuplicateDistinct {
+		return nil, fmt.Errorf("unknown decision: %s", decision)
+	}
+	if resolvedBy == "" {
+		return nil, fmt.Errorf("duplicate review %d needs an admin to resolve it", id)
+	}
+
+	tx, err := c.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	var review DuplicateReview
+	err = tx.QueryRow(ctx, `
+		SELECT id, komik_id, candidate_id, external_id, title, score, status
+		FROM "mKomikDuplicate"
+		WHERE id = $1 AND status = 'pending'
+		FOR UPDATE
+	`, id).Scan(&review.ID, &review.KomikID, &review.CandidateID, &review.ExternalID,
+		&review.Title, &review.Score, &review.Status)
+	if err == pgx.ErrNoRows {
+		return nil, ErrDuplicateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load duplicate review %d: %w", id, err)
+	}
+	if review.KomikID == nil && decision != DuplicateDistinct {
+		return nil, fmt.Errorf("comic of duplicate review %d no longer exists", id)
+	}
+
+	switch decision {
+	case DuplicateMerged:
+		if err := mergeKomik(ctx, tx, *review.KomikID, review.CandidateID); err != nil {
+			return nil, err
+		}
+		// The merged comic's other open reviews are moot
+		if _, err := tx.Exec(ctx, `
+			UPDATE "mKomikDuplicate" SET status = 'distinct', resolved_at = NOW(), resolved_by = $3
+			WHERE external_id = $1 AND id <> $2 AND status = 'pending'
+		`, review.ExternalID, review.ID, resolvedBy); err != nil {
+			return nil, fmt.Errorf("failed to close duplicate reviews of %s: %w", review.ExternalID, err)
+		}
+	case DuplicateLinked:
+		if err := linkKomik(ctx, tx, *review.KomikID, review.CandidateID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.QueryRow(ctx, `
+		UPDATE "mKomikDuplicate" SET status = $2, resolved_at = NOW(), resolved_by = $3
+		WHERE id = $1
+		RETURNING status, resolved_at, resolved_by
+	`, review.ID, decision, resolvedBy).Scan(&review.Status, &review.ResolvedAt, &review.ResolvedBy); err != nil {
+		return nil, fmt.Errorf("failed to resolve duplicate review %d: %w", id, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &review, nil
+}
+
+// linkKomik gives two comics the same work_id, joining any work either
+// already belongs to
+func linkKomik(ctx context.Context, tx pgx.Tx, komikID, candidateID string) error {
+	_, err := tx.Exec(ctx, `
+		WITH work AS (
+			SELECT COALESCE(
+				(SELECT work_id FROM "mKomik" WHERE id = $2),
+				(SELECT work_id FROM "mKomik" WHERE id = $1),
+				$2::uuid
+			) AS id
+		)
+		UPDATE "mKomik" SET work_id = (SELECT id FROM work), updated_at = NOW()
+		WHERE id IN ($1, $2)
+		OR work_id IN (
+			SELECT work_id FROM "mKomik" WHERE id IN ($1, $2) AND work_id IS NOT NULL
+		)
+	`, komikID, candidateID)
+	if err != nil {
+		return fmt.Errorf("failed to link %s and %s: %w", komikID, candidateID, err)
+	}
+	return nil
+}
+
+// mergeKomik moves everything of comic from into comic into and deletes
+// from. Chapters into already has (same number) are folded into its chapter;
+// rows that would duplicate one of into's (same user, same relation) are dropped.
+func mergeKomik(ctx context.Context, tx pgx.Tx, from, into string) error {
+	steps := []struct {
+		name  string
+		query string
+	}{
+		// Chapters into already has: repoint user data to into's chapter, then drop them
+		{"chapter history", `
+			UPDATE "trUserHistory" h SET id_chapter = t.id, id_komik = $2
+			FROM "mChapter" f JOIN "mChapter" t ON t.id_komik = $2 AND t.chapter_number = f.chapter_number
+			WHERE f.id_komik = $1 AND h.id_chapter = f.id
+			AND NOT EXISTS (SELECT 1 FROM "trUserHistory" x WHERE x.id_user = h.id_user AND x.id_chapter = t.id)`},
+		{"chapter comments", `
+			UPDATE "trComments" cm SET id_chapter = t.id
+			FROM "mChapter" f JOIN "mChapter" t ON t.id_komik = $2 AND t.chapter_number = f.chapter_number
+			WHERE f.id_komik = $1 AND cm.id_chapter = f.id`},
+		{"chapter votes", `
+			UPDATE "trChapterVote" v SET id_chapter = t.id
+			FROM "mChapter" f JOIN "mChapter" t ON t.id_komik = $2 AND t.chapter_number = f.chapter_number
+			WHERE f.id_komik = $1 AND v.id_chapter = f.id
+			AND NOT EXISTS (SELECT 1 FROM "trChapterVote" x WHERE x.id_user = v.id_user AND x.id_chapter = t.id)`},
+		{"duplicate chapters", `
+			WITH dup AS (
+				SELECT f.id FROM "mChapter" f JOIN "mChapter" t ON t.id_komik = $2 AND t.chapter_number = f.chapter_number
+				WHERE f.id_komik = $1
+			), history AS (
+				DELETE FROM "trUserHistory" WHERE id_chapter IN (SELECT id FROM dup)
+			), votes AS (
+				DELETE FROM "trChapterVote" WHERE id_chapter IN (SELECT id FROM dup)
+			), pages AS (
+				DELETE FROM "trChapter" WHERE id_chapter IN (SELECT id FROM dup)
+			)
+			DELETE FROM "mChapter" WHERE id IN (SELECT id FROM dup)`},
+		{"chapters", `UPDATE "mChapter" SET id_komik = $2, updated_at = NOW() WHERE id_komik = $1`},
+		{"history", `UPDATE "trUserHistory" SET id_komik = $2 WHERE id_komik = $1`},
+		{"comments", `UPDATE "trComments" SET id_komik = $2 WHERE id_komik = $1`},
+		{"bookmarks", `
+			UPDATE "trUserBookmark" b SET id_komik = $2 WHERE id_komik = $1
+			AND NOT EXISTS (SELECT 1 FROM "trUserBookmark" x WHERE x.id_user = b.id_user AND x.id_komik = $2)`},
+		{"votes", `
+			UPDATE "mKomikVote" v SET id_komik = $2 WHERE id_komik = $1
+			AND NOT EXISTS (SELECT 1 FROM "mKomikVote" x WHERE x.id_user = v.id_user AND x.id_komik = $2)`},
+		{"genres", `INSERT INTO "trGenre" (id_komik, id_genre) SELECT $2, id_genre FROM "trGenre" WHERE id_komik = $1 ON CONFLICT DO NOTHING`},
+		{"authors", `INSERT INTO "trAuthor" (id_komik, id_author) SELECT $2, id_author FROM "trAuthor" WHERE id_komik = $1 ON CONFLICT DO NOTHING`},
+		{"artists", `INSERT INTO "trArtist" (id_komik, id_artist) SELECT $2, id_artist FROM "trArtist" WHERE id_komik = $1 ON CONFLICT DO NOTHING`},
+		{"formats", `INSERT INTO "trFormat" (id_komik, id_format) SELECT $2, id_format FROM "trFormat" WHERE id_komik = $1 ON CONFLICT DO NOTHING`},
+		{"types", `INSERT INTO "trType" (id_komik, id_type) SELECT $2, id_type FROM "trType" WHERE id_komik = $1 ON CONFLICT DO NOTHING`},
+		{"leftovers", `
+			WITH bookmarks AS (DELETE FROM "trUserBookmark" WHERE id_komik = $1),
+			votes AS (DELETE FROM "mKomikVote" WHERE id_komik = $1),
+			genres AS (DELETE FROM "trGenre" WHERE id_komik = $1),
+			authors AS (DELETE FROM "trAuthor" WHERE id_komik = $1),
+			artists AS (DELETE FROM "trArtist" WHERE id_komik = $1),
+			formats AS (DELETE FROM "trFormat" WHERE id_komik = $1),
+			types AS (DELETE FROM "trType" WHERE id_komik = $1),
+			popular AS (DELETE FROM "mPopular" WHERE id_komik = $1),
+			recommended AS (DELETE FROM "mRecomed" WHERE id_komik = $1)
+			DELETE FROM "mKomik" WHERE id = $1`},
+	}
+
+	for _, step := range steps {
+		if _, err := tx.Exec(ctx, step.query, from, into); err != nil {
+			return fmt.Errorf("failed to merge %s of %s into %s: %w", step.name, from, into, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Entity types for recorded item failures
+const (
+	EntityMangaPage = "manga_page" // a manga list page (ExternalID is the page number)
+	EntityManga     = "manga"      // chapter list of a manga
+	EntityChapter   = "chapter"    // page detail of a chapter
+)
+
+// maxRecordedFailures bounds the in-memory failure history
+const maxRecordedFailures = 500
+
+// ItemFailure is an item that failed for good, after retries
+type ItemFailure struct {
+	Entity     string    `json:"entity"`
+	ExternalID string    `json:"external_id"`
+	Reason     string    `json:"reason"` // e.g. "http_503", "network", "timeout", "error"
+	Error      string    `json:"error"`
+	Attempts   int       `json:"attempts"`
+	FailedAt   time.Time `json:"failed_at"`
+}
+
+// FailureSummary is the failure history exposed by status endpoints
+type FailureSummary struct {
+	Total    int            `json:"total"`
+	ByReason map[string]int `json:"by_reason"`
+	Recent   []ItemFailure  `json:"recent"`
+}
+
+// failureLog keeps the most recent item failures and counts by reason
+type failureLog struct {
+	mu       sync.Mutex
+	items    []ItemFailure
+	total    int
+	byReason map[string]int
+}
+
+func newFailureLog() *failureLog {
+	return &failureLog{byReason: make(map[string]int)}
+}
+
+// recordFailure records an item's final failure with its reason, reports it
+// as an error event of the running job and, unless this is a dry run, keeps
+// it in the dead-letter table for a later retry
+func (c *Crawler) recordFailure(ctx context.Context, entity, externalID string, err error) {
+	failure := ItemFailure{
+		Entity:     entity,
+		ExternalID: externalID,
+		Reason:     failureReason(err),
+		Error:      err.Error(),
+		Attempts:   failureAttempts(err),
+		FailedAt:   time.Now(),
+	}
+
+	jobEvent(ctx, EventError, fmt.Sprintf("❌ %s %s failed after %d attempt(s) [%s]: %v",
+		entity, externalID, failure.Attempts, failure.Reason, err), map[string]interface{}{
+		"entity":      entity,
+		"external_id": externalID,
+		"reason":      failure.Reason,
+		"attempts":    failure.Attempts,
+	})
+
+	if !c.config.DryRun {
+		if err := c.saveDeadLetter(ctx, failure); err != nil {
+			jobEvent(ctx, EventWarning, fmt.Sprintf("Warning: %v", err), nil)
+		}
+	}
+
+	l := c.failures
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.total++
+	l.byReason[failure.Reason]++
+	l.items = append(l.items, failure)
+	if len(l.items) > maxRecordedFailures {
+		l.items = l.items[len(l.items)-maxRecordedFailures:]
+	}
+}
+
+// Failures returns a summary of the item failures recorded by this process,
+// with up to limit most recent first (see DeadLetterSummary for every worker)
+func (c *Crawler) Failures(limit int) FailureSummary {
+	l := c.failures
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	summary := FailureSummary{
+		Total:    l.total,
+		ByReason: make(map[string]int, len(l.byReason)),
+		Recent:   []ItemFailure{},
+	}
+	for reason, n := range l.byReason {
+		summary.ByReason[reason] = n
+	}
+	for i := len(l.items) - 1; i >= 0 && len(summary.Recent) < limit; i-- {
+		summary.Recent = append(summary.Recent, l.items[i])
+	}
+	return summary
+}
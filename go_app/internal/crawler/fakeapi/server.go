@@ -0,0 +1,346 @@
+// Package fakeapi provides an offline stand-in for the Shinigami upstream API.
+//
+// It serves the manga list, chapter list and chapter detail shapes the crawler
+// expects from fixture files, and can inject upstream misbehaviour (429s,
+// malformed JSON, slow responses, odd pagination) so the crawler and the
+// auto-updater can be exercised end to end without network access.
+package fakeapi
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed fixtures
+var embedded embed.FS
+
+// DefaultFixtures returns the fixture set bundled with the package
+func DefaultFixtures() fs.FS {
+	sub, err := fs.Sub(embedded, "fixtures")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// DefaultPageSize matches the page size the real API uses
+const DefaultPageSize = 24
+
+// Fault describes a misbehaviour injected into matching requests
+type Fault struct {
+	PathPrefix string        // request path prefix to match, relative to /v1 (e.g. "/manga/list")
+	Status     int           // respond with this status instead of serving the fixture
+	RetryAfter string        // optional Retry-After header sent with Status
+	Malformed  bool          // respond 200 with a truncated JSON body
+	Delay      time.Duration // wait before responding
+	Times      int           // number of requests affected, 0 means every request
+}
+
+// Server is a fake upstream API backed by fixture files.
+//
+// Fixture layout:
+//
+//	manga.json                     array of manga objects
+//	chapters/<manga_id>.json       array of chapters, newest first
+//	chapter_detail/<chapter_id>.json
+//	genres.json, formats.json, types.json, authors.json, artists.json
+type Server struct {
+	*httptest.Server
+
+	fixtures fs.FS
+
+	mu            sync.Mutex
+	faults        []*Fault
+	hits          map[string]int
+	pageSize      int
+	hideTotalPage bool
+}
+
+// New starts a fake API serving the given fixtures
+func New(fixtures fs.FS) *Server {
+	s := NewUnstarted(fixtures)
+	s.Start()
+	return s
+}
+
+// NewFromDir starts a fake API serving fixtures from a directory
+func NewFromDir(dir string) *Server {
+	return New(os.DirFS(dir))
+}
+
+// NewUnstarted creates a fake API without starting it, so the caller can
+// choose the listener (see cmd/fake-upstream)
+func NewUnstarted(fixtures fs.FS) *Server {
+	s := &Server{
+		fixtures: fixtures,
+		hits:     make(map[string]int),
+	}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// BaseURL returns the value to use as crawler.Config.BaseURL
+func (s *Server) BaseURL() string {
+	return s.URL + "/v1"
+}
+
+// Inject adds a fault. Faults are evaluated in the order they were added.
+func (s *Server) Inject(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all injected faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// SetPageSize forces a page size for every paginated endpoint regardless of
+// the page_size query parameter. 0 restores the default behaviour.
+func (s *Server) SetPageSize(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pageSize = n
+}
+
+// HideTotalPage omits total_page/total_record from list meta, like some
+// upstream endpoints do, forcing clients to stop on an empty page
+func (s *Server) HideTotalPage(hide bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hideTotalPage = hide
+}
+
+// Hits returns how many requests were received for paths with the given prefix
+func (s *Server) Hits(pathPrefix string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	total := 0
+	for p, n := range s.hits {
+		if strings.HasPrefix(p, pathPrefix) {
+			total += n
+		}
+	}
+	return total
+}
+
+// takeFault returns the first active fault matching the path, consuming one use
+func (s *Server) takeFault(p string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hits[p]++
+	for i, f := range s.faults {
+		if !strings.HasPrefix(p, f.PathPrefix) {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/v1/") {
+		http.NotFound(w, r)
+		return
+	}
+	p := strings.TrimPrefix(r.URL.Path, "/v1")
+
+	if f := s.takeFault(p); f != nil {
+		if f.Delay > 0 {
+			select {
+			case <-time.After(f.Delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if f.Status != 0 {
+			if f.RetryAfter != "" {
+				w.Header().Set("Retry-After", f.RetryAfter)
+			}
+			writeJSON(w, f.Status, map[string]interface{}{
+				"retcode": f.Status,
+				"message": http.StatusText(f.Status),
+			})
+			return
+		}
+		if f.Malformed {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"retcode":0,"message":"success","data":[{"manga_id":`))
+			return
+		}
+	}
+
+	query := r.URL.Query()
+	page := queryInt(query.Get("page"), 1)
+	pageSize := queryInt(query.Get("page_size"), DefaultPageSize)
+
+	switch {
+	case p == "/manga/list":
+		s.serveList(w, "manga.json", "", page, pageSize)
+	case strings.HasPrefix(p, "/chapter/detail/"):
+		id := path.Base(p)
+		s.serveObject(w, "chapter_detail/"+id+".json")
+	case strings.HasPrefix(p, "/chapter/") && strings.HasSuffix(p, "/list"):
+		mangaID := strings.TrimSuffix(strings.TrimPrefix(p, "/chapter/"), "/list")
+		s.serveList(w, "chapters/"+mangaID+".json", "", page, pageSize)
+	case p == "/genre/list":
+		s.serveList(w, "genres.json", "", 1, 0)
+	case p == "/format/list":
+		s.serveList(w, "formats.json", "", page, pageSize)
+	case p == "/type/list":
+		s.serveList(w, "types.json", "", page, pageSize)
+	case p == "/author/list":
+		s.serveList(w, "authors.json", query.Get("q"), page, pageSize)
+	case p == "/artist/list":
+		s.serveList(w, "artists.json", query.Get("q"), page, pageSize)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"retcode": 404, "message": "not found"})
+	}
+}
+
+// serveList serves a paginated slice of a fixture array. pageSize 0 disables pagination.
+func (s *Server) serveList(w http.ResponseWriter, name, q string, page, pageSize int) {
+	items, err := s.loadArray(name)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"retcode": 500, "message": err.Error()})
+		return
+	}
+
+	if q != "" {
+		items = filterByName(items, q)
+	}
+
+	s.mu.Lock()
+	if s.pageSize > 0 && pageSize > 0 {
+		pageSize = s.pageSize
+	}
+	hideTotal := s.hideTotalPage
+	s.mu.Unlock()
+
+	total := len(items)
+	totalPage := 1
+	if pageSize > 0 {
+		totalPage = (total + pageSize - 1) / pageSize
+		start := (page - 1) * pageSize
+		end := start + pageSize
+		if start > total {
+			start = total
+		}
+		if end > total {
+			end = total
+		}
+		items = items[start:end]
+	} else {
+		pageSize = total
+	}
+
+	meta := map[string]interface{}{
+		"request_id":   fmt.Sprintf("fake-%d", time.Now().UnixNano()),
+		"timestamp":    time.Now().Unix(),
+		"process_time": "0ms",
+		"page":         page,
+		"page_size":    pageSize,
+	}
+	if !hideTotal {
+		meta["total_page"] = totalPage
+		meta["total_record"] = total
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"retcode": 0,
+		"message": "success",
+		"meta":    meta,
+		"data":    items,
+	})
+}
+
+// serveObject serves a single fixture object
+func (s *Server) serveObject(w http.ResponseWriter, name string) {
+	data, err := fs.ReadFile(s.fixtures, name)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"retcode": 404, "message": "not found"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"retcode": 0,
+		"message": "success",
+		"meta": map[string]interface{}{
+			"request_id":   fmt.Sprintf("fake-%d", time.Now().UnixNano()),
+			"timestamp":    time.Now().Unix(),
+			"process_time": "0ms",
+		},
+		"data": json.RawMessage(data),
+	})
+}
+
+// loadArray reads a fixture array. A missing file is served as an empty list.
+func (s *Server) loadArray(name string) ([]json.RawMessage, error) {
+	data, err := fs.ReadFile(s.fixtures, name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []json.RawMessage{}, nil
+		}
+		return nil, err
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", name, err)
+	}
+	return items, nil
+}
+
+// filterByName keeps items whose "name" contains q, case-insensitively
+func filterByName(items []json.RawMessage, q string) []json.RawMessage {
+	q = strings.ToLower(q)
+	filtered := make([]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		var named struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(item, &named); err != nil {
+			continue
+		}
+		if strings.Contains(strings.ToLower(named.Name), q) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+func queryInt(value string, fallback int) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return fallback
+	}
+	return n
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
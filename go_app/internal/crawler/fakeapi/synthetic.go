@@ -0,0 +1,100 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SyntheticSpec sizes a generated fixture set
+type SyntheticSpec struct {
+	Manga            int // manga in manga.json
+	ChaptersPerManga int // chapters listed for each manga
+	PagesPerChapter  int // page images in each chapter detail
+}
+
+// syntheticNamespace seeds the generated IDs, so the same spec always yields
+// the same manga and chapter IDs
+var syntheticNamespace = uuid.MustParse("3c7e0d8a-5b1f-4f2e-9a64-1d2b8c0e7f15")
+
+// Synthetic generates a fixture set of the given size in the layout Server
+// serves, for load tests and benchmarks. Titles are random enough not to be
+// taken for duplicates of each other. Taxonomy lists are left empty.
+func Synthetic(spec SyntheticSpec) fs.FS {
+	files := fstest.MapFS{}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	manga := make([]map[string]interface{}, 0, spec.Manga)
+	for m := 0; m < spec.Manga; m++ {
+		mangaID := syntheticID("manga", m, 0)
+		manga = append(manga, map[string]interface{}{
+			"manga_id":        mangaID,
+			"title":           "Synthetic " + mangaID[:8],
+			"description":     fmt.Sprintf("Synthetic manga %d.", m+1),
+			"status":          1,
+			"country_id":      "KR",
+			"view_count":      1000 + m,
+			"cover_image_url": "https://storage.shngm.id/thumbnail/cover/" + mangaID + ".jpg",
+			"created_at":      base,
+			"updated_at":      base.Add(time.Duration(spec.Manga-m) * time.Hour), // listed newest first
+			"taxonomy":        map[string]interface{}{},
+		})
+
+		// Chapter lists are served newest first
+		chapters := make([]map[string]interface{}, 0, spec.ChaptersPerManga)
+		for n := spec.ChaptersPerManga; n >= 1; n-- {
+			chapterID := syntheticID("chapter", m, n)
+			released := base.Add(time.Duration(n) * 24 * time.Hour)
+			chapter := map[string]interface{}{
+				"chapter_id":          chapterID,
+				"manga_id":            mangaID,
+				"chapter_number":      float64(n),
+				"chapter_title":       fmt.Sprintf("Chapter %d", n),
+				"release_date":        released,
+				"view_count":          100 + n,
+				"thumbnail_image_url": "https://storage.shngm.id/thumbnail/chapter/" + chapterID + ".jpg",
+				"created_at":          released,
+				"updated_at":          released,
+			}
+			chapters = append(chapters, chapter)
+
+			pages := make([]string, spec.PagesPerChapter)
+			for p := range pages {
+				pages[p] = fmt.Sprintf("%02d-%s.jpg", p+1, chapterID[:8])
+			}
+			detail := map[string]interface{}{
+				"base_url":     "https://storage.shngm.id",
+				"base_url_low": "https://storage.shngm.id/low/unsafe/filters:format(webp):quality(70)",
+				"chapter": map[string]interface{}{
+					"path": fmt.Sprintf("/chapter/manga_%s/chapter_%s/", mangaID, chapterID),
+					"data": pages,
+				},
+			}
+			for k, v := range chapter {
+				detail[k] = v
+			}
+			files["chapter_detail/"+chapterID+".json"] = jsonFile(detail)
+		}
+		files["chapters/"+mangaID+".json"] = jsonFile(chapters)
+	}
+	files["manga.json"] = jsonFile(manga)
+
+	return files
+}
+
+// syntheticID returns a stable UUID for a generated manga or chapter
+func syntheticID(kind string, manga, chapter int) string {
+	return uuid.NewSHA1(syntheticNamespace, []byte(fmt.Sprintf("%s/%d/%d", kind, manga, chapter))).String()
+}
+
+func jsonFile(v interface{}) *fstest.MapFile {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return &fstest.MapFile{Data: data}
+}
@@ -0,0 +1,199 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Job event levels
+const (
+	EventInfo     = "info"
+	EventProgress = "progress"
+	EventWarning  = "warning"
+	EventError    = "error"
+)
+
+const (
+	// jobLogFlushInterval is how often buffered job events are written to the database
+	jobLogFlushInterval = time.Second
+	// maxJobEvents is how many events are kept per job; older ones are pruned
+	maxJobEvents = 2000
+)
+
+// JobEvent is one structured log entry of a crawl job
+type JobEvent struct {
+	ID        int64                  `json:"id"`
+	JobID     string                 `json:"job_id"`
+	Level     string                 `json:"level"`
+	Message   string                 `json:"message"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// jobLog buffers a running job's events and writes them to "mCrawlJobEvent"
+// in batches, so they can be streamed from any process sharing the database
+type jobLog struct {
+	queue   *JobQueue
+	jobID   string
+	mu      sync.Mutex
+	pending []JobEvent
+}
+
+type jobLogKey struct{}
+
+// withJobLog returns a context whose job events go to l
+func withJobLog(ctx context.Context, l *jobLog) context.Context {
+	return context.WithValue(ctx, jobLogKey{}, l)
+}
+
+// jobEvent logs a message and, when ctx belongs to a running job, records it
+// as a job event
+func jobEvent(ctx context.Context, level, message string, data map[string]interface{}) {
+	log.Println(message)
+	recordJobEvent(ctx, level, message, data)
+}
+
+// recordJobEvent records a job event without logging it (for frequent progress ticks)
+func recordJobEvent(ctx context.Context, level, message string, data map[string]interface{}) {
+	if l, ok := ctx.Value(jobLogKey{}).(*jobLog); ok {
+		l.add(level, message, data)
+	}
+}
+
+func (l *jobLog) add(level, message string, data map[string]interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending = append(l.pending, JobEvent{
+		JobID:     l.jobID,
+		Level:     level,
+		Message:   message,
+		Data:      data,
+		CreatedAt: time.Now(),
+	})
+}
+
+// run flushes buffered events every jobLogFlushInterval until done is closed,
+// then flushes what is left
+func (l *jobLog) run(done <-chan struct{}) {
+	ticker := time.NewTicker(jobLogFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.flush()
+		case <-done:
+			l.flush()
+			return
+		}
+	}
+}
+
+// flush writes buffered events and prunes the job's oldest events beyond maxJobEvents
+func (l *jobLog) flush() {
+	l.mu.Lock()
+	events := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+
+	if len(events) == 0 {
+		return
+	}
+	if err := l.queue.addEvents(context.Background(), l.jobID, events); err != nil {
+		// Plain log only: reporting through jobEvent would buffer it again
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// addEvents inserts events of a job and prunes its oldest events beyond maxJobEvents
+func (q *JobQueue) addEvents(ctx context.Context, jobID string, events []JobEvent) error {
+	batch := &pgx.Batch{}
+	for _, event := range events {
+		data, err := json.Marshal(event.Data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal job event data: %w", err)
+		}
+		batch.Queue(`
+			INSERT INTO "mCrawlJobEvent" (job_id, level, message, data, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+		`, jobID, event.Level, event.Message, data, event.CreatedAt)
+	}
+	batch.Queue(`
+		DELETE FROM "mCrawlJobEvent"
+		WHERE job_id = $1
+		AND id <= (
+			SELECT id FROM "mCrawlJobEvent"
+			WHERE job_id = $1
+			ORDER BY id DESC
+			OFFSET $2 LIMIT 1
+		)
+	`, jobID, maxJobEvents)
+
+	if err := q.db.Pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to save job events: %w", err)
+	}
+	return nil
+}
+
+// Events returns up to limit events of a job with IDs after afterID, oldest first
+func (q *JobQueue) Events(ctx context.Context, jobID string, afterID int64, limit int) ([]JobEvent, error) {
+	rows, err := q.db.Pool.Query(ctx, `
+		SELECT id, job_id, level, message, data, created_at
+		FROM "mCrawlJobEvent"
+		WHERE job_id = $1 AND id > $2
+		ORDER BY id
+		LIMIT $3
+	`, jobID, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load job events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []JobEvent{}
+	for rows.Next() {
+		var event JobEvent
+		var data []byte
+		if err := rows.Scan(&event.ID, &event.JobID, &event.Level, &event.Message, &data, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &event.Data); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal job event data: %w", err)
+			}
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// progressEventData summarises a checkpoint for a progress event: counters,
+// percentage, elapsed time, rate and ETA (as in GetProgressReport)
+func progressEventData(checkpoint *CrawlCheckpoint) map[string]interface{} {
+	elapsed := time.Since(checkpoint.StartTime)
+	data := map[string]interface{}{
+		"phase":           checkpoint.Phase,
+		"current_page":    checkpoint.CurrentPage,
+		"processed":       checkpoint.TotalProcessed,
+		"total":           checkpoint.EstimatedTotal,
+		"success":         checkpoint.SuccessCount,
+		"errors":          checkpoint.ErrorCount,
+		"elapsed_seconds": int(elapsed.Seconds()),
+	}
+
+	if checkpoint.EstimatedTotal > 0 {
+		data["percent"] = float64(checkpoint.TotalProcessed) / float64(checkpoint.EstimatedTotal) * 100
+	}
+	if checkpoint.TotalProcessed > 0 && elapsed > 0 {
+		rate := float64(checkpoint.TotalProcessed) / elapsed.Seconds()
+		data["rate_per_second"] = rate
+		if remaining := checkpoint.EstimatedTotal - checkpoint.TotalProcessed; remaining > 0 {
+			data["eta_seconds"] = int(float64(remaining) / rate)
+		}
+	}
+	return data
+}
@@ -0,0 +1,357 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"baca-komik-api/database"
+)
+
+// Crawl job states
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// jobModes are the crawl modes a job can run (see Crawler.RunJob)
+var jobModes = map[string]bool{
+	"genres": true, "formats": true, "types": true, "authors": true, "artists": true,
+	"manga": true, "manga_incremental": true, "chapters": true, "pages": true, "pipeline": true, "all": true, "auto": true,
+	"retry_failures": true, "reprocess": true, "reconcile": true,
+}
+
+// ValidJobMode reports whether a job can run mode
+func ValidJobMode(mode string) bool {
+	return jobModes[mode]
+}
+
+// ErrJobActive is returned when (re)queueing a job that is already queued or running
+var ErrJobActive = errors.New("job is already queued or running")
+
+// JobParams are the parameters a crawl job runs with
+type JobParams struct {
+	Mode      string `json:"mode"`
+	StartPage int    `json:"start_page,omitempty"`
+	EndPage   int    `json:"end_page,omitempty"`
+	BatchSize int    `json:"batch_size,omitempty"`
+	MangaID   string `json:"manga_id,omitempty"`
+	DryRun    bool   `json:"dry_run,omitempty"`
+	Verbose   *bool  `json:"verbose,omitempty"`  // nil = worker's setting
+	BaseURL   string `json:"base_url,omitempty"` // "" = worker's upstream
+	Resume    bool   `json:"resume,omitempty"`   // continue from the job's checkpoint
+
+	// chapters/pipeline: stop each chapter list at the first page of known chapters
+	NewChaptersOnly bool `json:"new_chapters_only,omitempty"`
+
+	// retry_failures: dead letters to retry (all pending ones of FailureEntity if empty)
+	FailureIDs    []int64 `json:"failure_ids,omitempty"`
+	FailureEntity string  `json:"failure_entity,omitempty"`
+}
+
+// Options returns the crawler options the job runs with
+func (p JobParams) Options() Options {
+	return Options{
+		DryRun:    p.DryRun,
+		Verbose:   p.Verbose,
+		BatchSize: p.BatchSize,
+		BaseURL:   p.BaseURL,
+
+		NewChaptersOnly: p.NewChaptersOnly,
+	}
+}
+
+// Job is a crawl job in the "mCrawlJob" table
+type Job struct {
+	ID              string     `json:"id"`
+	Mode            string     `json:"mode"`
+	Status          string     `json:"status"`
+	Params          JobParams  `json:"params"`
+	WorkerID        string     `json:"worker_id,omitempty"`
+	CancelRequested bool       `json:"cancel_requested"`
+	Processed       int        `json:"processed"`
+	Succeeded       int        `json:"succeeded"`
+	Failed          int        `json:"failed"`
+	Total           int        `json:"total"`
+	Quarantined     int        `json:"quarantined"`
+	Error           string     `json:"error,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+	HeartbeatAt     *time.Time `json:"heartbeat_at,omitempty"`
+}
+
+// NewJobID returns a job ID for a new job of the given mode
+func NewJobID(prefix, mode string) string {
+	return fmt.Sprintf("%s_%s_%d", prefix, mode, time.Now().UnixMilli())
+}
+
+// JobQueue is the Postgres-backed crawl job queue. The API and cmd/crawler
+// share it: either can queue jobs, and any worker can claim them.
+type JobQueue struct {
+	db *database.DB
+}
+
+// NewJobQueue creates a job queue on db
+func NewJobQueue(db *database.DB) *JobQueue {
+	return &JobQueue{db: db}
+}
+
+const jobColumns = `
+	id, mode, status, params, COALESCE(worker_id, ''), cancel_requested,
+	processed_items, success_items, failed_items, total_items, quarantined_items, COALESCE(error_message, ''),
+	created_at, started_at, finished_at, heartbeat_at
+`
+
+// scanJob scans a row selected with jobColumns
+func scanJob(row pgx.Row) (*Job, error) {
+	var job Job
+	var params []byte
+	if err := row.Scan(
+		&job.ID, &job.Mode, &job.Status, &params, &job.WorkerID, &job.CancelRequested,
+		&job.Processed, &job.Succeeded, &job.Failed, &job.Total, &job.Quarantined, &job.Error,
+		&job.CreatedAt, &job.StartedAt, &job.FinishedAt, &job.HeartbeatAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(params, &job.Params); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job params: %w", err)
+	}
+	return &job, nil
+}
+
+// Enqueue queues a job for any worker. An existing finished job with the same
+// ID is queued again (used to resume it); ErrJobActive if it is still active.
+func (q *JobQueue) Enqueue(ctx context.Context, id string, params JobParams) (*Job, error) {
+	return q.upsert(ctx, id, params, JobQueued, "")
+}
+
+// Start registers a job that workerID runs right away, without queueing it
+func (q *JobQueue) Start(ctx context.Context, id string, params JobParams, workerID string) (*Job, error) {
+	return q.upsert(ctx, id, params, JobRunning, workerID)
+}
+
+func (q *JobQueue) upsert(ctx context.Context, id string, params JobParams, status, workerID string) (*Job, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job params: %w", err)
+	}
+
+	query := `
+		INSERT INTO "mCrawlJob" (id, mode, status, params, worker_id, started_at, heartbeat_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''),
+			CASE WHEN $3 = 'running' THEN NOW() END,
+			CASE WHEN $3 = 'running' THEN NOW() END)
+		ON CONFLICT (id) DO UPDATE SET
+			mode = EXCLUDED.mode,
+			status = EXCLUDED.status,
+			params = EXCLUDED.params,
+			worker_id = EXCLUDED.worker_id,
+			cancel_requested = false,
+			error_message = NULL,
+			started_at = EXCLUDED.started_at,
+			finished_at = NULL,
+			heartbeat_at = EXCLUDED.heartbeat_at,
+			updated_at = NOW()
+		WHERE "mCrawlJob".status NOT IN ('queued', 'running')
+		RETURNING ` + jobColumns
+
+	job, err := scanJob(q.db.Pool.QueryRow(ctx, query, id, params.Mode, status, data, workerID))
+	if err == pgx.ErrNoRows {
+		return nil, ErrJobActive
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save job %s: %w", id, err)
+	}
+	return job, nil
+}
+
+// Claim takes the oldest queued job for workerID, or returns nil if none is
+// queued. SKIP LOCKED lets several workers claim concurrently without
+// blocking on, or double-claiming, the same job.
+func (q *JobQueue) Claim(ctx context.Context, workerID string) (*Job, error) {
+	query := `
+		UPDATE "mCrawlJob"
+		SET status = 'running', worker_id = $1, started_at = NOW(), heartbeat_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM "mCrawlJob"
+			WHERE status = 'queued'
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING ` + jobColumns
+
+	job, err := scanJob(q.db.Pool.QueryRow(ctx, query, workerID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	return job, nil
+}
+
+// Heartbeat marks a running job as alive and reports whether it was asked to cancel
+func (q *JobQueue) Heartbeat(ctx context.Context, id string) (bool, error) {
+	var cancelRequested bool
+	err := q.db.Pool.QueryRow(ctx, `
+		UPDATE "mCrawlJob" SET heartbeat_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+		RETURNING cancel_requested
+	`, id).Scan(&cancelRequested)
+	if err != nil {
+		return false, fmt.Errorf("failed to update job heartbeat: %w", err)
+	}
+	return cancelRequested, nil
+}
+
+// UpdateCounters records a running job's progress
+func (q *JobQueue) UpdateCounters(ctx context.Context, id string, processed, succeeded, failed, total int) error {
+	_, err := q.db.Pool.Exec(ctx, `
+		UPDATE "mCrawlJob"
+		SET processed_items = $2, success_items = $3, failed_items = $4, total_items = $5, updated_at = NOW()
+		WHERE id = $1
+	`, id, processed, succeeded, failed, total)
+	if err != nil {
+		return fmt.Errorf("failed to update job counters: %w", err)
+	}
+	return nil
+}
+
+// AddQuarantined adds n to a job's count of quarantined records
+func (q *JobQueue) AddQuarantined(ctx context.Context, id string, n int) error {
+	_, err := q.db.Pool.Exec(ctx, `
+		UPDATE "mCrawlJob" SET quarantined_items = quarantined_items + $2, updated_at = NOW()
+		WHERE id = $1
+	`, id, n)
+	if err != nil {
+		return fmt.Errorf("failed to update job quarantine counter: %w", err)
+	}
+	return nil
+}
+
+// Finish records the outcome of a job: succeeded, cancelled (err is
+// context.Canceled) or failed with err's text
+func (q *JobQueue) Finish(ctx context.Context, id string, err error) error {
+	status := JobSucceeded
+	var message *string
+	switch {
+	case errors.Is(err, context.Canceled):
+		status = JobCancelled
+	case err != nil:
+		status = JobFailed
+		text := err.Error()
+		message = &text
+	}
+
+	if _, err := q.db.Pool.Exec(ctx, `
+		UPDATE "mCrawlJob"
+		SET status = $2, error_message = $3, finished_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, id, status, message); err != nil {
+		return fmt.Errorf("failed to finish job %s: %w", id, err)
+	}
+	return nil
+}
+
+// RequestCancel cancels a job, or every active job when id is empty. Queued
+// jobs are cancelled at once; running jobs are flagged and stopped by their
+// worker at its next heartbeat. Returns the IDs of the affected jobs.
+func (q *JobQueue) RequestCancel(ctx context.Context, id string) ([]string, error) {
+	rows, err := q.db.Pool.Query(ctx, `
+		UPDATE "mCrawlJob"
+		SET cancel_requested = true,
+			status = CASE WHEN status = 'queued' THEN 'cancelled' ELSE status END,
+			finished_at = CASE WHEN status = 'queued' THEN NOW() ELSE finished_at END,
+			updated_at = NOW()
+		WHERE status IN ('queued', 'running')
+		AND ($1 = '' OR id = $1)
+		RETURNING id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel jobs: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var jobID string
+		if err := rows.Scan(&jobID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, jobID)
+	}
+	return ids, rows.Err()
+}
+
+// Get returns a job, or nil if it does not exist
+func (q *JobQueue) Get(ctx context.Context, id string) (*Job, error) {
+	job, err := scanJob(q.db.Pool.QueryRow(ctx, `SELECT `+jobColumns+` FROM "mCrawlJob" WHERE id = $1`, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job %s: %w", id, err)
+	}
+	return job, nil
+}
+
+// List returns the most recent jobs, optionally only those with status
+func (q *JobQueue) List(ctx context.Context, status string, limit int) ([]Job, error) {
+	rows, err := q.db.Pool.Query(ctx, `
+		SELECT `+jobColumns+`
+		FROM "mCrawlJob"
+		WHERE $1 = '' OR status = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+// RecoverStale fails running jobs whose worker stopped sending heartbeats
+// (crashed or redeployed) so they can be resumed. Returns how many were failed.
+func (q *JobQueue) RecoverStale(ctx context.Context, staleAfter time.Duration) (int64, error) {
+	tag, err := q.db.Pool.Exec(ctx, `
+		UPDATE "mCrawlJob"
+		SET status = 'failed', error_message = 'worker stopped sending heartbeats', finished_at = NOW(), updated_at = NOW()
+		WHERE status = 'running'
+		AND heartbeat_at < NOW() - make_interval(secs => $1)
+	`, staleAfter.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to recover stale jobs: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// Prune deletes finished jobs older than retention. Returns how many were deleted.
+func (q *JobQueue) Prune(ctx context.Context, retention time.Duration) (int64, error) {
+	tag, err := q.db.Pool.Exec(ctx, `
+		DELETE FROM "mCrawlJob"
+		WHERE status IN ('succeeded', 'failed', 'cancelled')
+		AND finished_at < NOW() - make_interval(secs => $1)
+	`, retention.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune jobs: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,199 @@
+package crawler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// PageSet is a version of a chapter's pages. The current version is the one
+// in "trChapter"; superseded ones are kept in "mChapterPageSet" for
+// WorkerConfig.PageSetRetention.
+type PageSet struct {
+	Version      int        `json:"version"`
+	ContentHash  string     `json:"content_hash,omitempty"`
+	PageURLs     []string   `json:"page_urls"`
+	JobID        string     `json:"job_id,omitempty"`
+	ValidFrom    *time.Time `json:"valid_from,omitempty"`
+	SupersededAt *time.Time `json:"superseded_at,omitempty"`
+}
+
+// ChapterPageSets is the current page set of a chapter and the superseded
+// ones still kept, newest first
+type ChapterPageSets struct {
+	ChapterID  string    `json:"chapter_id"`
+	ExternalID string    `json:"external_id,omitempty"`
+	Current    PageSet   `json:"current"`
+	Superseded []PageSet `json:"superseded"`
+}
+
+// PageSetChange is a superseded page set, i.e. an upstream re-upload
+type PageSetChange struct {
+	ChapterID      string    `json:"chapter_id"`
+	ExternalID     string    `json:"external_id,omitempty"`
+	MangaTitle     string    `json:"manga_title"`
+	ChapterNumber  float64   `json:"chapter_number"`
+	Version        int       `json:"version"`
+	Pages          int       `json:"pages"`
+	CurrentVersion int       `json:"current_version"`
+	CurrentPages   int       `json:"current_pages"`
+	JobID          string    `json:"job_id,omitempty"`
+	SupersededAt   time.Time `json:"superseded_at"`
+}
+
+// currentPageSet is the page set stored on a chapter row
+type currentPageSet struct {
+	hash    *string
+	version int
+	at      *time.Time
+}
+
+// pageSetHash hashes the ordered page files of a chapter detail. The base
+// URL is left out so a CDN move is not taken for a re-upload.
+func pageSetHash(path string, filenames []string) string {
+	h := sha256.New()
+	h.Write([]byte(path))
+	for _, filename := range filenames {
+		h.Write([]byte{0})
+		h.Write([]byte(filename))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// supersedePageSet keeps the pages currently stored for a chapter as a
+// superseded page set before they are replaced by pageURLs. It reports
+// whether the pages changed: pages saved before page sets were versioned
+// (no hash) are only kept when they differ from pageURLs.
+func (c *Crawler) supersedePageSet(ctx context.Context, tx pgx.Tx, internalChapterID, externalChapterID string, current currentPageSet, pageURLs []string) (bool, error) {
+	var stored []string
+	if err := tx.QueryRow(ctx, `
+		SELECT COALESCE(array_agg(page_url ORDER BY page_number), '{}')
+		FROM "trChapter" WHERE id_chapter = $1
+	`, internalChapterID).Scan(&stored); err != nil {
+		return false, fmt.Errorf("failed to load current pages: %w", err)
+	}
+	if len(stored) == 0 {
+		return true, nil
+	}
+	if current.hash == nil && equalStrings(stored, pageURLs) {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO "mChapterPageSet" (id_chapter, version, content_hash, page_urls, job_id, valid_from)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		ON CONFLICT (id_chapter, version) DO NOTHING
+	`, internalChapterID, current.version, current.hash, stored, JobIDFromContext(ctx), current.at); err != nil {
+		return false, fmt.Errorf("failed to keep page set %d: %w", current.version, err)
+	}
+
+	jobEvent(ctx, EventWarning, fmt.Sprintf("🔁 Chapter %s was re-uploaded upstream: %d page(s) → %d (page set v%d → v%d)",
+		externalChapterID, len(stored), len(pageURLs), current.version, current.version+1),
+		map[string]interface{}{"chapter_id": externalChapterID, "version": current.version + 1})
+	return true, nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ChapterPageSets returns the page sets of a chapter by internal ID, or nil
+// if there is no such chapter
+func (c *Crawler) ChapterPageSets(ctx context.Context, chapterID string) (*ChapterPageSets, error) {
+	sets := &ChapterPageSets{ChapterID: chapterID, Superseded: []PageSet{}}
+	var hash *string
+	err := c.db.Pool.QueryRow(ctx, `
+		SELECT COALESCE(c.external_id, ''), c.page_set_hash, c.page_set_version, c.page_set_at,
+			COALESCE((SELECT array_agg(page_url ORDER BY page_number) FROM "trChapter" WHERE id_chapter = c.id), '{}')
+		FROM "mChapter" c WHERE c.id = $1
+	`, chapterID).Scan(&sets.ExternalID, &hash, &sets.Current.Version, &sets.Current.ValidFrom, &sets.Current.PageURLs)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load page set of chapter %s: %w", chapterID, err)
+	}
+	if hash != nil {
+		sets.Current.ContentHash = *hash
+	}
+
+	rows, err := c.db.Pool.Query(ctx, `
+		SELECT version, COALESCE(content_hash, ''), page_urls, COALESCE(job_id, ''), valid_from, superseded_at
+		FROM "mChapterPageSet" WHERE id_chapter = $1
+		ORDER BY version DESC
+	`, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load superseded page sets of chapter %s: %w", chapterID, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var set PageSet
+		if err := rows.Scan(&set.Version, &set.ContentHash, &set.PageURLs, &set.JobID,
+			&set.ValidFrom, &set.SupersededAt); err != nil {
+			return nil, err
+		}
+		sets.Superseded = append(sets.Superseded, set)
+	}
+	return sets, rows.Err()
+}
+
+// ListPageSetChanges returns superseded page sets, most recent first, and
+// how many there are in total
+func (c *Crawler) ListPageSetChanges(ctx context.Context, limit, offset int) ([]PageSetChange, int, error) {
+	var total int
+	if err := c.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM "mChapterPageSet"`).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count page set changes: %w", err)
+	}
+
+	rows, err := c.db.Pool.Query(ctx, `
+		SELECT c.id, COALESCE(c.external_id, ''), k.title, c.chapter_number,
+			s.version, cardinality(s.page_urls), c.page_set_version,
+			(SELECT COUNT(*) FROM "trChapter" t WHERE t.id_chapter = c.id),
+			COALESCE(s.job_id, ''), s.superseded_at
+		FROM "mChapterPageSet" s
+		JOIN "mChapter" c ON c.id = s.id_chapter
+		JOIN "mKomik" k ON k.id = c.id_komik
+		ORDER BY s.superseded_at DESC, s.id DESC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list page set changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []PageSetChange{}
+	for rows.Next() {
+		var ch PageSetChange
+		if err := rows.Scan(&ch.ChapterID, &ch.ExternalID, &ch.MangaTitle, &ch.ChapterNumber,
+			&ch.Version, &ch.Pages, &ch.CurrentVersion, &ch.CurrentPages, &ch.JobID, &ch.SupersededAt); err != nil {
+			return nil, 0, err
+		}
+		changes = append(changes, ch)
+	}
+	return changes, total, rows.Err()
+}
+
+// PrunePageSets deletes page sets superseded more than retention ago.
+// Returns how many were deleted.
+func (c *Crawler) PrunePageSets(ctx context.Context, retention time.Duration) (int64, error) {
+	tag, err := c.db.Pool.Exec(ctx, `
+		DELETE FROM "mChapterPageSet" WHERE superseded_at < NOW() - make_interval(secs => $1)
+	`, retention.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune page sets: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,284 @@
+package crawler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Archived payload kinds
+const (
+	PayloadManga         = "manga"          // manga list item
+	PayloadChapter       = "chapter"        // chapter list item
+	PayloadChapterDetail = "chapter_detail" // chapter detail with page images
+)
+
+// reprocessBatchSize is how many archived payloads are loaded at a time
+const reprocessBatchSize = 200
+
+// UnmarshalJSON decodes a manga and keeps the raw object in Raw
+func (m *ExternalManga) UnmarshalJSON(data []byte) error {
+	type plain ExternalManga
+	if err := json.Unmarshal(data, (*plain)(m)); err != nil {
+		return err
+	}
+	m.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// UnmarshalJSON decodes a chapter and keeps the raw object in Raw
+func (ch *ExternalChapter) UnmarshalJSON(data []byte) error {
+	type plain ExternalChapter
+	if err := json.Unmarshal(data, (*plain)(ch)); err != nil {
+		return err
+	}
+	ch.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// UnmarshalJSON decodes a chapter detail and keeps the raw object in Raw
+func (d *ExternalChapterDetail) UnmarshalJSON(data []byte) error {
+	type plain ExternalChapterDetail
+	if err := json.Unmarshal(data, (*plain)(d)); err != nil {
+		return err
+	}
+	d.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+type skipArchiveKey struct{}
+
+// withoutArchive returns a context whose saves do not archive payloads again,
+// for reprocessing payloads that come from the archive
+func withoutArchive(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipArchiveKey{}, true)
+}
+
+// rawPayload is an upstream object to archive
+type rawPayload struct {
+	externalID string
+	data       json.RawMessage
+}
+
+// archivePayloads stores the last raw payload of each item in "mRawPayload",
+// with its hash; changed_at only moves when the hash does. Items without a
+// raw payload (e.g. built by the auto-updater) are skipped, and nothing is
+// archived while reprocessing. Failures are logged rather than failing the crawl.
+func (c *Crawler) archivePayloads(ctx context.Context, kind string, payloads []rawPayload) {
+	if skip, _ := ctx.Value(skipArchiveKey{}).(bool); skip {
+		return
+	}
+	batch := &pgx.Batch{}
+	for _, p := range payloads {
+		if len(p.data) == 0 {
+			continue
+		}
+		sum := sha256.Sum256(p.data)
+		batch.Queue(`
+			INSERT INTO "mRawPayload" (entity_type, external_id, source, payload, content_hash, fetched_at, changed_at)
+			VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+			ON CONFLICT (entity_type, external_id) DO UPDATE SET
+				source = EXCLUDED.source,
+				payload = EXCLUDED.payload,
+				content_hash = EXCLUDED.content_hash,
+				fetched_at = EXCLUDED.fetched_at,
+				changed_at = CASE
+					WHEN "mRawPayload".content_hash = EXCLUDED.content_hash THEN "mRawPayload".changed_at
+					ELSE EXCLUDED.changed_at
+				END
+		`, kind, p.externalID, c.source.Name(), []byte(p.data), hex.EncodeToString(sum[:]))
+	}
+	if batch.Len() == 0 {
+		return
+	}
+
+	if err := c.db.Pool.SendBatch(ctx, batch).Close(); err != nil {
+		log.Printf("Warning: failed to archive %d %s payload(s): %v", batch.Len(), kind, err)
+	}
+}
+
+// storedPayload is an archived payload loaded for reprocessing
+type storedPayload struct {
+	ExternalID string
+	Data       []byte
+}
+
+// loadPayloads returns up to limit archived payloads of kind with external IDs
+// after afterID, in external ID order. mangaID, if set, keeps only that manga
+// (or its chapters).
+func (c *Crawler) loadPayloads(ctx context.Context, kind, mangaID, afterID string, limit int) ([]storedPayload, error) {
+	query := `
+		SELECT external_id, payload FROM "mRawPayload"
+		WHERE entity_type = $1 AND external_id > $2
+	`
+	args := []interface{}{kind, afterID, limit}
+	if mangaID != "" {
+		if kind == PayloadManga {
+			query += ` AND external_id = $4`
+		} else {
+			query += ` AND payload->>'manga_id' = $4`
+		}
+		args = append(args, mangaID)
+	}
+	query += ` ORDER BY external_id LIMIT $3`
+
+	rows, err := c.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s payloads: %w", kind, err)
+	}
+	defer rows.Close()
+
+	payloads := []storedPayload{}
+	for rows.Next() {
+		var p storedPayload
+		if err := rows.Scan(&p.ExternalID, &p.Data); err != nil {
+			return nil, err
+		}
+		payloads = append(payloads, p)
+	}
+	return payloads, rows.Err()
+}
+
+// countPayloads returns how many payloads Reprocess will go through
+func (c *Crawler) countPayloads(ctx context.Context, mangaID string) (int, error) {
+	var total int
+	err := c.db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM "mRawPayload"
+		WHERE $1 = ''
+		OR (entity_type = 'manga' AND external_id = $1)
+		OR (entity_type <> 'manga' AND payload->>'manga_id' = $1)
+	`, mangaID).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count payloads: %w", err)
+	}
+	return total, nil
+}
+
+// Reprocess re-derives "mKomik", "mChapter" and "trChapter" rows from the
+// archived payloads, without contacting the upstream: manga first, then
+// chapters, then chapter pages. mangaID limits it to one manga and its
+// chapters ("" or "all" = everything).
+func (c *Crawler) Reprocess(ctx context.Context, mangaID string) error {
+	if mangaID == "all" {
+		mangaID = ""
+	}
+	// Payloads are decoded with their raw form so they are validated like
+	// fresh ones, but they are already archived
+	ctx = withoutArchive(ctx)
+
+	total, err := c.countPayloads(ctx, mangaID)
+	if err != nil {
+		return err
+	}
+	jobEvent(ctx, EventInfo, fmt.Sprintf("Reprocessing %d archived payload(s)...", total), nil)
+
+	progress := c.startCheckpoint(ctx, "reprocess", nil)
+	progress.EstimatedTotal = total
+
+	for _, kind := range []string{PayloadManga, PayloadChapter, PayloadChapterDetail} {
+		afterID := ""
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			payloads, err := c.loadPayloads(ctx, kind, mangaID, afterID, reprocessBatchSize)
+			if err != nil {
+				return err
+			}
+			if len(payloads) == 0 {
+				break
+			}
+
+			saved, failed := c.reprocessBatch(ctx, kind, payloads)
+			progress.SuccessCount += saved
+			progress.ErrorCount += failed
+			progress.TotalProcessed += len(payloads)
+			c.reportProgress(ctx, progress)
+
+			afterID = payloads[len(payloads)-1].ExternalID
+		}
+	}
+
+	jobEvent(ctx, EventInfo, fmt.Sprintf("Reprocess completed: %d payloads processed, %d saved, %d failed",
+		progress.TotalProcessed, progress.SuccessCount, progress.ErrorCount), nil)
+	return nil
+}
+
+// reprocessBatch saves one batch of archived payloads of kind and returns how
+// many were saved and how many failed
+func (c *Crawler) reprocessBatch(ctx context.Context, kind string, payloads []storedPayload) (saved, failed int) {
+	switch kind {
+	case PayloadManga:
+		mangaList := make([]ExternalManga, 0, len(payloads))
+		for _, p := range payloads {
+			var manga ExternalManga
+			if err := json.Unmarshal(p.Data, &manga); err != nil {
+				log.Printf("Warning: invalid manga payload %s: %v", p.ExternalID, err)
+				failed++
+				continue
+			}
+			mangaList = append(mangaList, manga)
+		}
+		if len(mangaList) == 0 || c.config.DryRun {
+			return len(mangaList), failed
+		}
+		if err := c.saveMangaList(ctx, mangaList); err != nil {
+			log.Printf("Warning: failed to reprocess %d manga: %v", len(mangaList), err)
+			return 0, failed + len(mangaList)
+		}
+		return len(mangaList), failed
+
+	case PayloadChapter:
+		byManga := make(map[string][]ExternalChapter)
+		var order []string
+		for _, p := range payloads {
+			var chapter ExternalChapter
+			if err := json.Unmarshal(p.Data, &chapter); err != nil {
+				log.Printf("Warning: invalid chapter payload %s: %v", p.ExternalID, err)
+				failed++
+				continue
+			}
+			if _, ok := byManga[chapter.MangaID]; !ok {
+				order = append(order, chapter.MangaID)
+			}
+			byManga[chapter.MangaID] = append(byManga[chapter.MangaID], chapter)
+		}
+		for _, id := range order {
+			chapters := byManga[id]
+			if !c.config.DryRun {
+				if _, _, err := c.saveChaptersList(ctx, chapters, id); err != nil {
+					log.Printf("Warning: failed to reprocess %d chapters of manga %s: %v", len(chapters), id, err)
+					failed += len(chapters)
+					continue
+				}
+			}
+			saved += len(chapters)
+		}
+		return saved, failed
+
+	default:
+		for _, p := range payloads {
+			var detail ExternalChapterDetail
+			if err := json.Unmarshal(p.Data, &detail); err != nil {
+				log.Printf("Warning: invalid chapter detail payload %s: %v", p.ExternalID, err)
+				failed++
+				continue
+			}
+			if !c.config.DryRun {
+				if err := c.saveChapterPages(ctx, p.ExternalID, &detail); err != nil {
+					log.Printf("Warning: failed to reprocess pages of chapter %s: %v", p.ExternalID, err)
+					failed++
+					continue
+				}
+			}
+			saved++
+		}
+		return saved, failed
+	}
+}
@@ -0,0 +1,458 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Pipeline stage names, used as keys in CrawlCheckpoint.Stages
+const (
+	StageManga    = "manga"
+	StageChapters = "chapters"
+	StagePages    = "pages"
+)
+
+// pipelineCheckpointInterval is how often a running pipeline saves its checkpoint
+const pipelineCheckpointInterval = 10 * time.Second
+
+// maxDryRunChapterDetails bounds the chapter details a dry-run pipeline
+// fetches; later chapters are counted as skipped
+const maxDryRunChapterDetails = 20
+
+// PipelineConfig controls the concurrency and queue sizes of the crawl pipeline
+type PipelineConfig struct {
+	MangaWorkers   int // manga list pages fetched concurrently
+	ChapterWorkers int // manga whose chapter lists are fetched concurrently
+	PageWorkers    int // chapter details fetched concurrently
+	ChapterQueue   int // manga waiting for the chapter stage
+	PageQueue      int // chapters waiting for the page stage
+}
+
+// DefaultPipelineConfig returns conservative pipeline settings
+func DefaultPipelineConfig() PipelineConfig {
+	return PipelineConfig{
+		MangaWorkers:   1,
+		ChapterWorkers: 2,
+		PageWorkers:    4,
+		ChapterQueue:   100,
+		PageQueue:      500,
+	}
+}
+
+// withDefaults fills zero fields from DefaultPipelineConfig
+func (p PipelineConfig) withDefaults() PipelineConfig {
+	def := DefaultPipelineConfig()
+	if p.MangaWorkers <= 0 {
+		p.MangaWorkers = def.MangaWorkers
+	}
+	if p.ChapterWorkers <= 0 {
+		p.ChapterWorkers = def.ChapterWorkers
+	}
+	if p.PageWorkers <= 0 {
+		p.PageWorkers = def.PageWorkers
+	}
+	if p.ChapterQueue <= 0 {
+		p.ChapterQueue = def.ChapterQueue
+	}
+	if p.PageQueue <= 0 {
+		p.PageQueue = def.PageQueue
+	}
+	return p
+}
+
+// pipelineManga is a manga queued for the chapter stage, tagged with the
+// list page it came from so the manga stage checkpoint only advances once
+// every manga on a page has been handed off
+type pipelineManga struct {
+	id   string
+	page int
+}
+
+// pipelineChapter is a chapter queued for the page stage, tagged with the
+// manga list page its manga came from
+type pipelineChapter struct {
+	id   string
+	page int
+}
+
+// CrawlPipeline crawls manga, chapters and pages as a streaming pipeline:
+// manga list pages feed chapter-list workers through a bounded queue, and
+// chapter workers feed page-detail workers the same way. Each stage runs with
+// its own concurrency from Config.Pipeline. If endPage = -1, crawl all pages.
+//
+// Progress for every stage is saved in the checkpoint (phase "pipeline").
+// CurrentPage is the last manga list page whose manga have all been through
+// the chapter stage and whose chapters have all left the page stage, so
+// resuming from CurrentPage+1 does not skip any manga or pages.
+func (c *Crawler) CrawlPipeline(ctx context.Context, startPage, endPage int) error {
+	return c.crawlPipeline(ctx, startPage, endPage, nil)
+}
+
+// crawlPipeline runs the pipeline crawl, continuing the counters of resume if not nil
+func (c *Crawler) crawlPipeline(ctx context.Context, startPage, endPage int, resume *CrawlCheckpoint) error {
+	cfg := c.config.Pipeline.withDefaults()
+
+	if endPage == -1 {
+		jobEvent(ctx, EventInfo, fmt.Sprintf("🚀 Starting pipeline crawl of ALL manga from page %d...", startPage), nil)
+	} else {
+		jobEvent(ctx, EventInfo, fmt.Sprintf("🚀 Starting pipeline crawl of manga pages %d to %d...", startPage, endPage), nil)
+	}
+	log.Printf("Pipeline workers: manga=%d chapters=%d pages=%d, queues: chapters=%d pages=%d",
+		cfg.MangaWorkers, cfg.ChapterWorkers, cfg.PageWorkers, cfg.ChapterQueue, cfg.PageQueue)
+
+	progress := newPipelineProgress(c.startCheckpoint(ctx, "pipeline", resume), startPage, endPage, cfg)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pages := make(chan int)
+	mangaQueue := make(chan pipelineManga, cfg.ChapterQueue)
+	chapterQueue := make(chan pipelineChapter, cfg.PageQueue)
+
+	// lastPage shrinks as workers learn the real page count
+	lastPage := int64(math.MaxInt64)
+	if endPage != -1 {
+		lastPage = int64(endPage)
+	}
+	lowerLastPage := func(page int) {
+		for {
+			current := atomic.LoadInt64(&lastPage)
+			if int64(page) >= current || atomic.CompareAndSwapInt64(&lastPage, current, int64(page)) {
+				return
+			}
+		}
+	}
+
+	// Page dispatcher
+	go func() {
+		defer close(pages)
+		for page := startPage; int64(page) <= atomic.LoadInt64(&lastPage); page++ {
+			select {
+			case pages <- page:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Stage 1: manga list pages
+	var mangaWG sync.WaitGroup
+	for i := 0; i < cfg.MangaWorkers; i++ {
+		mangaWG.Add(1)
+		go func() {
+			defer mangaWG.Done()
+			for page := range pages {
+				if int64(page) > atomic.LoadInt64(&lastPage) {
+					progress.mangaPageFetched(page, 0)
+					continue
+				}
+
+				result, err := c.source.ListManga(ctx, page)
+				if err != nil {
+					if ctx.Err() != nil {
+						return
+					}
+					c.recordFailure(ctx, EntityMangaPage, strconv.Itoa(page), err)
+					progress.record(StageManga, "", page, err)
+					progress.mangaPageFetched(page, 0)
+					continue
+				}
+
+				if result.TotalPage > 0 {
+					lowerLastPage(result.TotalPage)
+				}
+				if len(result.Manga) == 0 {
+					log.Printf("No more manga found on page %d", page)
+					lowerLastPage(page - 1)
+					progress.mangaPageFetched(page, 0)
+					continue
+				}
+
+				if c.config.DryRun {
+					log.Printf("DRY RUN: Would save %d manga from page %d", len(result.Manga), page)
+				} else if err := c.saveMangaList(ctx, result.Manga); err != nil {
+					if ctx.Err() != nil {
+						return
+					}
+					c.recordFailure(ctx, EntityMangaPage, strconv.Itoa(page), err)
+					progress.record(StageManga, "", page, err)
+					progress.mangaPageFetched(page, 0)
+					continue
+				}
+
+				progress.record(StageManga, result.Manga[len(result.Manga)-1].ID, page, nil)
+				progress.mangaPageFetched(page, len(result.Manga))
+				for _, manga := range result.Manga {
+					select {
+					case mangaQueue <- pipelineManga{id: manga.ID, page: page}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+	}
+	go func() {
+		mangaWG.Wait()
+		close(mangaQueue)
+	}()
+
+	// Stage 2: chapter lists
+	var chapterWG sync.WaitGroup
+	for i := 0; i < cfg.ChapterWorkers; i++ {
+		chapterWG.Add(1)
+		go func() {
+			defer chapterWG.Done()
+			for manga := range mangaQueue {
+				_, err := c.crawlChaptersForManga(ctx, manga.id, c.config.NewChaptersOnly, func(chapters []ExternalChapter) error {
+					for _, chapter := range chapters {
+						progress.chapterQueued(manga.page)
+						select {
+						case chapterQueue <- pipelineChapter{id: chapter.ID, page: manga.page}:
+						case <-ctx.Done():
+							return ctx.Err()
+						}
+					}
+					return nil
+				})
+				if ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					c.recordFailure(ctx, EntityManga, manga.id, err)
+				}
+				progress.record(StageChapters, manga.id, 0, err)
+				progress.itemDone(manga.page)
+			}
+		}()
+	}
+	go func() {
+		chapterWG.Wait()
+		close(chapterQueue)
+	}()
+
+	// Stage 3: chapter pages
+	var pageWG sync.WaitGroup
+	var dryRunDetails int64
+	for i := 0; i < cfg.PageWorkers; i++ {
+		pageWG.Add(1)
+		go func() {
+			defer pageWG.Done()
+			for chapter := range chapterQueue {
+				// Read-only, so a dry run skips chapters with pages too
+				hasPages, err := c.chapterHasPages(ctx, chapter.id)
+				if err == nil && hasPages {
+					progress.skip(StagePages)
+					progress.itemDone(chapter.page)
+					continue
+				}
+				if c.config.DryRun {
+					n := atomic.AddInt64(&dryRunDetails, 1)
+					if n == maxDryRunChapterDetails+1 {
+						log.Printf("DRY RUN: Fetched %d chapter details, skipping the rest", maxDryRunChapterDetails)
+					}
+					if n > maxDryRunChapterDetails {
+						progress.skip(StagePages)
+						progress.itemDone(chapter.page)
+						continue
+					}
+				}
+
+				err = c.crawlPagesForChapter(ctx, chapter.id)
+				if ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					c.recordFailure(ctx, EntityChapter, chapter.id, err)
+				}
+				progress.record(StagePages, chapter.id, 0, err)
+				progress.itemDone(chapter.page)
+			}
+		}()
+	}
+
+	// Periodic checkpoints until the last stage drains
+	done := make(chan struct{})
+	go func() {
+		pageWG.Wait()
+		close(done)
+	}()
+
+	saveCheckpoint := func() {
+		checkpoint := progress.snapshot(len(mangaQueue), len(chapterQueue))
+		c.saveProgress(ctx, &checkpoint)
+	}
+
+	ticker := time.NewTicker(pipelineCheckpointInterval)
+	defer ticker.Stop()
+
+	for running := true; running; {
+		select {
+		case <-ticker.C:
+			saveCheckpoint()
+		case <-done:
+			running = false
+		}
+	}
+	saveCheckpoint()
+
+	final := progress.snapshot(0, 0)
+	for _, name := range []string{StageManga, StageChapters, StagePages} {
+		stage := final.Stages[name]
+		jobEvent(ctx, EventInfo, fmt.Sprintf("Pipeline %s stage: %d processed, %d success, %d failed, %d skipped",
+			name, stage.Processed, stage.Success, stage.Errors, stage.Skipped), nil)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.finishCheckpoint(ctx, &final)
+	jobEvent(ctx, EventInfo, "✅ Pipeline crawl completed", nil)
+	return nil
+}
+
+// pipelineProgress tracks per-stage counters and the manga page low-water
+// mark. pagePending counts the manga and chapters of each list page still in
+// the pipeline.
+type pipelineProgress struct {
+	mu          sync.Mutex
+	checkpoint  CrawlCheckpoint
+	pagePending map[int]int
+	pageDone    map[int]bool
+	nextPage    int
+}
+
+// newPipelineProgress tracks a crawl from startPage, keeping the counters of
+// checkpoint (a fresh one, or the one being resumed)
+func newPipelineProgress(checkpoint *CrawlCheckpoint, startPage, endPage int, cfg PipelineConfig) *pipelineProgress {
+	workers := map[string]int{
+		StageManga:    cfg.MangaWorkers,
+		StageChapters: cfg.ChapterWorkers,
+		StagePages:    cfg.PageWorkers,
+	}
+	stages := make(map[string]*StageCheckpoint, len(workers))
+	for name, n := range workers {
+		stage := StageCheckpoint{}
+		if resumed := checkpoint.Stages[name]; resumed != nil {
+			stage = *resumed
+		}
+		stage.Workers = n
+		stage.Queued = 0
+		stages[name] = &stage
+	}
+
+	checkpoint.CurrentPage = startPage - 1
+	checkpoint.EndPage = endPage
+	checkpoint.Stages = stages
+	return &pipelineProgress{
+		checkpoint:  *checkpoint,
+		pagePending: make(map[int]int),
+		pageDone:    make(map[int]bool),
+		nextPage:    startPage,
+	}
+}
+
+// record counts one processed item for a stage
+func (p *pipelineProgress) record(stage, lastID string, page int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := p.checkpoint.Stages[stage]
+	s.Processed++
+	p.checkpoint.TotalProcessed++
+	if err != nil {
+		s.Errors++
+		p.checkpoint.ErrorCount++
+		return
+	}
+	s.Success++
+	p.checkpoint.SuccessCount++
+	if lastID != "" {
+		s.LastID = lastID
+	}
+	if page > s.CurrentPage {
+		s.CurrentPage = page
+	}
+	switch stage {
+	case StageChapters:
+		p.checkpoint.LastMangaID = lastID
+	case StagePages:
+		p.checkpoint.LastChapterID = lastID
+	}
+}
+
+// skip counts an item a stage did not need to process
+func (p *pipelineProgress) skip(stage string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.checkpoint.Stages[stage].Skipped++
+}
+
+// mangaPageFetched registers how many manga a list page queued for the chapter stage
+func (p *pipelineProgress) mangaPageFetched(page, count int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if count == 0 {
+		p.pageDone[page] = true
+		p.advance()
+		return
+	}
+	p.pagePending[page] = count
+	p.checkpoint.EstimatedTotal += count
+}
+
+// chapterQueued holds page open until a chapter of one of its manga has
+// left the page stage
+func (p *pipelineProgress) chapterQueued(page int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pagePending[page]++
+}
+
+// itemDone marks one manga from page as finished with the chapter stage, or
+// one of their chapters as finished with the page stage. The page is done
+// once all of them are.
+func (p *pipelineProgress) itemDone(page int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pagePending[page]--
+	if p.pagePending[page] <= 0 {
+		delete(p.pagePending, page)
+		p.pageDone[page] = true
+		p.advance()
+	}
+}
+
+// advance moves CurrentPage over every contiguous finished page. Caller holds mu.
+func (p *pipelineProgress) advance() {
+	for p.pageDone[p.nextPage] {
+		delete(p.pageDone, p.nextPage)
+		p.checkpoint.CurrentPage = p.nextPage
+		p.nextPage++
+	}
+}
+
+// snapshot returns a copy of the checkpoint with current queue depths
+func (p *pipelineProgress) snapshot(chapterQueued, pageQueued int) CrawlCheckpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	checkpoint := p.checkpoint
+	checkpoint.Stages = make(map[string]*StageCheckpoint, len(p.checkpoint.Stages))
+	for name, stage := range p.checkpoint.Stages {
+		copied := *stage
+		checkpoint.Stages[name] = &copied
+	}
+	checkpoint.Stages[StageChapters].Queued = chapterQueued
+	checkpoint.Stages[StagePages].Queued = pageQueued
+	return checkpoint
+}
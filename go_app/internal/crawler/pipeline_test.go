@@ -0,0 +1,90 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+
+	"baca-komik-api/internal/crawler/crawltest"
+	"baca-komik-api/internal/crawler/fakeapi"
+)
+
+func TestPipelineProgressWaitsForPageStage(t *testing.T) {
+	p := newPipelineProgress(&CrawlCheckpoint{}, 1, -1, DefaultPipelineConfig())
+	currentPage := func() int { return p.snapshot(0, 0).CurrentPage }
+
+	// Page 1 lists one manga with two chapters, page 2 lists nothing
+	p.mangaPageFetched(1, 1)
+	p.chapterQueued(1)
+	p.chapterQueued(1)
+	p.itemDone(1) // manga through the chapter stage
+	p.mangaPageFetched(2, 0)
+	if got := currentPage(); got != 0 {
+		t.Fatalf("current page with chapters queued = %d, want 0", got)
+	}
+
+	p.itemDone(1)
+	if got := currentPage(); got != 0 {
+		t.Fatalf("current page with a chapter queued = %d, want 0", got)
+	}
+
+	p.itemDone(1)
+	if got := currentPage(); got != 2 {
+		t.Fatalf("current page once every chapter left the page stage = %d, want 2", got)
+	}
+}
+
+func TestPipelineProgressContinuesResumedCheckpoint(t *testing.T) {
+	resume := &CrawlCheckpoint{
+		JobID:          "crawl_pipeline_1",
+		Phase:          "pipeline",
+		CurrentPage:    4,
+		EndPage:        10,
+		TotalProcessed: 7,
+		SuccessCount:   6,
+		ErrorCount:     1,
+		Stages: map[string]*StageCheckpoint{
+			StagePages: {Workers: 2, Queued: 30, Processed: 7, Success: 6, Errors: 1, Skipped: 3},
+		},
+	}
+	p := newPipelineProgress(resume, resume.CurrentPage+1, resume.EndPage, DefaultPipelineConfig())
+	p.record(StagePages, "chapter-8", 0, nil)
+
+	got := p.snapshot(0, 0)
+	if got.JobID != resume.JobID || got.CurrentPage != 4 || got.EndPage != 10 {
+		t.Errorf("job/current page/end page = %s/%d/%d, want %s/4/10", got.JobID, got.CurrentPage, got.EndPage, resume.JobID)
+	}
+	if got.TotalProcessed != 8 || got.SuccessCount != 7 || got.ErrorCount != 1 {
+		t.Errorf("processed/success/errors = %d/%d/%d, want 8/7/1", got.TotalProcessed, got.SuccessCount, got.ErrorCount)
+	}
+	pages := got.Stages[StagePages]
+	if pages.Processed != 8 || pages.Success != 7 || pages.Skipped != 3 || pages.Workers != DefaultPipelineConfig().PageWorkers {
+		t.Errorf("pages stage = %+v, want 8 processed, 7 success, 3 skipped with %d workers", *pages, DefaultPipelineConfig().PageWorkers)
+	}
+	if got.Stages[StageManga] == nil || got.Stages[StageChapters] == nil {
+		t.Error("stages missing from the resumed checkpoint were not added")
+	}
+}
+
+func TestDryRunPipelineCapsChapterDetails(t *testing.T) {
+	db := crawltest.DB(t)
+	fixtures := fakeapi.DefaultFixtures()
+	mangaIDs := crawltest.MangaIDs(t, fixtures)
+	crawltest.DeleteManga(t, db, mangaIDs)
+
+	srv := fakeapi.New(fixtures)
+	defer srv.Close()
+
+	c := newFakeCrawler(srv, db)
+	c.config.DryRun = true
+	if err := c.CrawlPipeline(context.Background(), 1, -1); err != nil {
+		t.Fatalf("CrawlPipeline: %v", err)
+	}
+
+	// The fixtures list 33 chapters, none stored
+	if got := srv.Hits("/chapter/detail/"); got != maxDryRunChapterDetails {
+		t.Errorf("chapter detail requests = %d, want %d", got, maxDryRunChapterDetails)
+	}
+	if crawltest.MangaExists(t, db, mangaIDs[0]) {
+		t.Error("dry run saved manga")
+	}
+}
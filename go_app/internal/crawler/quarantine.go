@@ -0,0 +1,148 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// QuarantinedRecord is an upstream record that failed validation and was
+// kept in "mCrawlQuarantine" instead of being persisted
+type QuarantinedRecord struct {
+	ID          int64           `json:"id"`
+	Entity      string          `json:"entity"`
+	ExternalID  string          `json:"external_id"`
+	JobID       string          `json:"job_id,omitempty"`
+	Reason      string          `json:"reason"`
+	Payload     json.RawMessage `json:"payload"`
+	Occurrences int             `json:"occurrences"`
+	FirstSeenAt time.Time       `json:"first_seen_at"`
+	LastSeenAt  time.Time       `json:"last_seen_at"`
+}
+
+// invalidRecord is a record to quarantine
+type invalidRecord struct {
+	externalID string
+	reason     string
+	payload    json.RawMessage
+}
+
+// recordPayload returns the raw payload of a record, or the record encoded
+// again when it has none
+func recordPayload(raw json.RawMessage, record interface{}) json.RawMessage {
+	if len(raw) > 0 {
+		return raw
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// validMangaList returns the manga that pass validation and quarantines the rest
+func (c *Crawler) validMangaList(ctx context.Context, mangaList []ExternalManga) []ExternalManga {
+	valid := make([]ExternalManga, 0, len(mangaList))
+	var invalid []invalidRecord
+	for _, manga := range mangaList {
+		if reason := validateManga(manga); reason != "" {
+			invalid = append(invalid, invalidRecord{manga.ID, reason, recordPayload(manga.Raw, manga)})
+			continue
+		}
+		valid = append(valid, manga)
+	}
+	c.quarantine(ctx, PayloadManga, invalid)
+	return valid
+}
+
+// validChapters returns the chapters of mangaID that pass validation and
+// quarantines the rest
+func (c *Crawler) validChapters(ctx context.Context, chapters []ExternalChapter, mangaID string) []ExternalChapter {
+	valid := make([]ExternalChapter, 0, len(chapters))
+	var invalid []invalidRecord
+	for _, chapter := range chapters {
+		if reason := validateChapter(chapter, mangaID); reason != "" {
+			invalid = append(invalid, invalidRecord{chapter.ID, reason, recordPayload(chapter.Raw, chapter)})
+			continue
+		}
+		valid = append(valid, chapter)
+	}
+	c.quarantine(ctx, PayloadChapter, invalid)
+	return valid
+}
+
+// quarantine stores invalid records of kind and adds them to the job's
+// quarantined counter. Failures are logged rather than failing the crawl.
+func (c *Crawler) quarantine(ctx context.Context, kind string, records []invalidRecord) {
+	if len(records) == 0 {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	jobID := JobIDFromContext(ctx)
+
+	batch := &pgx.Batch{}
+	for _, r := range records {
+		jobEvent(ctx, EventWarning, fmt.Sprintf("🚫 Quarantined %s %s: %s", kind, r.externalID, r.reason),
+			map[string]interface{}{"entity": kind, "external_id": r.externalID, "reason": r.reason})
+		batch.Queue(`
+			INSERT INTO "mCrawlQuarantine" (entity_type, external_id, job_id, reason, payload)
+			VALUES ($1, $2, NULLIF($3, ''), $4, $5)
+			ON CONFLICT (entity_type, external_id) DO UPDATE SET
+				job_id = EXCLUDED.job_id,
+				reason = EXCLUDED.reason,
+				payload = EXCLUDED.payload,
+				occurrences = "mCrawlQuarantine".occurrences + 1,
+				last_seen_at = NOW()
+		`, kind, r.externalID, jobID, r.reason, []byte(r.payload))
+	}
+	if err := c.db.Pool.SendBatch(ctx, batch).Close(); err != nil {
+		log.Printf("Warning: failed to quarantine %d %s record(s): %v", len(records), kind, err)
+	}
+
+	if jobID != "" {
+		if err := c.jobs.AddQuarantined(ctx, jobID, len(records)); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// ListQuarantine returns quarantined records (of entity, if set), most
+// recently seen first, and how many there are in total
+func (c *Crawler) ListQuarantine(ctx context.Context, entity string, limit, offset int) ([]QuarantinedRecord, int, error) {
+	var total int
+	if err := c.db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM "mCrawlQuarantine" WHERE $1 = '' OR entity_type = $1
+	`, entity).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count quarantined records: %w", err)
+	}
+
+	rows, err := c.db.Pool.Query(ctx, `
+		SELECT id, entity_type, external_id, COALESCE(job_id, ''), reason, payload,
+			occurrences, first_seen_at, last_seen_at
+		FROM "mCrawlQuarantine"
+		WHERE $1 = '' OR entity_type = $1
+		ORDER BY last_seen_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, entity, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list quarantined records: %w", err)
+	}
+	defer rows.Close()
+
+	records := []QuarantinedRecord{}
+	for rows.Next() {
+		var r QuarantinedRecord
+		var payload []byte
+		if err := rows.Scan(&r.ID, &r.Entity, &r.ExternalID, &r.JobID, &r.Reason, &payload,
+			&r.Occurrences, &r.FirstSeenAt, &r.LastSeenAt); err != nil {
+			return nil, 0, err
+		}
+		r.Payload = payload
+		records = append(records, r)
+	}
+	return records, total, rows.Err()
+}
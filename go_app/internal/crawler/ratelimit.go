@@ -0,0 +1,189 @@
+package crawler
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultRequestsPerSecond is the per-host request rate used when none is configured
+const DefaultRequestsPerSecond = 4.0
+
+const (
+	// minRateDivisor bounds how far throttling can slow a host (configured rate / 16)
+	minRateDivisor = 16
+	// recoverySteps is how many successful responses it takes to climb from
+	// zero back to the configured rate after a slow-down
+	recoverySteps = 20
+)
+
+// RateLimiter is an adaptive token bucket per upstream host.
+//
+// Every request takes a token; tokens refill at the host's current rate.
+// A 429 or 503 response halves that host's rate and empties its bucket, and
+// each successful response raises it again by a small step up to the
+// configured rate (additive increase, multiplicative decrease).
+type RateLimiter struct {
+	mu    sync.Mutex
+	rate  float64 // configured requests per second
+	burst float64
+	hosts map[string]*hostBucket
+}
+
+type hostBucket struct {
+	rate          float64 // current requests per second
+	tokens        float64 // may go negative while requests wait for a reservation
+	last          time.Time
+	requests      int64
+	throttled     int64
+	lastThrottled time.Time
+}
+
+// HostLimitState is a snapshot of one host's bucket, for status endpoints
+type HostLimitState struct {
+	Host            string     `json:"host"`
+	ConfiguredRPS   float64    `json:"configured_rps"`
+	CurrentRPS      float64    `json:"current_rps"`
+	Tokens          float64    `json:"tokens"`
+	Requests        int64      `json:"requests"`
+	Throttled       int64      `json:"throttled_responses"`
+	LastThrottledAt *time.Time `json:"last_throttled_at,omitempty"`
+}
+
+// NewRateLimiter creates a limiter allowing rps requests per second per host
+// (DefaultRequestsPerSecond if rps <= 0)
+func NewRateLimiter(rps float64) *RateLimiter {
+	if rps <= 0 {
+		rps = DefaultRequestsPerSecond
+	}
+	return &RateLimiter{
+		rate:  rps,
+		burst: math.Max(1, math.Ceil(rps)),
+		hosts: make(map[string]*hostBucket),
+	}
+}
+
+// bucket returns the host's bucket with tokens refilled up to now. Caller holds mu.
+func (l *RateLimiter) bucket(host string, now time.Time) *hostBucket {
+	b, ok := l.hosts[host]
+	if !ok {
+		b = &hostBucket{rate: l.rate, tokens: l.burst, last: now}
+		l.hosts[host] = b
+		return b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	return b
+}
+
+// Wait blocks until a request to host is allowed or ctx is cancelled
+func (l *RateLimiter) Wait(ctx context.Context, host string) error {
+	l.mu.Lock()
+	b := l.bucket(host, time.Now())
+	b.tokens--
+	b.requests++
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give the reservation back
+		l.mu.Lock()
+		b.tokens++
+		b.requests--
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Observe adapts the host's rate to a response status
+func (l *RateLimiter) Observe(host string, status int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b := l.bucket(host, now)
+
+	switch {
+	case status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable:
+		b.rate = math.Max(b.rate/2, l.rate/minRateDivisor)
+		b.tokens = math.Min(b.tokens, 0)
+		b.throttled++
+		b.lastThrottled = now
+	case status < 400 && b.rate < l.rate:
+		b.rate = math.Min(l.rate, b.rate+l.rate/recoverySteps)
+	}
+}
+
+// State returns a snapshot of every host seen so far, sorted by host
+func (l *RateLimiter) State() []HostLimitState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	states := make([]HostLimitState, 0, len(l.hosts))
+	for host := range l.hosts {
+		b := l.bucket(host, now)
+		state := HostLimitState{
+			Host:          host,
+			ConfiguredRPS: l.rate,
+			CurrentRPS:    b.rate,
+			Tokens:        math.Round(b.tokens*100) / 100,
+			Requests:      b.requests,
+			Throttled:     b.throttled,
+		}
+		if !b.lastThrottled.IsZero() {
+			lastThrottled := b.lastThrottled
+			state.LastThrottledAt = &lastThrottled
+		}
+		states = append(states, state)
+	}
+
+	sort.Slice(states, func(i, j int) bool { return states[i].Host < states[j].Host })
+	return states
+}
+
+// RateLimitedTransport waits on a RateLimiter before each request and feeds
+// response statuses back into it. A nil Limiter passes requests straight through.
+type RateLimitedTransport struct {
+	Base    http.RoundTripper
+	Limiter *RateLimiter
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *RateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if t.Limiter == nil {
+		return base.RoundTrip(req)
+	}
+
+	if err := t.Limiter.Wait(req.Context(), req.URL.Host); err != nil {
+		return nil, err
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.Limiter.Observe(req.URL.Host, resp.StatusCode)
+	return resp, nil
+}
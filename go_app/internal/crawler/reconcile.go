@@ -0,0 +1,354 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// A catalog reconciliation that would mark more than reconcileMaxRemovedShare
+// of the stored manga removed (and more than reconcileMinGuarded of them)
+// is refused: that looks like a truncated listing rather than takedowns
+const (
+	reconcileMaxRemovedShare = 0.2
+	reconcileMinGuarded      = 10
+)
+
+// Reconciliation is the outcome of comparing stored manga or chapters with
+// an upstream listing
+type Reconciliation struct {
+	Entity  string   `json:"entity"`  // komik or chapter
+	Listed  int      `json:"listed"`  // IDs listed upstream
+	Removed []string `json:"removed"` // external IDs marked removed (in a dry run, that would be)
+}
+
+// RemovedEntity is a comic or chapter marked removed upstream
+type RemovedEntity struct {
+	Entity        string    `json:"entity"`
+	ID            string    `json:"id"`
+	ExternalID    string    `json:"external_id"`
+	KomikID       string    `json:"komik_id"`
+	Title         string    `json:"title"`
+	ChapterNumber *float64  `json:"chapter_number,omitempty"`
+	RemovedAt     time.Time `json:"removed_at"`
+}
+
+// Reconcile marks stored manga and chapters that the upstream no longer
+// lists as removed: the manga catalog first, then the chapter list of every
+// manga still listed. mangaID limits it to the chapters of one manga ("" or
+// "all" = everything). Removed rows are hidden from the public API and come
+// back when a later crawl sees them again.
+func (c *Crawler) Reconcile(ctx context.Context, mangaID string) error {
+	if mangaID != "" && mangaID != "all" {
+		_, err := c.ReconcileChapters(ctx, mangaID)
+		return err
+	}
+	return c.reconcile(ctx, nil)
+}
+
+// reconcile is Reconcile for everything, continuing after resume.LastMangaID
+// if resume is not nil. A resumed run that got past the catalog pass does not
+// list the catalog again.
+func (c *Crawler) reconcile(ctx context.Context, resume *CrawlCheckpoint) error {
+	checkpoint := c.startCheckpoint(ctx, "reconcile", resume)
+	if checkpoint.LastMangaID == "" {
+		if _, err := c.ReconcileManga(ctx); err != nil {
+			return err
+		}
+	}
+
+	mangaIDs, err := c.getAllMangaIDs(ctx, checkpoint.LastMangaID)
+	if err != nil {
+		return fmt.Errorf("failed to get manga IDs: %w", err)
+	}
+	jobEvent(ctx, EventInfo, fmt.Sprintf("Reconciling chapters of %d manga", len(mangaIDs)), nil)
+	checkpoint.EstimatedTotal = checkpoint.TotalProcessed + len(mangaIDs)
+
+	removed := 0
+	for _, mangaID := range mangaIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if result, err := c.ReconcileChapters(ctx, mangaID); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Warning: %v", err)
+			checkpoint.ErrorCount++
+		} else {
+			removed += len(result.Removed)
+			checkpoint.SuccessCount++
+		}
+		checkpoint.TotalProcessed++
+		checkpoint.LastMangaID = mangaID
+		c.saveProgress(ctx, checkpoint)
+	}
+
+	c.finishCheckpoint(ctx, checkpoint)
+	jobEvent(ctx, EventInfo, fmt.Sprintf("Reconcile completed: %d manga checked, %d chapter(s) removed, %d failed",
+		checkpoint.TotalProcessed, removed, checkpoint.ErrorCount), nil)
+	return nil
+}
+
+// ReconcileManga lists the whole upstream manga catalog and marks stored
+// comics whose upstream IDs (their own, or one merged into them) are no
+// longer in it as removed. It is refused if the catalog comes back empty or
+// would remove too many of the stored comics (see reconcileMaxRemovedShare).
+func (c *Crawler) ReconcileManga(ctx context.Context) (*Reconciliation, error) {
+	listed, err := c.listedMangaIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(listed) == 0 {
+		return nil, fmt.Errorf("upstream listed no manga, not reconciling")
+	}
+
+	tx, err := c.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	var stored int
+	if err := tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM "mKomik" WHERE external_id IS NOT NULL AND removed_at IS NULL
+	`).Scan(&stored); err != nil {
+		return nil, fmt.Errorf("failed to count stored manga: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		WITH updated AS (
+			UPDATE "mKomik" k SET removed_at = NOW()
+			WHERE k.external_id IS NOT NULL AND k.removed_at IS NULL
+			AND NOT (k.external_id = ANY($1))
+			AND NOT EXISTS (
+				SELECT 1 FROM "mKomikDuplicate" d
+				WHERE d.candidate_id = k.id AND d.status = 'merged' AND d.external_id = ANY($1)
+			)
+			RETURNING k.id, k.external_id, k.removed_at
+		),
+		changes AS (
+			INSERT INTO "mChangeLog" (entity_type, entity_id, komik_id, field, old_value, new_value, job_id)
+			SELECT '`+ChangeKomik+`', id, id, 'removed_at', NULL, removed_at::text, NULLIF($2, '')
+			FROM updated
+		)
+		SELECT external_id FROM updated ORDER BY external_id
+	`, listed, JobIDFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark removed manga: %w", err)
+	}
+	result := &Reconciliation{Entity: ChangeKomik, Listed: len(listed), Removed: []string{}}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to mark removed manga: %w", err)
+		}
+		result.Removed = append(result.Removed, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to mark removed manga: %w", err)
+	}
+
+	if len(result.Removed) > reconcileMinGuarded && float64(len(result.Removed)) > reconcileMaxRemovedShare*float64(stored) {
+		return nil, fmt.Errorf("upstream listed %d manga, %d of %d stored would be removed; refusing, the listing looks truncated",
+			len(listed), len(result.Removed), stored)
+	}
+
+	if c.config.DryRun {
+		log.Printf("DRY RUN: Would mark %d of %d stored manga removed: %v", len(result.Removed), stored, result.Removed)
+		return result, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, id := range result.Removed {
+		jobEvent(ctx, EventWarning, fmt.Sprintf("🗑️ Manga %s is no longer listed upstream, marked removed", id),
+			map[string]interface{}{"entity": ChangeKomik, "external_id": id})
+	}
+	jobEvent(ctx, EventInfo, fmt.Sprintf("Manga catalog reconciled: %d listed upstream, %d marked removed",
+		len(listed), len(result.Removed)), nil)
+	return result, nil
+}
+
+// ReconcileChapters lists every chapter of a manga upstream (and of the
+// manga merged into the same comic) and marks stored chapters of the comic
+// that are no longer listed as removed. An empty listing is not trusted: a
+// series taken down entirely is left to ReconcileManga.
+func (c *Crawler) ReconcileChapters(ctx context.Context, mangaID string) (*Reconciliation, error) {
+	var komikID string
+	var sourceIDs []string
+	err := c.db.Pool.QueryRow(ctx, `
+		SELECT k.id, ARRAY(
+			SELECT k.external_id WHERE k.external_id IS NOT NULL
+			UNION
+			SELECT d.external_id FROM "mKomikDuplicate" d WHERE d.candidate_id = k.id AND d.status = 'merged'
+		)
+		FROM "mKomik" k
+		WHERE k.external_id = $1
+		OR k.id = (SELECT candidate_id FROM "mKomikDuplicate" WHERE external_id = $1 AND status = 'merged' LIMIT 1)
+		LIMIT 1
+	`, mangaID).Scan(&komikID, &sourceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get internal manga ID for %s: %w", mangaID, err)
+	}
+
+	var listed []string
+	for _, sourceID := range sourceIDs {
+		ids, err := c.listedChapterIDs(ctx, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		listed = append(listed, ids...)
+	}
+	if len(listed) == 0 {
+		return nil, fmt.Errorf("upstream listed no chapters for manga %s, not reconciling", mangaID)
+	}
+
+	tx, err := c.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	rows, err := tx.Query(ctx, `
+		WITH updated AS (
+			UPDATE "mChapter" c SET removed_at = NOW()
+			WHERE c.id_komik = $1 AND c.external_id IS NOT NULL AND c.removed_at IS NULL
+			AND NOT (c.external_id = ANY($2))
+			RETURNING c.id, c.id_komik, c.external_id, c.removed_at
+		),
+		changes AS (
+			INSERT INTO "mChangeLog" (entity_type, entity_id, komik_id, field, old_value, new_value, job_id)
+			SELECT '`+ChangeChapter+`', id, id_komik, 'removed_at', NULL, removed_at::text, NULLIF($3, '')
+			FROM updated
+		)
+		SELECT external_id FROM updated ORDER BY external_id
+	`, komikID, listed, JobIDFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark removed chapters of manga %s: %w", mangaID, err)
+	}
+	result := &Reconciliation{Entity: ChangeChapter, Listed: len(listed), Removed: []string{}}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to mark removed chapters of manga %s: %w", mangaID, err)
+		}
+		result.Removed = append(result.Removed, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to mark removed chapters of manga %s: %w", mangaID, err)
+	}
+
+	if c.config.DryRun {
+		if len(result.Removed) > 0 {
+			log.Printf("DRY RUN: Would mark %d chapter(s) of manga %s removed: %v", len(result.Removed), mangaID, result.Removed)
+		}
+		return result, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if len(result.Removed) > 0 {
+		jobEvent(ctx, EventWarning, fmt.Sprintf("🗑️ %d chapter(s) of manga %s are no longer listed upstream, marked removed",
+			len(result.Removed), mangaID),
+			map[string]interface{}{"entity": ChangeChapter, "manga_id": mangaID, "external_ids": result.Removed})
+	}
+	return result, nil
+}
+
+// listedMangaIDs returns the IDs of every manga in the upstream listing. Any
+// failed page fails the whole listing, as a partial one cannot be reconciled.
+func (c *Crawler) listedMangaIDs(ctx context.Context) ([]string, error) {
+	var ids []string
+	for page := 1; ; page++ {
+		result, err := c.source.ListManga(ctx, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list manga page %d: %w", page, err)
+		}
+		if len(result.Manga) == 0 {
+			break
+		}
+		for _, manga := range result.Manga {
+			ids = append(ids, manga.ID)
+		}
+		if result.TotalPage > 0 && page >= result.TotalPage {
+			break
+		}
+	}
+	return ids, nil
+}
+
+// listedChapterIDs returns the IDs of every chapter of a manga upstream,
+// including ones that fail validation
+func (c *Crawler) listedChapterIDs(ctx context.Context, mangaID string) ([]string, error) {
+	var ids []string
+	for page := 1; ; page++ {
+		list, err := c.source.ListChapters(ctx, mangaID, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list chapters of manga %s page %d: %w", mangaID, page, err)
+		}
+		if len(list.Chapters) == 0 {
+			break
+		}
+		for _, chapter := range list.Chapters {
+			ids = append(ids, chapter.ID)
+		}
+		if list.TotalPage > 0 && page >= list.TotalPage {
+			break
+		}
+	}
+	return ids, nil
+}
+
+// ListRemoved returns comics and chapters marked removed upstream (of entity,
+// if set), most recently removed first, and how many there are in total
+func (c *Crawler) ListRemoved(ctx context.Context, entity string, limit, offset int) ([]RemovedEntity, int, error) {
+	const removed = `
+		SELECT '` + ChangeKomik + `' AS entity, k.id, COALESCE(k.external_id, '') AS external_id, k.id AS komik_id,
+			k.title, NULL::float8 AS chapter_number, k.removed_at
+		FROM "mKomik" k WHERE k.removed_at IS NOT NULL
+		UNION ALL
+		SELECT '` + ChangeChapter + `', c.id, COALESCE(c.external_id, ''), c.id_komik,
+			k.title, c.chapter_number, c.removed_at
+		FROM "mChapter" c JOIN "mKomik" k ON k.id = c.id_komik
+		WHERE c.removed_at IS NOT NULL
+	`
+
+	var total int
+	if err := c.db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM (`+removed+`) r WHERE $1 = '' OR r.entity = $1
+	`, entity).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count removed entities: %w", err)
+	}
+
+	rows, err := c.db.Pool.Query(ctx, `
+		SELECT r.entity, r.id, r.external_id, r.komik_id, r.title, r.chapter_number, r.removed_at
+		FROM (`+removed+`) r
+		WHERE $1 = '' OR r.entity = $1
+		ORDER BY r.removed_at DESC, r.id
+		LIMIT $2 OFFSET $3
+	`, entity, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list removed entities: %w", err)
+	}
+	defer rows.Close()
+
+	entities := []RemovedEntity{}
+	for rows.Next() {
+		var e RemovedEntity
+		if err := rows.Scan(&e.Entity, &e.ID, &e.ExternalID, &e.KomikID, &e.Title,
+			&e.ChapterNumber, &e.RemovedAt); err != nil {
+			return nil, 0, err
+		}
+		entities = append(entities, e)
+	}
+	return entities, total, rows.Err()
+}
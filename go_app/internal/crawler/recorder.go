@@ -0,0 +1,113 @@
+package crawler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RecordedResponse is one upstream response stored in a record/replay archive
+type RecordedResponse struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Status     int         `json:"status"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+	RecordedAt time.Time   `json:"recorded_at"`
+}
+
+// archiveKey returns the archive file name for a request.
+// Only method and path+query are used so an archive recorded against one
+// host can be replayed with a different base URL.
+func archiveKey(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Method + " " + req.URL.RequestURI()))
+	return hex.EncodeToString(sum[:]) + ".json"
+}
+
+// RecordingTransport passes requests through and writes every raw response
+// (status, headers, body) to a directory archive
+type RecordingTransport struct {
+	Base http.RoundTripper
+	Dir  string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	record := RecordedResponse{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		Status:     resp.StatusCode,
+		Header:     resp.Header,
+		Body:       string(body),
+		RecordedAt: time.Now(),
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal recorded response: %w", err)
+	}
+	if err := os.MkdirAll(t.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create record directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(t.Dir, archiveKey(req)), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write recorded response: %w", err)
+	}
+
+	return resp, nil
+}
+
+// ReplayTransport serves responses from a directory archive instead of the network
+type ReplayTransport struct {
+	Dir string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	data, err := os.ReadFile(filepath.Join(t.Dir, archiveKey(req)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL.RequestURI())
+		}
+		return nil, fmt.Errorf("failed to read recorded response: %w", err)
+	}
+
+	var record RecordedResponse
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal recorded response: %w", err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", record.Status, http.StatusText(record.Status)),
+		StatusCode:    record.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        record.Header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(record.Body))),
+		ContentLength: int64(len(record.Body)),
+		Request:       req,
+	}, nil
+}
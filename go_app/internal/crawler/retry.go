@@ -0,0 +1,242 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxRetryAfter caps how long a single Retry-After header can stall a request
+const maxRetryAfter = 2 * time.Minute
+
+// RetryPolicy controls how failed upstream requests are retried
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts including the first
+	BaseDelay   time.Duration // backoff before the second attempt
+	MaxDelay    time.Duration // backoff ceiling
+}
+
+// DefaultRetryPolicy returns the retry policy used when none is configured
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+	}
+}
+
+// withDefaults fills zero fields from DefaultRetryPolicy
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	def := DefaultRetryPolicy()
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = def.MaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = def.BaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = def.MaxDelay
+	}
+	return p
+}
+
+// backoff returns the jittered delay after the given failed attempt:
+// exponential in the attempt number, randomised between half and the full value
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	half := int64(d / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// UpstreamError is an upstream request that failed for good:
+// a non-retryable status, or retries exhausted
+type UpstreamError struct {
+	URL        string
+	StatusCode int // 0 when no response was received
+	Attempts   int
+	Err        error // transport error when StatusCode is 0
+}
+
+func (e *UpstreamError) Error() string {
+	var msg string
+	if e.StatusCode != 0 {
+		msg = fmt.Sprintf("API returned status %d for URL: %s", e.StatusCode, e.URL)
+	} else {
+		msg = fmt.Sprintf("request to %s failed: %v", e.URL, e.Err)
+	}
+	if e.Attempts > 1 {
+		msg += fmt.Sprintf(" (after %d attempts)", e.Attempts)
+	}
+	return msg
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+// retryableStatus reports whether a response status is worth retrying
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter reads a Retry-After header (seconds or HTTP date)
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
+
+// RetryTransport retries transport errors and retryable statuses with
+// jittered exponential backoff, honoring Retry-After. Every attempt waits on
+// and reports to the circuit breaker, so a dead upstream pauses all callers
+// sharing it instead of burning through their retries. Only use it for
+// requests without a body.
+type RetryTransport struct {
+	Base    http.RoundTripper
+	Policy  RetryPolicy
+	Breaker *CircuitBreaker // optional
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	policy := t.Policy.withDefaults()
+	ctx := req.Context()
+
+	for attempt := 1; ; attempt++ {
+		if t.Breaker != nil {
+			if err := t.Breaker.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := base.RoundTrip(req)
+		if ctx.Err() != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, ctx.Err()
+		}
+
+		var retryAfter time.Duration
+		retry := false
+		switch {
+		case err != nil:
+			retry = true
+		case retryableStatus(resp.StatusCode):
+			retry = true
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		}
+
+		if t.Breaker != nil {
+			t.Breaker.Record(err != nil || resp.StatusCode >= 500)
+		}
+
+		if !retry {
+			return resp, nil
+		}
+		if attempt >= policy.MaxAttempts {
+			upstreamErr := &UpstreamError{URL: req.URL.String(), Attempts: attempt, Err: err}
+			if resp != nil {
+				upstreamErr.StatusCode = resp.StatusCode
+				resp.Body.Close()
+			}
+			return nil, upstreamErr
+		}
+
+		delay := policy.backoff(attempt)
+		if retryAfter > delay {
+			delay = min(retryAfter, maxRetryAfter)
+		}
+
+		var message string
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			message = fmt.Sprintf("Retrying %s in %v (attempt %d/%d): status %d", req.URL, delay.Round(time.Millisecond), attempt+1, policy.MaxAttempts, resp.StatusCode)
+		} else {
+			message = fmt.Sprintf("Retrying %s in %v (attempt %d/%d): %v", req.URL, delay.Round(time.Millisecond), attempt+1, policy.MaxAttempts, err)
+		}
+		jobEvent(ctx, EventWarning, message, nil)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// newUpstreamClient builds the client for upstream requests:
+// retries and circuit breaker, then the per-host rate limit, then base.
+// The network timeout is per attempt (see networkTransport); the client has
+// none because retries and breaker pauses can legitimately take longer.
+func newUpstreamClient(base http.RoundTripper, policy RetryPolicy, limiter *RateLimiter, breaker *CircuitBreaker) *http.Client {
+	return &http.Client{
+		Transport: &RetryTransport{
+			Base:    &RateLimitedTransport{Base: base, Limiter: limiter},
+			Policy:  policy,
+			Breaker: breaker,
+		},
+	}
+}
+
+// networkTransport returns a transport that gives up on a single attempt
+// when no response headers arrive within 30 seconds
+func networkTransport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 30 * time.Second
+	return transport
+}
+
+// failureAttempts returns how many attempts an error took (1 if unknown)
+func failureAttempts(err error) int {
+	var upstreamErr *UpstreamError
+	if errors.As(err, &upstreamErr) {
+		return upstreamErr.Attempts
+	}
+	return 1
+}
+
+// failureReason classifies an error into a short, stable reason code
+func failureReason(err error) string {
+	var upstreamErr *UpstreamError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "cancelled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &upstreamErr):
+		if upstreamErr.StatusCode != 0 {
+			return fmt.Sprintf("http_%d", upstreamErr.StatusCode)
+		}
+		return "network"
+	}
+	return "error"
+}
@@ -0,0 +1,51 @@
+package crawler
+
+import "context"
+
+// Source is an upstream catalog the crawler can ingest from.
+// Implementations translate their own wire format into the External* types,
+// so the save logic in database.go does not depend on any particular API.
+// Every call must return promptly once ctx is cancelled.
+type Source interface {
+	// Name identifies the source (e.g. "shngm")
+	Name() string
+
+	// ListManga returns one page of the manga listing, most recently updated first
+	ListManga(ctx context.Context, page int) (*MangaPage, error)
+	// ListChapters returns one page of chapters for a manga, newest first
+	ListChapters(ctx context.Context, mangaID string, page int) (*ChapterPage, error)
+	// GetChapterDetail returns a chapter including its page images
+	GetChapterDetail(ctx context.Context, chapterID string) (*ExternalChapterDetail, error)
+
+	// Taxonomy lists
+	ListGenres(ctx context.Context) ([]ExternalGenre, error)
+	ListFormats(ctx context.Context, page int) ([]ExternalFormat, error)
+	ListTypes(ctx context.Context, page int) ([]ExternalType, error)
+	ListAuthors(ctx context.Context, query string, page int) (*AuthorPage, error)
+	ListArtists(ctx context.Context, query string, page int) (*ArtistPage, error)
+}
+
+// MangaPage is one page of a manga listing.
+// TotalPage is 0 when the source does not report it.
+type MangaPage struct {
+	Manga     []ExternalManga
+	TotalPage int
+}
+
+// ChapterPage is one page of a chapter listing
+type ChapterPage struct {
+	Chapters  []ExternalChapter
+	TotalPage int
+}
+
+// AuthorPage is one page of an author search
+type AuthorPage struct {
+	Authors   []ExternalAuthor
+	TotalPage int
+}
+
+// ArtistPage is one page of an artist search
+type ArtistPage struct {
+	Artists   []ExternalArtist
+	TotalPage int
+}
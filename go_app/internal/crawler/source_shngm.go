@@ -0,0 +1,263 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ShngmSource reads from the Shinigami API (https://api.shngm.io/v1)
+type ShngmSource struct {
+	baseURL string
+	headers map[string]string
+	verbose bool
+	client  *http.Client
+}
+
+// NewShngmSource creates a Source for the Shinigami API using the crawler config.
+// Requests are retried per config.Retry, wait on limiter and pause while breaker
+// is open (nil limiter/breaker disable them); replayed requests skip all three.
+func NewShngmSource(config *Config, limiter *RateLimiter, breaker *CircuitBreaker) *ShngmSource {
+	var client *http.Client
+
+	// Record/replay of raw upstream traffic
+	if config.ReplayDir != "" {
+		client = &http.Client{
+			Transport: &ReplayTransport{Dir: config.ReplayDir},
+			Timeout:   30 * time.Second,
+		}
+	} else if config.RecordDir != "" {
+		client = newUpstreamClient(&RecordingTransport{Base: networkTransport(), Dir: config.RecordDir}, config.Retry, limiter, breaker)
+	} else {
+		client = newUpstreamClient(networkTransport(), config.Retry, limiter, breaker)
+	}
+
+	return &ShngmSource{
+		baseURL: config.BaseURL,
+		headers: config.Headers,
+		verbose: config.Verbose,
+		client:  client,
+	}
+}
+
+// Name returns the source identifier
+func (s *ShngmSource) Name() string {
+	return "shngm"
+}
+
+// makeRequest makes HTTP request with proper headers
+func (s *ShngmSource) makeRequest(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Add headers
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+
+	if s.verbose {
+		log.Printf("Making request to: %s", url)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		var upstreamErr *UpstreamError
+		if errors.As(err, &upstreamErr) {
+			return nil, upstreamErr
+		}
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, &UpstreamError{URL: url, StatusCode: resp.StatusCode, Attempts: 1}
+	}
+
+	return resp, nil
+}
+
+// fetchBody fetches a response body
+func (s *ShngmSource) fetchBody(ctx context.Context, url string) ([]byte, error) {
+	resp, err := s.makeRequest(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return body, nil
+}
+
+// fetchJSON fetches and unmarshals JSON response
+func (s *ShngmSource) fetchJSON(ctx context.Context, url string, target interface{}) error {
+	body, err := s.fetchBody(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+
+	return nil
+}
+
+// fetchData fetches a generic APIResponse and decodes its data field into target
+func (s *ShngmSource) fetchData(ctx context.Context, url string, target interface{}) (*APIMeta, error) {
+	var response APIResponse
+	if err := s.fetchJSON(ctx, url, &response); err != nil {
+		return nil, err
+	}
+
+	if len(response.Data) == 0 {
+		return &response.Meta, nil
+	}
+	if err := json.Unmarshal(response.Data, target); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal data: %w", err)
+	}
+
+	return &response.Meta, nil
+}
+
+// ListManga fetches a page of the latest-updated manga list
+func (s *ShngmSource) ListManga(ctx context.Context, page int) (*MangaPage, error) {
+	var response MangaListResponse
+	if err := s.fetchJSON(ctx, s.mangaListURL(page), &response); err != nil {
+		return nil, err
+	}
+
+	return &MangaPage{
+		Manga:     response.Data,
+		TotalPage: totalPage(response.Meta),
+	}, nil
+}
+
+// ListChapters fetches a page of chapters for a manga
+func (s *ShngmSource) ListChapters(ctx context.Context, mangaID string, page int) (*ChapterPage, error) {
+	var response ChaptersResponse
+	if err := s.fetchJSON(ctx, s.chapterListURL(mangaID, page), &response); err != nil {
+		return nil, err
+	}
+
+	if s.verbose {
+		log.Printf("API Response retcode: %d, message: %s", response.RetCode, response.Message)
+	}
+
+	return &ChapterPage{
+		Chapters:  response.Data,
+		TotalPage: totalPage(response.Meta),
+	}, nil
+}
+
+// GetChapterDetail fetches chapter detail including page filenames
+func (s *ShngmSource) GetChapterDetail(ctx context.Context, chapterID string) (*ExternalChapterDetail, error) {
+	var response ChapterDetailResponse
+	if err := s.fetchJSON(ctx, s.chapterDetailURL(chapterID), &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
+// ListGenres fetches all genres (no pagination)
+func (s *ShngmSource) ListGenres(ctx context.Context) ([]ExternalGenre, error) {
+	var genres []ExternalGenre
+	if _, err := s.fetchData(ctx, fmt.Sprintf("%s/genre/list", s.baseURL), &genres); err != nil {
+		return nil, err
+	}
+	return genres, nil
+}
+
+// ListFormats fetches a page of formats
+func (s *ShngmSource) ListFormats(ctx context.Context, page int) ([]ExternalFormat, error) {
+	var formats []ExternalFormat
+	if _, err := s.fetchData(ctx, fmt.Sprintf("%s/format/list?page=%d", s.baseURL, page), &formats); err != nil {
+		return nil, err
+	}
+	return formats, nil
+}
+
+// ListTypes fetches a page of types
+func (s *ShngmSource) ListTypes(ctx context.Context, page int) ([]ExternalType, error) {
+	var types []ExternalType
+	if _, err := s.fetchData(ctx, fmt.Sprintf("%s/type/list?page=%d", s.baseURL, page), &types); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+// ListAuthors fetches a page of authors matching query
+func (s *ShngmSource) ListAuthors(ctx context.Context, query string, page int) (*AuthorPage, error) {
+	var authors []ExternalAuthor
+	meta, err := s.fetchData(ctx, fmt.Sprintf("%s/author/list?q=%s&page=%d", s.baseURL, url.QueryEscape(query), page), &authors)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthorPage{Authors: authors, TotalPage: totalPage(*meta)}, nil
+}
+
+// ListArtists fetches a page of artists matching query
+func (s *ShngmSource) ListArtists(ctx context.Context, query string, page int) (*ArtistPage, error) {
+	var artists []ExternalArtist
+	meta, err := s.fetchData(ctx, fmt.Sprintf("%s/artist/list?q=%s&page=%d", s.baseURL, url.QueryEscape(query), page), &artists)
+	if err != nil {
+		return nil, err
+	}
+	return &ArtistPage{Artists: artists, TotalPage: totalPage(*meta)}, nil
+}
+
+// Sample returns the raw first page of endpoint (one of the Endpoint*
+// constants), for schema drift checks. id is the manga ID of a chapter list
+// or the chapter ID of a chapter detail.
+func (s *ShngmSource) Sample(ctx context.Context, endpoint, id string) ([]byte, error) {
+	var url string
+	switch endpoint {
+	case EndpointMangaList:
+		url = s.mangaListURL(1)
+	case EndpointChapterList:
+		url = s.chapterListURL(id, 1)
+	case EndpointChapterDetail:
+		url = s.chapterDetailURL(id)
+	case EndpointGenreList:
+		url = fmt.Sprintf("%s/genre/list", s.baseURL)
+	case EndpointFormatList, EndpointTypeList, EndpointAuthorList, EndpointArtistList:
+		url = fmt.Sprintf("%s/%s/list?page=1", s.baseURL, strings.TrimSuffix(endpoint, "_list"))
+	default:
+		return nil, fmt.Errorf("unknown endpoint: %s", endpoint)
+	}
+	return s.fetchBody(ctx, url)
+}
+
+func (s *ShngmSource) mangaListURL(page int) string {
+	return fmt.Sprintf("%s/manga/list?type=&page=%d&page_size=24&is_update=true&sort=latest&sort_order=desc",
+		s.baseURL, page)
+}
+
+func (s *ShngmSource) chapterListURL(mangaID string, page int) string {
+	return fmt.Sprintf("%s/chapter/%s/list?page=%d&page_size=24&sort_by=chapter_number&sort_order=desc",
+		s.baseURL, mangaID, page)
+}
+
+func (s *ShngmSource) chapterDetailURL(chapterID string) string {
+	return fmt.Sprintf("%s/chapter/detail/%s", s.baseURL, chapterID)
+}
+
+// totalPage returns the page count from response meta, or 0 when absent
+func totalPage(meta APIMeta) int {
+	if meta.TotalPage == nil {
+		return 0
+	}
+	return *meta.TotalPage
+}
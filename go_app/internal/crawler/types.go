@@ -1,14 +1,17 @@
 package crawler
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // API Response structures for external API
 
 type APIResponse struct {
-	RetCode   int         `json:"retcode"`
-	Message   string      `json:"message"`
-	Meta      APIMeta     `json:"meta"`
-	Data      interface{} `json:"data"`
+	RetCode   int             `json:"retcode"`
+	Message   string          `json:"message"`
+	Meta      APIMeta         `json:"meta"`
+	Data      json.RawMessage `json:"data"`
 }
 
 type APIMeta struct {
@@ -49,6 +52,22 @@ type MangaListResponse struct {
 	Facet   interface{}     `json:"facet"`
 }
 
+// Chapter detail response
+type ChapterDetailResponse struct {
+	RetCode int                   `json:"retcode"`
+	Message string                `json:"message"`
+	Meta    APIMeta               `json:"meta"`
+	Data    ExternalChapterDetail `json:"data"`
+}
+
+// Response whose data is a plain list, e.g. the taxonomy lists
+type DataResponse[T any] struct {
+	RetCode int     `json:"retcode"`
+	Message string  `json:"message"`
+	Meta    APIMeta `json:"meta"`
+	Data    T       `json:"data"`
+}
+
 type Pagination struct {
 	Page      int `json:"page"`
 	PageSize  int `json:"page_size"`
@@ -94,11 +113,15 @@ type ExternalManga struct {
 	BookmarkCount    *int      `json:"bookmark_count"`
 	CoverImageURL    *string   `json:"cover_image_url"`
 	CreatedAt        *time.Time `json:"created_at"`
+	UpdatedAt        *time.Time `json:"updated_at"` // last upstream update; orders the is_update listing
 	Rank             *float64  `json:"rank"`
 	ReleaseYear      *string   `json:"release_year"`
 	IsRecommended    bool      `json:"is_recommended"`
 	UserRate         float64   `json:"user_rate"`
 	Taxonomy         *ExternalTaxonomy `json:"taxonomy,omitempty"`
+
+	// Raw is the manga object as received, archived for reprocessing
+	Raw json.RawMessage `json:"-"`
 }
 
 // Taxonomy structure from API
@@ -120,6 +143,9 @@ type ExternalChapter struct {
 	ThumbnailImageURL *string    `json:"thumbnail_image_url"`
 	CreatedAt         *time.Time `json:"created_at"`
 	UpdatedAt         *time.Time `json:"updated_at"`
+
+	// Raw is the chapter object as received, archived for reprocessing
+	Raw json.RawMessage `json:"-"`
 }
 
 type ExternalChapterDetail struct {
@@ -139,6 +165,9 @@ type ExternalChapterDetail struct {
 	ReleaseDate       time.Time `json:"release_date"`
 	CreatedAt         time.Time `json:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at"`
+
+	// Raw is the chapter detail object as received, archived for reprocessing
+	Raw json.RawMessage `json:"-"`
 }
 
 type ChapterPages struct {
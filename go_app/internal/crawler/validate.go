@@ -0,0 +1,92 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Validation rules per upstream type. Each returns why a record must not be
+// persisted, or "" if it is valid. Required keys are checked against the raw
+// payload (when there is one) so a missing field is told apart from a zero
+// value, e.g. chapter 0 from no chapter_number at all.
+
+// validateManga checks a manga list item
+func validateManga(manga ExternalManga) string {
+	if reason := missingKeys(manga.Raw, "manga_id", "title"); reason != "" {
+		return reason
+	}
+	if strings.TrimSpace(manga.ID) == "" {
+		return "empty manga_id"
+	}
+	if strings.TrimSpace(manga.Title) == "" {
+		return "empty title"
+	}
+	return ""
+}
+
+// validateChapter checks a chapter list item of mangaID
+func validateChapter(chapter ExternalChapter, mangaID string) string {
+	if reason := missingKeys(chapter.Raw, "chapter_id", "chapter_number"); reason != "" {
+		return reason
+	}
+	if strings.TrimSpace(chapter.ID) == "" {
+		return "empty chapter_id"
+	}
+	if chapter.ChapterNumber < 0 || math.IsNaN(chapter.ChapterNumber) || math.IsInf(chapter.ChapterNumber, 0) {
+		return fmt.Sprintf("invalid chapter_number %v", chapter.ChapterNumber)
+	}
+	if chapter.MangaID != "" && chapter.MangaID != mangaID {
+		return fmt.Sprintf("manga_id %s does not match manga %s", chapter.MangaID, mangaID)
+	}
+	return ""
+}
+
+// validateChapterDetail checks the detail of chapterID
+func validateChapterDetail(detail *ExternalChapterDetail, chapterID string) string {
+	if reason := missingKeys(detail.Raw, "chapter_id", "base_url", "chapter"); reason != "" {
+		return reason
+	}
+	if detail.ChapterID != "" && detail.ChapterID != chapterID {
+		return fmt.Sprintf("chapter_id %s does not match chapter %s", detail.ChapterID, chapterID)
+	}
+	if strings.TrimSpace(detail.BaseURL) == "" {
+		return "empty base_url"
+	}
+	if strings.TrimSpace(detail.Chapter.Path) == "" {
+		return "empty chapter.path"
+	}
+	if len(detail.Chapter.Data) == 0 {
+		return "no pages in chapter.data"
+	}
+	for i, filename := range detail.Chapter.Data {
+		if strings.TrimSpace(filename) == "" {
+			return fmt.Sprintf("empty filename for page %d", i+1)
+		}
+	}
+	return ""
+}
+
+// missingKeys returns which of keys are absent or null in a raw JSON object,
+// or "" if all are present (or there is no raw payload to check)
+func missingKeys(raw json.RawMessage, keys ...string) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return "payload is not a JSON object"
+	}
+
+	var missing []string
+	for _, key := range keys {
+		if value, ok := fields[key]; !ok || string(value) == "null" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return ""
+	}
+	return "missing " + strings.Join(missing, ", ")
+}
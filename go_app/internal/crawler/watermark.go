@@ -0,0 +1,148 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultIncrementalOverlap is how far past the high-water mark an incremental
+// crawl keeps paginating, for updates the listing shows out of order
+const DefaultIncrementalOverlap = 2 * time.Hour
+
+// Watermark entities
+const watermarkManga = "manga"
+
+// Watermark is the newest upstream update an incremental crawl has seen
+type Watermark struct {
+	Source        string    `json:"source"`
+	Entity        string    `json:"entity"`
+	HighWaterMark time.Time `json:"high_water_mark"`
+	JobID         string    `json:"job_id,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// mangaWatermark tracks an incremental manga crawl
+type mangaWatermark struct {
+	cutoff time.Time // a page entirely older than this ends the crawl
+	newest time.Time // newest upstream update seen
+}
+
+// olderPage reports whether every manga on a page was updated before the
+// cutoff. Manga without an update time count as new, so a listing without
+// them is crawled in full.
+func (w *mangaWatermark) olderPage(mangaList []ExternalManga) bool {
+	for _, manga := range mangaList {
+		if manga.UpdatedAt == nil || !manga.UpdatedAt.Before(w.cutoff) {
+			return false
+		}
+	}
+	return len(mangaList) > 0
+}
+
+// observe records the newest update time of a page
+func (w *mangaWatermark) observe(mangaList []ExternalManga) {
+	for _, manga := range mangaList {
+		if manga.UpdatedAt != nil && manga.UpdatedAt.After(w.newest) {
+			w.newest = *manga.UpdatedAt
+		}
+	}
+}
+
+// overlap returns Config.IncrementalOverlap, or DefaultIncrementalOverlap if unset
+func (c *Crawler) overlap() time.Duration {
+	if c.config.IncrementalOverlap > 0 {
+		return c.config.IncrementalOverlap
+	}
+	return DefaultIncrementalOverlap
+}
+
+// CrawlMangaIncremental crawls the manga listing newest first from startPage
+// until a full page is older than the source's high-water mark minus the
+// overlap window (or endPage, if not -1), then moves the mark to the newest
+// update seen. Without a mark it crawls the whole listing once to set it.
+// A crawl cut short by endPage leaves the mark alone, since the manga past
+// endPage were never seen.
+func (c *Crawler) CrawlMangaIncremental(ctx context.Context, startPage, endPage int) error {
+	return c.crawlMangaIncremental(ctx, startPage, endPage, nil)
+}
+
+// crawlMangaIncremental is CrawlMangaIncremental continuing the counters of
+// resume if not nil. The mark only moves when a crawl ends, so a resumed
+// crawl stops at the same cutoff.
+func (c *Crawler) crawlMangaIncremental(ctx context.Context, startPage, endPage int, resume *CrawlCheckpoint) error {
+	mark, err := c.LoadWatermark(ctx, watermarkManga)
+	if err != nil {
+		return err
+	}
+
+	watermark := &mangaWatermark{}
+	if mark == nil {
+		jobEvent(ctx, EventInfo, "No manga high-water mark yet, crawling the whole listing to set it", nil)
+	} else {
+		watermark.cutoff = mark.HighWaterMark.Add(-c.overlap())
+		watermark.newest = mark.HighWaterMark
+		jobEvent(ctx, EventInfo, fmt.Sprintf("Crawling manga updated since %s (high-water mark %s, overlap %s)",
+			watermark.cutoff.Format(time.RFC3339), mark.HighWaterMark.Format(time.RFC3339), c.overlap()), nil)
+	}
+
+	checkpoint, stop, err := c.crawlManga(ctx, startPage, endPage, resume, watermark)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case stop == stopEndPage:
+		jobEvent(ctx, EventWarning, fmt.Sprintf("⚠️ Stopped at end page %d before reaching already-seen updates, keeping the high-water mark", endPage), nil)
+	case c.config.DryRun:
+		log.Printf("DRY RUN: Would move the manga high-water mark to %s", watermark.newest.Format(time.RFC3339))
+	case checkpoint.ErrorCount > 0:
+		// Pages that failed may hold updates newer than the old mark
+		jobEvent(ctx, EventWarning, fmt.Sprintf("⚠️ %d manga failed, keeping the high-water mark", checkpoint.ErrorCount), nil)
+	case watermark.newest.IsZero():
+		jobEvent(ctx, EventWarning, "⚠️ The listing has no updated_at, no high-water mark to save", nil)
+	default:
+		if err := c.saveWatermark(ctx, watermarkManga, watermark.newest); err != nil {
+			return err
+		}
+		jobEvent(ctx, EventInfo, fmt.Sprintf("Manga high-water mark is now %s", watermark.newest.Format(time.RFC3339)), nil)
+	}
+	return nil
+}
+
+// LoadWatermark returns the high-water mark of entity for the crawler's
+// source, or nil if none was saved yet
+func (c *Crawler) LoadWatermark(ctx context.Context, entity string) (*Watermark, error) {
+	mark := Watermark{Source: c.source.Name(), Entity: entity}
+	err := c.db.Pool.QueryRow(ctx, `
+		SELECT high_water_mark, COALESCE(job_id, ''), updated_at
+		FROM "mCrawlWatermark" WHERE source = $1 AND entity_type = $2
+	`, mark.Source, entity).Scan(&mark.HighWaterMark, &mark.JobID, &mark.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s high-water mark: %w", entity, err)
+	}
+	return &mark, nil
+}
+
+// saveWatermark moves the high-water mark of entity forward to mark; it
+// never moves back
+func (c *Crawler) saveWatermark(ctx context.Context, entity string, mark time.Time) error {
+	_, err := c.db.Pool.Exec(context.WithoutCancel(ctx), `
+		INSERT INTO "mCrawlWatermark" (source, entity_type, high_water_mark, job_id, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NOW())
+		ON CONFLICT (source, entity_type) DO UPDATE SET
+			high_water_mark = GREATEST("mCrawlWatermark".high_water_mark, EXCLUDED.high_water_mark),
+			job_id = EXCLUDED.job_id,
+			updated_at = NOW()
+	`, c.source.Name(), entity, mark.UTC(), JobIDFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to save %s high-water mark: %w", entity, err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"baca-komik-api/internal/crawler/crawltest"
+	"baca-komik-api/internal/crawler/fakeapi"
+)
+
+func TestCrawlMangaIncrementalKeepsMarkAtEndPage(t *testing.T) {
+	db := crawltest.DB(t)
+	fixtures := fakeapi.DefaultFixtures()
+	mangaIDs := crawltest.MangaIDs(t, fixtures)
+
+	srv := fakeapi.New(fixtures)
+	defer srv.Close()
+	srv.SetPageSize(1)
+	ctx := context.Background()
+	c := newFakeCrawler(srv, db)
+
+	reset := func() {
+		crawltest.DeleteManga(t, db, mangaIDs)
+		if _, err := db.Pool.Exec(ctx, `DELETE FROM "mCrawlWatermark" WHERE source = $1 AND entity_type = $2`,
+			c.source.Name(), watermarkManga); err != nil {
+			t.Fatalf("failed to delete high-water mark: %v", err)
+		}
+	}
+	reset()
+	t.Cleanup(reset)
+
+	loadMark := func(t *testing.T) *Watermark {
+		mark, err := c.LoadWatermark(ctx, watermarkManga)
+		if err != nil {
+			t.Fatalf("LoadWatermark: %v", err)
+		}
+		return mark
+	}
+	newest := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC) // first fixture manga
+
+	t.Run("first run cut short sets no mark", func(t *testing.T) {
+		reset()
+		if err := c.CrawlMangaIncremental(ctx, 1, 1); err != nil {
+			t.Fatalf("CrawlMangaIncremental: %v", err)
+		}
+		if mark := loadMark(t); mark != nil {
+			t.Errorf("high-water mark = %s, want none", mark.HighWaterMark)
+		}
+	})
+
+	t.Run("run cut short keeps the mark", func(t *testing.T) {
+		reset()
+		old := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+		if err := c.saveWatermark(ctx, watermarkManga, old); err != nil {
+			t.Fatalf("saveWatermark: %v", err)
+		}
+		if err := c.CrawlMangaIncremental(ctx, 1, 2); err != nil {
+			t.Fatalf("CrawlMangaIncremental: %v", err)
+		}
+		if mark := loadMark(t); mark == nil || !mark.HighWaterMark.Equal(old) {
+			t.Errorf("high-water mark = %v, want %s", mark, old)
+		}
+	})
+
+	t.Run("run to the end of the listing moves the mark", func(t *testing.T) {
+		reset()
+		if err := c.CrawlMangaIncremental(ctx, 1, -1); err != nil {
+			t.Fatalf("CrawlMangaIncremental: %v", err)
+		}
+		if mark := loadMark(t); mark == nil || !mark.HighWaterMark.Equal(newest) {
+			t.Errorf("high-water mark = %v, want %s", mark, newest)
+		}
+	})
+}
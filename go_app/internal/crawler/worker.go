@@ -0,0 +1,236 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// jobHeartbeatInterval is how often a worker reports a running job alive
+	// and checks whether it was asked to cancel
+	jobHeartbeatInterval = 5 * time.Second
+	// jobStaleAfter is how long a running job may go without a heartbeat
+	// before another worker marks it failed
+	jobStaleAfter = 2 * time.Minute
+	// jobMaintenanceInterval is how often a worker recovers stale jobs and prunes old ones
+	jobMaintenanceInterval = time.Hour
+)
+
+// WorkerConfig controls how a worker drains the job queue
+type WorkerConfig struct {
+	Concurrency  int           // jobs run at the same time
+	PollInterval time.Duration // wait between claims when the queue is empty
+	Retention    time.Duration // finished jobs older than this are deleted
+	// PageSetRetention is how long superseded chapter page sets are kept
+	PageSetRetention time.Duration
+}
+
+// DefaultWorkerConfig returns the worker settings used when none are configured
+func DefaultWorkerConfig() WorkerConfig {
+	return WorkerConfig{
+		Concurrency:  1,
+		PollInterval: 5 * time.Second,
+		Retention:    30 * 24 * time.Hour,
+
+		PageSetRetention: 30 * 24 * time.Hour,
+	}
+}
+
+// withDefaults fills zero fields from DefaultWorkerConfig
+func (w WorkerConfig) withDefaults() WorkerConfig {
+	def := DefaultWorkerConfig()
+	if w.Concurrency <= 0 {
+		w.Concurrency = def.Concurrency
+	}
+	if w.PollInterval <= 0 {
+		w.PollInterval = def.PollInterval
+	}
+	if w.Retention <= 0 {
+		w.Retention = def.Retention
+	}
+	if w.PageSetRetention <= 0 {
+		w.PageSetRetention = def.PageSetRetention
+	}
+	return w
+}
+
+// Worker runs crawl jobs from the shared queue
+type Worker struct {
+	crawler *Crawler
+	queue   *JobQueue
+	config  WorkerConfig
+	id      string
+}
+
+// NewWorker creates a worker running jobs on crawler. Its ID names the host
+// and process so jobs show where they ran.
+func NewWorker(crawler *Crawler, config WorkerConfig) *Worker {
+	hostname, _ := os.Hostname()
+	return &Worker{
+		crawler: crawler,
+		queue:   crawler.Jobs(),
+		config:  config.withDefaults(),
+		id:      fmt.Sprintf("%s:%d", hostname, os.Getpid()),
+	}
+}
+
+// ID returns the worker's identifier
+func (w *Worker) ID() string {
+	return w.id
+}
+
+// Run claims and runs queued jobs until ctx is cancelled
+func (w *Worker) Run(ctx context.Context) {
+	log.Printf("👷 Crawl worker %s started (concurrency %d)", w.id, w.config.Concurrency)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.maintain(ctx)
+	}()
+
+	for i := 0; i < w.config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.poll(ctx)
+		}()
+	}
+	wg.Wait()
+
+	log.Printf("👷 Crawl worker %s stopped", w.id)
+}
+
+// poll claims one job at a time, waiting PollInterval while the queue is empty
+func (w *Worker) poll(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := w.queue.Claim(ctx, w.id)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Warning: %v", err)
+		}
+		if job != nil {
+			w.execute(ctx, job)
+			continue
+		}
+
+		timer := time.NewTimer(w.config.PollInterval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+}
+
+// maintain recovers jobs left running by dead workers and prunes old jobs
+// and superseded page sets
+func (w *Worker) maintain(ctx context.Context) {
+	ticker := time.NewTicker(jobMaintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := w.queue.RecoverStale(ctx, jobStaleAfter); err != nil {
+			log.Printf("Warning: %v", err)
+		} else if n > 0 {
+			log.Printf("Marked %d stale job(s) as failed", n)
+		}
+		if n, err := w.queue.Prune(ctx, w.config.Retention); err != nil {
+			log.Printf("Warning: %v", err)
+		} else if n > 0 {
+			log.Printf("Pruned %d finished job(s)", n)
+		}
+		if n, err := w.crawler.PrunePageSets(ctx, w.config.PageSetRetention); err != nil {
+			log.Printf("Warning: %v", err)
+		} else if n > 0 {
+			log.Printf("Pruned %d superseded page set(s)", n)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// RunNow registers a job as running on this worker and runs it in the
+// foreground, so jobs started from the CLI show up in the shared queue
+func (w *Worker) RunNow(ctx context.Context, id string, params JobParams) error {
+	// A job left running by a crashed process would otherwise block resuming it
+	if _, err := w.queue.RecoverStale(ctx, jobStaleAfter); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	job, err := w.queue.Start(ctx, id, params, w.id)
+	if err != nil {
+		return err
+	}
+	return w.execute(ctx, job)
+}
+
+// execute runs a claimed job, sending heartbeats and stopping it when a
+// cancel is requested, then records its outcome. The job's events are
+// buffered and written to the job log while it runs.
+func (w *Worker) execute(ctx context.Context, job *Job) error {
+	events := &jobLog{queue: w.queue, jobID: job.ID}
+	eventsDone := make(chan struct{})
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		events.run(eventsDone)
+	}()
+
+	jobCtx, cancel := context.WithCancel(withJobLog(WithJobID(ctx, job.ID), events))
+	defer cancel()
+
+	jobEvent(jobCtx, EventInfo, fmt.Sprintf("▶️ Running job %s (%s) on worker %s", job.ID, job.Mode, w.id), nil)
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(jobHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				cancelRequested, err := w.queue.Heartbeat(jobCtx, job.ID)
+				if err != nil && jobCtx.Err() == nil {
+					log.Printf("Warning: %v", err)
+				}
+				if cancelRequested {
+					jobEvent(jobCtx, EventWarning, fmt.Sprintf("🛑 Cancel requested for job %s", job.ID), nil)
+					cancel()
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := w.crawler.RunJob(jobCtx, job)
+	close(done)
+	if err != nil && jobCtx.Err() != nil {
+		// Report cancellation rather than whatever error it surfaced as
+		err = jobCtx.Err()
+	}
+
+	if err != nil {
+		jobEvent(jobCtx, EventError, fmt.Sprintf("⏹️ Job %s ended: %v", job.ID, err), nil)
+	} else {
+		jobEvent(jobCtx, EventInfo, fmt.Sprintf("✅ Job %s succeeded", job.ID), nil)
+	}
+
+	// Write the last events before the job is marked finished, so streams
+	// that stop at the end of the job see them
+	close(eventsDone)
+	<-flushed
+
+	if finishErr := w.queue.Finish(context.WithoutCancel(ctx), job.ID, err); finishErr != nil {
+		log.Printf("Warning: %v", finishErr)
+	}
+	return err
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 	"time"
 
@@ -89,9 +90,11 @@ func (h *AutoUpdateHandler) GetAutoUpdateStatus(c *gin.Context) {
 		Success: true,
 		Message: "Auto-update service status retrieved",
 		Data: map[string]interface{}{
-			"status":    status,
-			"config":    h.service.GetConfig(),
-			"timestamp": time.Now(),
+			"status":          status,
+			"config":          h.service.GetConfig(),
+			"rate_limit":      h.service.RateLimitState(),
+			"circuit_breaker": h.service.BreakerState(),
+			"timestamp":       time.Now(),
 		},
 	})
 }
@@ -154,10 +157,7 @@ func (h *AutoUpdateHandler) TriggerManualUpdate(c *gin.Context) {
 	}
 
 	// Trigger manual update in background
-	go func() {
-		// This would need to be implemented in the service
-		// h.service.TriggerManualUpdate()
-	}()
+	go h.service.RunOnce(context.Background())
 
 	c.JSON(http.StatusOK, AutoUpdateResponse{
 		Success: true,
@@ -167,3 +167,50 @@ func (h *AutoUpdateHandler) TriggerManualUpdate(c *gin.Context) {
 		},
 	})
 }
+
+// GetDriftReport returns the last upstream schema drift check
+func (h *AutoUpdateHandler) GetDriftReport(c *gin.Context) {
+	report, err := h.service.LatestDriftReport(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, AutoUpdateResponse{
+			Success: false,
+			Message: "Failed to load drift report: " + err.Error(),
+		})
+		return
+	}
+	if report == nil {
+		c.JSON(http.StatusNotFound, AutoUpdateResponse{
+			Success: false,
+			Message: "No schema drift check has run yet",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, AutoUpdateResponse{
+		Success: true,
+		Message: "Schema drift report retrieved",
+		Data:    report,
+	})
+}
+
+// CheckDrift samples the upstream now and compares it against our structs
+func (h *AutoUpdateHandler) CheckDrift(c *gin.Context) {
+	report, err := h.service.CheckSchemaDrift(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, AutoUpdateResponse{
+			Success: false,
+			Message: "Schema drift check failed: " + err.Error(),
+		})
+		return
+	}
+
+	message := "No schema drift detected"
+	if report.Drifted {
+		message = "Upstream schema drift detected"
+	}
+	c.JSON(http.StatusOK, AutoUpdateResponse{
+		Success: true,
+		Message: message,
+		Data:    report,
+	})
+}
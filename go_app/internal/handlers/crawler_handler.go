@@ -1,45 +1,72 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"sync"
+	"strconv"
+	"strings"
 	"time"
 
 	"baca-komik-api/internal/crawler"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type CrawlerHandler struct {
-	crawler       *crawler.Crawler
-	activeJobs    map[string]*CrawlJob
-	jobsMutex     sync.RWMutex
+	crawler  *crawler.Crawler
+	jobs     *crawler.JobQueue
+	baseURLs []string
 }
 
-type CrawlJob struct {
-	ID        string    `json:"id"`
-	Mode      string    `json:"mode"`
-	Status    string    `json:"status"` // "running", "completed", "failed"
-	StartTime time.Time `json:"start_time"`
-	EndTime   *time.Time `json:"end_time,omitempty"`
-	Progress  *CrawlProgress `json:"progress,omitempty"`
-	Error     string    `json:"error,omitempty"`
-}
-
-type CrawlProgress struct {
-	CurrentStep   string `json:"current_step"`
-	TotalSteps    int    `json:"total_steps"`
-	CompletedSteps int   `json:"completed_steps"`
-	Percentage    float64 `json:"percentage"`
-}
-
-func NewCrawlerHandler(c *crawler.Crawler) *CrawlerHandler {
+// NewCrawlerHandler creates the crawler handler. Jobs are queued in the
+// shared job table and run by crawler.Worker instances (API or CLI).
+// baseURLs are the upstream base URLs a job's base_url may be set to.
+func NewCrawlerHandler(c *crawler.Crawler, baseURLs []string) *CrawlerHandler {
 	return &CrawlerHandler{
-		crawler:    c,
-		activeJobs: make(map[string]*CrawlJob),
+		crawler:  c,
+		jobs:     c.Jobs(),
+		baseURLs: baseURLs,
 	}
 }
 
+// recentFailuresLimit is how many recent item failures status responses include
+const recentFailuresLimit = 20
+
+// defaultHistoryLimit is how many jobs /history returns by default
+const defaultHistoryLimit = 50
+
+const (
+	// jobEventPollInterval is how often a job event stream checks for new events
+	jobEventPollInterval = time.Second
+	// jobEventBatch is the most events a stream sends per poll
+	jobEventBatch = 500
+	// jobEventKeepAlive is how long a stream may stay silent before a keep-alive comment
+	jobEventKeepAlive = 15 * time.Second
+)
+
+// defaultFailuresLimit is how many dead letters /failures returns by default
+const defaultFailuresLimit = 50
+
+// defaultDuplicatesLimit is how many duplicate reviews /duplicates returns by default
+const defaultDuplicatesLimit = 50
+
+// defaultChangesLimit is how many field changes /changes returns by default
+const defaultChangesLimit = 100
+
+// defaultQuarantineLimit is how many quarantined records /quarantine returns by default
+const defaultQuarantineLimit = 50
+
+// defaultRemovedLimit is how many removed comics and chapters /removed returns by default
+const defaultRemovedLimit = 50
+
+// defaultPageSetsLimit is how many superseded page sets /page-sets returns by default
+const defaultPageSetsLimit = 50
+
+// maxPreviewPages bounds the manga list pages a dry-run (preview) job may fetch
+const maxPreviewPages = 5
+
 type CrawlRequest struct {
 	Mode      string `json:"mode" binding:"required"`
 	StartPage int    `json:"start_page,omitempty"`
@@ -47,6 +74,23 @@ type CrawlRequest struct {
 	BatchSize int    `json:"batch_size,omitempty"`
 	MangaID   string `json:"manga_id,omitempty"`
 	DryRun    bool   `json:"dry_run,omitempty"`
+	Verbose   *bool  `json:"verbose,omitempty"`
+	BaseURL   string `json:"base_url,omitempty"`
+
+	NewChaptersOnly bool `json:"new_chapters_only,omitempty"`
+}
+
+// FailureRetryRequest selects dead letters to retry: the given IDs, or with
+// All every pending one (of Entity, if set)
+type FailureRetryRequest struct {
+	IDs    []int64 `json:"ids,omitempty"`
+	Entity string  `json:"entity,omitempty"`
+	All    bool    `json:"all,omitempty"`
+}
+
+// FailureIgnoreRequest lists dead letters to mark ignored
+type FailureIgnoreRequest struct {
+	IDs []int64 `json:"ids" binding:"required"`
 }
 
 type CrawlResponse struct {
@@ -57,7 +101,7 @@ type CrawlResponse struct {
 	Data      interface{} `json:"data,omitempty"`
 }
 
-// StartCrawling starts a crawling job
+// StartCrawling queues a crawling job
 func (h *CrawlerHandler) StartCrawling(c *gin.Context) {
 	var req CrawlRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -68,103 +112,94 @@ func (h *CrawlerHandler) StartCrawling(c *gin.Context) {
 		return
 	}
 
+	if !crawler.ValidJobMode(req.Mode) {
+		c.JSON(http.StatusBadRequest, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Unknown mode: %s", req.Mode),
+		})
+		return
+	}
+
 	// Set defaults
+	if req.StartPage == 0 {
+		req.StartPage = 1
+	}
 	if req.BatchSize == 0 {
 		req.BatchSize = 10
 	}
 	if req.EndPage == 0 {
 		req.EndPage = 10
+		if req.Mode == "manga_incremental" && !req.DryRun {
+			// Stops on its own at already-seen updates
+			req.EndPage = -1
+		}
 	}
 
-	jobID := fmt.Sprintf("crawl_%s_%d", req.Mode, time.Now().Unix())
-
-	// Create job entry
-	job := &CrawlJob{
-		ID:        jobID,
-		Mode:      req.Mode,
-		Status:    "running",
-		StartTime: time.Now(),
-		Progress: &CrawlProgress{
-			CurrentStep: "Starting...",
-			TotalSteps:  1,
-			CompletedSteps: 0,
-			Percentage: 0,
-		},
+	if err := validateCrawlRequest(&req, h.baseURLs); err != nil {
+		c.JSON(http.StatusBadRequest, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Invalid request: %v", err),
+		})
+		return
 	}
 
-	// Store job in active jobs
-	h.jobsMutex.Lock()
-	h.activeJobs[jobID] = job
-	h.jobsMutex.Unlock()
-
-	// Start crawling in background goroutine
-	go func() {
-		var err error
-
-		// Update job status
-		h.updateJobProgress(jobID, "Running crawling...", 0, 1)
-
-		switch req.Mode {
-		case "genres":
-			err = h.crawler.CrawlGenres()
-		case "formats":
-			err = h.crawler.CrawlFormats()
-		case "types":
-			err = h.crawler.CrawlTypes()
-		case "authors":
-			err = h.crawler.CrawlAuthors()
-		case "artists":
-			err = h.crawler.CrawlArtists()
-		case "manga":
-			err = h.crawler.CrawlManga(req.StartPage, req.EndPage)
-		case "chapters":
-			if req.MangaID == "all" || req.MangaID == "" {
-				// Auto-crawl chapters for all manga in database
-				err = h.crawler.CrawlAllChapters()
-			} else {
-				// Crawl chapters for specific manga ID
-				err = h.crawler.CrawlChaptersForManga(req.MangaID)
-			}
-		case "pages":
-			err = h.crawler.CrawlAllPages()
-		case "all":
-			err = h.crawler.CrawlAll()
-		case "auto":
-			err = h.crawler.CrawlAllMasterData()
-		default:
-			err = fmt.Errorf("unknown mode: %s", req.Mode)
-		}
+	// Queue the job; a worker (this server or cmd/crawler --mode=worker) picks it up
+	jobID := crawler.NewJobID("crawl", req.Mode)
+	job, err := h.jobs.Enqueue(c.Request.Context(), jobID, crawler.JobParams{
+		Mode:      req.Mode,
+		StartPage: req.StartPage,
+		EndPage:   req.EndPage,
+		BatchSize: req.BatchSize,
+		MangaID:   req.MangaID,
+		DryRun:    req.DryRun,
+		Verbose:   req.Verbose,
+		BaseURL:   req.BaseURL,
 
-		// Update job completion
-		h.completeJob(jobID, err)
-	}()
+		NewChaptersOnly: req.NewChaptersOnly,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to queue crawling job: %v", err),
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, CrawlResponse{
 		Success:   true,
-		Message:   fmt.Sprintf("Crawling job started in background: %s", req.Mode),
+		Message:   fmt.Sprintf("Crawling job queued: %s", req.Mode),
 		StartTime: time.Now(),
-		JobID:     jobID,
-		Data:      map[string]string{"job_id": jobID, "status": "running"},
+		JobID:     job.ID,
+		Data:      map[string]string{"job_id": job.ID, "status": job.Status},
 	})
 }
 
 // GetCrawlStatus returns current crawling status
 func (h *CrawlerHandler) GetCrawlStatus(c *gin.Context) {
-	// Check for active background jobs first
-	activeJob := h.getActiveJob()
-	if activeJob != nil {
-		elapsed := time.Since(activeJob.StartTime)
-
-		statusData := map[string]interface{}{
-			"job_id":           activeJob.ID,
-			"mode":             activeJob.Mode,
-			"status":           activeJob.Status,
-			"current_step":     activeJob.Progress.CurrentStep,
-			"progress_percent": activeJob.Progress.Percentage,
-			"elapsed_time":     elapsed.Round(time.Second).String(),
-			"start_time":       activeJob.StartTime,
+	// Check for running jobs first (from any worker, API or CLI)
+	running, err := h.jobs.List(c.Request.Context(), crawler.JobRunning, 1)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to load jobs: %v", err),
+		})
+		return
+	}
+	if len(running) > 0 && c.Query("job_id") == "" {
+		failures, err := h.crawler.DeadLetterSummary(c.Request.Context(), running[0].ID, recentFailuresLimit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, CrawlResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to load failures: %v", err),
+			})
+			return
 		}
 
+		statusData := jobStatusData(&running[0])
+		statusData["rate_limit"] = h.rateLimitState()
+		statusData["circuit_breaker"] = h.breakerState()
+		statusData["failures"] = failures
+
 		c.JSON(http.StatusOK, CrawlResponse{
 			Success: true,
 			Message: "Active crawling job found",
@@ -173,8 +208,8 @@ func (h *CrawlerHandler) GetCrawlStatus(c *gin.Context) {
 		return
 	}
 
-	// Fallback to checkpoint system
-	checkpoint, err := h.crawler.LoadCheckpoint()
+	// Fallback to checkpoint system (?job_id=... or the latest job)
+	checkpoint, err := h.crawler.LoadCheckpoint(c.Request.Context(), c.Query("job_id"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, CrawlResponse{
 			Success: false,
@@ -183,11 +218,29 @@ func (h *CrawlerHandler) GetCrawlStatus(c *gin.Context) {
 		return
 	}
 
+	// Pending dead letters of the job, or all of them without one
+	var jobID string
+	if checkpoint != nil {
+		jobID = checkpoint.JobID
+	}
+	failures, err := h.crawler.DeadLetterSummary(c.Request.Context(), jobID, recentFailuresLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to load failures: %v", err),
+		})
+		return
+	}
+
 	if checkpoint == nil {
 		c.JSON(http.StatusOK, CrawlResponse{
 			Success: true,
 			Message: "No active crawling session",
-			Data:    nil,
+			Data: map[string]interface{}{
+				"rate_limit":      h.rateLimitState(),
+				"circuit_breaker": h.breakerState(),
+				"failures":        failures,
+			},
 		})
 		return
 	}
@@ -208,6 +261,7 @@ func (h *CrawlerHandler) GetCrawlStatus(c *gin.Context) {
 	}
 
 	statusData := map[string]interface{}{
+		"job_id":           checkpoint.JobID,
 		"phase":            checkpoint.Phase,
 		"current_page":     checkpoint.CurrentPage,
 		"total_processed":  checkpoint.TotalProcessed,
@@ -218,6 +272,13 @@ func (h *CrawlerHandler) GetCrawlStatus(c *gin.Context) {
 		"success_count":    checkpoint.SuccessCount,
 		"error_count":      checkpoint.ErrorCount,
 		"last_update":      checkpoint.LastUpdateTime,
+		"completed_at":     checkpoint.CompletedAt,
+		"rate_limit":       h.rateLimitState(),
+		"circuit_breaker":  h.breakerState(),
+		"failures":         failures,
+	}
+	if len(checkpoint.Stages) > 0 {
+		statusData["stages"] = checkpoint.Stages
 	}
 
 	c.JSON(http.StatusOK, CrawlResponse{
@@ -227,25 +288,51 @@ func (h *CrawlerHandler) GetCrawlStatus(c *gin.Context) {
 	})
 }
 
-// StopCrawling stops current crawling (clears checkpoint)
+// StopCrawling cancels queued and running jobs (all of them, or only ?job_id=...).
+// Running jobs stop at their worker's next heartbeat, wherever they run.
+// Their checkpoints are kept for /resume unless ?clear_checkpoint=true.
 func (h *CrawlerHandler) StopCrawling(c *gin.Context) {
-	if err := h.crawler.ClearCheckpoint(); err != nil {
+	jobID := c.Query("job_id")
+
+	cancelled, err := h.jobs.RequestCancel(c.Request.Context(), jobID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, CrawlResponse{
 			Success: false,
 			Message: fmt.Sprintf("Failed to stop crawling: %v", err),
 		})
 		return
 	}
+	if jobID != "" && len(cancelled) == 0 {
+		c.JSON(http.StatusNotFound, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("No running job found: %s", jobID),
+		})
+		return
+	}
+
+	message := fmt.Sprintf("Crawling stopped (%d job(s) cancelled)", len(cancelled))
+	if c.Query("clear_checkpoint") == "true" {
+		if err := h.crawler.ClearCheckpoint(c.Request.Context(), jobID); err != nil {
+			c.JSON(http.StatusInternalServerError, CrawlResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to stop crawling: %v", err),
+			})
+			return
+		}
+		message += " and checkpoint cleared"
+	}
 
 	c.JSON(http.StatusOK, CrawlResponse{
 		Success: true,
-		Message: "Crawling stopped and checkpoint cleared",
+		Message: message,
+		Data:    map[string]interface{}{"cancelled_jobs": cancelled},
 	})
 }
 
-// ResumeCrawling resumes from last checkpoint
+// ResumeCrawling resumes a job (?job_id=..., default the latest) from its checkpoint.
+// The resumed job keeps the original job ID so it continues the same checkpoint.
 func (h *CrawlerHandler) ResumeCrawling(c *gin.Context) {
-	checkpoint, err := h.crawler.LoadCheckpoint()
+	checkpoint, err := h.crawler.LoadCheckpoint(c.Request.Context(), c.Query("job_id"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, CrawlResponse{
 			Success: false,
@@ -262,36 +349,121 @@ func (h *CrawlerHandler) ResumeCrawling(c *gin.Context) {
 		return
 	}
 
-	// Start resume in background
-	go func() {
-		// TODO: Implement resume logic based on checkpoint.Phase
-		// For now, just continue from where it left off
-		switch checkpoint.Phase {
-		case "manga":
-			h.crawler.CrawlManga(checkpoint.CurrentPage, -1)
-		case "chapters":
-			h.crawler.CrawlAllChapters()
-		case "pages":
-			h.crawler.CrawlAllPages()
-		}
-	}()
+	if checkpoint.CompletedAt != nil {
+		c.JSON(http.StatusBadRequest, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Job %s already completed, nothing to resume", checkpoint.JobID),
+		})
+		return
+	}
+
+	// Queue the same job again, with its original options; the worker
+	// resumes it from its checkpoint
+	params := crawler.JobParams{Mode: checkpoint.Phase, EndPage: checkpoint.EndPage}
+	job, err := h.jobs.Get(c.Request.Context(), checkpoint.JobID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to load job: %v", err),
+		})
+		return
+	}
+	if job != nil {
+		params = job.Params
+	}
+	params.Resume = true
+
+	_, err = h.jobs.Enqueue(c.Request.Context(), checkpoint.JobID, params)
+	if errors.Is(err, crawler.ErrJobActive) {
+		c.JSON(http.StatusConflict, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Job %s is still queued or running", checkpoint.JobID),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to queue resume: %v", err),
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, CrawlResponse{
 		Success:   true,
-		Message:   fmt.Sprintf("Resuming %s crawling from page %d", checkpoint.Phase, checkpoint.CurrentPage),
+		Message:   fmt.Sprintf("Resuming %s crawling of job %s from page %d", checkpoint.Phase, checkpoint.JobID, checkpoint.CurrentPage),
 		StartTime: time.Now(),
+		JobID:     checkpoint.JobID,
 		Data:      checkpoint,
 	})
 }
 
-// GetCrawlHistory returns crawling statistics
+// validateCrawlRequest checks the page range of a crawl request and that its
+// base URL is one of baseURLs, and keeps dry-run jobs small enough to be a
+// safe preview: a bounded page range, chapters of a single manga, and no
+// database-wide modes.
+func validateCrawlRequest(req *CrawlRequest, baseURLs []string) error {
+	if req.StartPage < 1 {
+		return fmt.Errorf("start_page must be at least 1")
+	}
+	if req.EndPage != -1 && req.EndPage < req.StartPage {
+		return fmt.Errorf("end_page must be -1 (all) or at least start_page")
+	}
+	if req.BatchSize < 0 {
+		return fmt.Errorf("batch_size must be positive")
+	}
+
+	if req.BaseURL != "" && !allowedBaseURL(req.BaseURL, baseURLs) {
+		return fmt.Errorf("base_url is not an allowed upstream (see CRAWLER_BASE_URLS)")
+	}
+
+	if !req.DryRun {
+		return nil
+	}
+	switch req.Mode {
+	case "manga", "manga_incremental", "pipeline":
+		if req.EndPage == -1 || req.EndPage-req.StartPage+1 > maxPreviewPages {
+			return fmt.Errorf("dry run of %s is limited to %d pages", req.Mode, maxPreviewPages)
+		}
+	case "chapters", "reconcile":
+		if req.MangaID == "" || req.MangaID == "all" {
+			return fmt.Errorf("dry run of %s needs a specific manga_id", req.Mode)
+		}
+	case "pages", "all", "auto", "authors", "artists", "retry_failures":
+		// Unbounded: every chapter, every author/artist search page, or every
+		// pending dead letter
+		return fmt.Errorf("mode %s cannot be previewed with dry_run", req.Mode)
+	}
+	return nil
+}
+
+// allowedBaseURL reports whether baseURL is one of allowed, ignoring a
+// trailing slash
+func allowedBaseURL(baseURL string, allowed []string) bool {
+	baseURL = strings.TrimSuffix(strings.TrimSpace(baseURL), "/")
+	for _, a := range allowed {
+		if baseURL == strings.TrimSuffix(strings.TrimSpace(a), "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCrawlHistory returns recent jobs (?status=... filters, ?limit=... caps, default 50)
 func (h *CrawlerHandler) GetCrawlHistory(c *gin.Context) {
-	h.jobsMutex.RLock()
-	jobs := make([]*CrawlJob, 0, len(h.activeJobs))
-	for _, job := range h.activeJobs {
-		jobs = append(jobs, job)
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultHistoryLimit)))
+	if err != nil || limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+
+	jobs, err := h.jobs.List(c.Request.Context(), c.Query("status"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to load jobs: %v", err),
+		})
+		return
 	}
-	h.jobsMutex.RUnlock()
 
 	c.JSON(http.StatusOK, CrawlResponse{
 		Success: true,
@@ -300,90 +472,632 @@ func (h *CrawlerHandler) GetCrawlHistory(c *gin.Context) {
 	})
 }
 
-// Helper functions for job management
-func (h *CrawlerHandler) updateJobProgress(jobID, step string, completed, total int) {
-	h.jobsMutex.Lock()
-	defer h.jobsMutex.Unlock()
-
-	if job, exists := h.activeJobs[jobID]; exists {
-		job.Progress.CurrentStep = step
-		job.Progress.CompletedSteps = completed
-		job.Progress.TotalSteps = total
-		if total > 0 {
-			job.Progress.Percentage = float64(completed) / float64(total) * 100
-		}
+// GetFailures lists dead letters (?status=pending|ignored|resolved, default
+// pending; ?entity=manga_page|manga|chapter; ?limit=... and ?offset=...)
+func (h *CrawlerHandler) GetFailures(c *gin.Context) {
+	filter := crawler.DeadLetterFilter{
+		Status: c.DefaultQuery("status", crawler.FailurePending),
+		Entity: c.Query("entity"),
+		Limit:  defaultFailuresLimit,
+	}
+	if filter.Status == "all" {
+		filter.Status = ""
+	}
+	if filter.Entity != "" && !crawler.ValidEntity(filter.Entity) {
+		c.JSON(http.StatusBadRequest, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Unknown entity: %s", filter.Entity),
+		})
+		return
+	}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 {
+		filter.Limit = limit
+	}
+	if offset, err := strconv.Atoi(c.Query("offset")); err == nil && offset > 0 {
+		filter.Offset = offset
+	}
+
+	failures, total, err := h.crawler.ListDeadLetters(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to load failures: %v", err),
+		})
+		return
 	}
+
+	c.JSON(http.StatusOK, CrawlResponse{
+		Success: true,
+		Message: "Failures retrieved",
+		Data: map[string]interface{}{
+			"failures": failures,
+			"total":    total,
+			"limit":    filter.Limit,
+			"offset":   filter.Offset,
+		},
+	})
 }
 
-func (h *CrawlerHandler) completeJob(jobID string, err error) {
-	h.jobsMutex.Lock()
-	defer h.jobsMutex.Unlock()
+// RetryFailures queues a retry_failures job for the selected dead letters
+func (h *CrawlerHandler) RetryFailures(c *gin.Context) {
+	var req FailureRetryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Invalid request: %v", err),
+		})
+		return
+	}
+
+	if len(req.IDs) == 0 && !req.All {
+		c.JSON(http.StatusBadRequest, CrawlResponse{
+			Success: false,
+			Message: "Invalid request: give ids or set all to true",
+		})
+		return
+	}
+	if req.Entity != "" && !crawler.ValidEntity(req.Entity) {
+		c.JSON(http.StatusBadRequest, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Unknown entity: %s", req.Entity),
+		})
+		return
+	}
+
+	params := crawler.JobParams{Mode: "retry_failures", FailureEntity: req.Entity}
+	if !req.All {
+		params.FailureIDs = req.IDs
+	}
 
-	if job, exists := h.activeJobs[jobID]; exists {
-		now := time.Now()
-		job.EndTime = &now
+	jobID := crawler.NewJobID("crawl", params.Mode)
+	job, err := h.jobs.Enqueue(c.Request.Context(), jobID, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to queue retry job: %v", err),
+		})
+		return
+	}
 
-		if err != nil {
-			job.Status = "failed"
-			job.Error = err.Error()
-		} else {
-			job.Status = "completed"
-			job.Progress.Percentage = 100
-			job.Progress.CurrentStep = "Completed"
-		}
+	c.JSON(http.StatusOK, CrawlResponse{
+		Success:   true,
+		Message:   "Retry job queued",
+		StartTime: time.Now(),
+		JobID:     job.ID,
+		Data:      map[string]string{"job_id": job.ID, "status": job.Status},
+	})
+}
+
+// IgnoreFailures marks dead letters as ignored so they are not retried
+func (h *CrawlerHandler) IgnoreFailures(c *gin.Context) {
+	var req FailureIgnoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Invalid request: %v", err),
+		})
+		return
+	}
+
+	n, err := h.crawler.IgnoreDeadLetters(c.Request.Context(), req.IDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to ignore failures: %v", err),
+		})
+		return
 	}
+
+	c.JSON(http.StatusOK, CrawlResponse{
+		Success: true,
+		Message: fmt.Sprintf("%d failure(s) marked ignored", n),
+		Data:    map[string]interface{}{"ignored": n},
+	})
 }
 
-func (h *CrawlerHandler) getActiveJob() *CrawlJob {
-	h.jobsMutex.RLock()
-	defer h.jobsMutex.RUnlock()
+// GetDuplicates lists duplicate manga reviews (?status=pending|merged|linked|distinct|all,
+// default pending; ?limit=... and ?offset=...), highest score first
+func (h *CrawlerHandler) GetDuplicates(c *gin.Context) {
+	status := c.DefaultQuery("status", crawler.DuplicatePending)
+	if status == "all" {
+		status = ""
+	}
+	limit := defaultDuplicatesLimit
+	if n, err := strconv.Atoi(c.Query("limit")); err == nil && n > 0 {
+		limit = n
+	}
+	offset := 0
+	if n, err := strconv.Atoi(c.Query("offset")); err == nil && n > 0 {
+		offset = n
+	}
 
-	for _, job := range h.activeJobs {
-		if job.Status == "running" {
-			return job
-		}
+	reviews, total, err := h.crawler.ListDuplicateReviews(c.Request.Context(), status, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to load duplicates: %v", err),
+		})
+		return
 	}
-	return nil
+
+	c.JSON(http.StatusOK, CrawlResponse{
+		Success: true,
+		Message: "Duplicates retrieved",
+		Data: map[string]interface{}{
+			"duplicates": reviews,
+			"total":      total,
+			"limit":      limit,
+			"offset":     offset,
+		},
+	})
 }
 
-// GetJobStatus returns status of specific job
-func (h *CrawlerHandler) GetJobStatus(c *gin.Context) {
-	jobID := c.Param("id")
+// MergeDuplicate merges the new comic of a duplicate review into its candidate
+func (h *CrawlerHandler) MergeDuplicate(c *gin.Context) {
+	h.resolveDuplicate(c, crawler.DuplicateMerged)
+}
 
-	h.jobsMutex.RLock()
-	job, exists := h.activeJobs[jobID]
-	h.jobsMutex.RUnlock()
+// LinkDuplicate links both comics of a duplicate review as the same work
+func (h *CrawlerHandler) LinkDuplicate(c *gin.Context) {
+	h.resolveDuplicate(c, crawler.DuplicateLinked)
+}
+
+// MarkDuplicateDistinct marks both comics of a duplicate review as different works
+func (h *CrawlerHandler) MarkDuplicateDistinct(c *gin.Context) {
+	h.resolveDuplicate(c, crawler.DuplicateDistinct)
+}
+
+// resolveDuplicate applies decision to the duplicate review in the :id path
+// param, as the authenticated admin
+func (h *CrawlerHandler) resolveDuplicate(c *gin.Context, decision string) {
+	// The route group checks the admin role; merges also delete comics and
+	// move user data, so never resolve without a known admin
+	adminID := c.GetString("user_id")
+	if adminID == "" && c.GetString("user_role") == "service_role" {
+		adminID = "service_role" // service tokens have no user
+	}
+	if adminID == "" {
+		c.JSON(http.StatusUnauthorized, CrawlResponse{
+			Success: false,
+			Message: "Admin authentication required",
+		})
+		return
+	}
 
-	if !exists {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Invalid duplicate ID: %s", c.Param("id")),
+		})
+		return
+	}
+
+	review, err := h.crawler.ResolveDuplicate(c.Request.Context(), id, decision, adminID)
+	if errors.Is(err, crawler.ErrDuplicateNotFound) {
 		c.JSON(http.StatusNotFound, CrawlResponse{
 			Success: false,
-			Message: fmt.Sprintf("Job not found: %s", jobID),
+			Message: fmt.Sprintf("No pending duplicate review: %d", id),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to resolve duplicate: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, CrawlResponse{
+		Success: true,
+		Message: fmt.Sprintf("Duplicate %d marked %s", id, decision),
+		Data:    review,
+	})
+}
+
+// GetQuarantine returns upstream records that failed validation (?entity=manga|chapter|chapter_detail)
+func (h *CrawlerHandler) GetQuarantine(c *gin.Context) {
+	entity := c.Query("entity")
+	switch entity {
+	case "", crawler.PayloadManga, crawler.PayloadChapter, crawler.PayloadChapterDetail:
+	default:
+		c.JSON(http.StatusBadRequest, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Unknown entity: %s", entity),
+		})
+		return
+	}
+	limit := defaultQuarantineLimit
+	if n, err := strconv.Atoi(c.Query("limit")); err == nil && n > 0 {
+		limit = n
+	}
+	offset := 0
+	if n, err := strconv.Atoi(c.Query("offset")); err == nil && n > 0 {
+		offset = n
+	}
+
+	records, total, err := h.crawler.ListQuarantine(c.Request.Context(), entity, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to load quarantine: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, CrawlResponse{
+		Success: true,
+		Message: "Quarantined records retrieved",
+		Data: map[string]interface{}{
+			"records": records,
+			"total":   total,
+			"limit":   limit,
+			"offset":  offset,
+		},
+	})
+}
+
+// GetRemoved returns comics and chapters marked removed upstream by a
+// reconcile job (?entity=komik|chapter), most recently removed first
+func (h *CrawlerHandler) GetRemoved(c *gin.Context) {
+	entity := c.Query("entity")
+	if entity != "" && entity != crawler.ChangeKomik && entity != crawler.ChangeChapter {
+		c.JSON(http.StatusBadRequest, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Unknown entity: %s", entity),
+		})
+		return
+	}
+	limit := defaultRemovedLimit
+	if n, err := strconv.Atoi(c.Query("limit")); err == nil && n > 0 {
+		limit = n
+	}
+	offset := 0
+	if n, err := strconv.Atoi(c.Query("offset")); err == nil && n > 0 {
+		offset = n
+	}
+
+	removed, total, err := h.crawler.ListRemoved(c.Request.Context(), entity, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to load removed entities: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, CrawlResponse{
+		Success: true,
+		Message: "Removed entities retrieved",
+		Data: map[string]interface{}{
+			"removed": removed,
+			"total":   total,
+			"limit":   limit,
+			"offset":  offset,
+		},
+	})
+}
+
+// GetPageSetChanges returns superseded chapter page sets (upstream
+// re-uploads), most recent first
+func (h *CrawlerHandler) GetPageSetChanges(c *gin.Context) {
+	limit := defaultPageSetsLimit
+	if n, err := strconv.Atoi(c.Query("limit")); err == nil && n > 0 {
+		limit = n
+	}
+	offset := 0
+	if n, err := strconv.Atoi(c.Query("offset")); err == nil && n > 0 {
+		offset = n
+	}
+
+	changes, total, err := h.crawler.ListPageSetChanges(c.Request.Context(), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to load page set changes: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, CrawlResponse{
+		Success: true,
+		Message: "Page set changes retrieved",
+		Data: map[string]interface{}{
+			"changes": changes,
+			"total":   total,
+			"limit":   limit,
+			"offset":  offset,
+		},
+	})
+}
+
+// GetChapterPageSets returns the current and superseded page sets of a chapter
+func (h *CrawlerHandler) GetChapterPageSets(c *gin.Context) {
+	chapterID := c.Param("id")
+	if _, err := uuid.Parse(chapterID); err != nil {
+		c.JSON(http.StatusBadRequest, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Invalid chapter ID: %s", chapterID),
+		})
+		return
+	}
+
+	sets, err := h.crawler.ChapterPageSets(c.Request.Context(), chapterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to load page sets: %v", err),
+		})
+		return
+	}
+	if sets == nil {
+		c.JSON(http.StatusNotFound, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Chapter not found: %s", chapterID),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, CrawlResponse{
+		Success: true,
+		Message: "Page sets retrieved",
+		Data:    sets,
+	})
+}
+
+// GetChanges returns logged field changes of crawled comics and chapters,
+// newest first (?field=status&since=<RFC3339> feeds "status changed" notifications)
+func (h *CrawlerHandler) GetChanges(c *gin.Context) {
+	h.listChanges(c, "")
+}
+
+// GetComicChanges returns the change history of one comic and its chapters
+func (h *CrawlerHandler) GetComicChanges(c *gin.Context) {
+	komikID := c.Param("id")
+	if _, err := uuid.Parse(komikID); err != nil {
+		c.JSON(http.StatusBadRequest, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Invalid comic ID: %s", komikID),
+		})
+		return
+	}
+	h.listChanges(c, komikID)
+}
+
+// listChanges responds with the changes matching the query (?entity, ?field,
+// ?since, ?limit, ?offset), limited to komikID if set
+func (h *CrawlerHandler) listChanges(c *gin.Context, komikID string) {
+	filter := crawler.ChangeFilter{
+		KomikID: komikID,
+		Entity:  c.Query("entity"),
+		Field:   c.Query("field"),
+		Limit:   defaultChangesLimit,
+	}
+	if filter.Entity != "" && filter.Entity != crawler.ChangeKomik && filter.Entity != crawler.ChangeChapter {
+		c.JSON(http.StatusBadRequest, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Unknown entity: %s", filter.Entity),
+		})
+		return
+	}
+	if since := c.Query("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, CrawlResponse{
+				Success: false,
+				Message: fmt.Sprintf("Invalid since (want RFC3339): %s", since),
+			})
+			return
+		}
+		filter.Since = &t
+	}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 {
+		filter.Limit = limit
+	}
+	if offset, err := strconv.Atoi(c.Query("offset")); err == nil && offset > 0 {
+		filter.Offset = offset
+	}
+
+	changes, total, err := h.crawler.ListChanges(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to load changes: %v", err),
 		})
 		return
 	}
 
+	c.JSON(http.StatusOK, CrawlResponse{
+		Success: true,
+		Message: "Changes retrieved",
+		Data: map[string]interface{}{
+			"changes": changes,
+			"total":   total,
+			"limit":   filter.Limit,
+			"offset":  filter.Offset,
+		},
+	})
+}
+
+// Helper functions for job management
+
+// breakerState returns the upstream circuit breaker state for status responses
+func (h *CrawlerHandler) breakerState() *crawler.BreakerState {
+	if h.crawler.Breaker() == nil {
+		return nil
+	}
+	state := h.crawler.Breaker().State()
+	return &state
+}
+
+// rateLimitState returns the per-host upstream limiter state for status responses
+func (h *CrawlerHandler) rateLimitState() []crawler.HostLimitState {
+	if h.crawler.Limiter() == nil {
+		return nil
+	}
+	return h.crawler.Limiter().State()
+}
+
+// jobStatusData builds the status response fields of a job
+func jobStatusData(job *crawler.Job) map[string]interface{} {
 	var elapsed time.Duration
-	if job.EndTime != nil {
-		elapsed = job.EndTime.Sub(job.StartTime)
-	} else {
-		elapsed = time.Since(job.StartTime)
+	if job.StartedAt != nil {
+		if job.FinishedAt != nil {
+			elapsed = job.FinishedAt.Sub(*job.StartedAt)
+		} else {
+			elapsed = time.Since(*job.StartedAt)
+		}
 	}
 
-	statusData := map[string]interface{}{
+	progress := float64(0)
+	if job.Status == crawler.JobSucceeded {
+		progress = 100
+	} else if job.Total > 0 {
+		progress = float64(job.Processed) / float64(job.Total) * 100
+	}
+
+	return map[string]interface{}{
 		"job_id":           job.ID,
 		"mode":             job.Mode,
 		"status":           job.Status,
-		"current_step":     job.Progress.CurrentStep,
-		"progress_percent": job.Progress.Percentage,
+		"params":           job.Params,
+		"worker_id":        job.WorkerID,
+		"processed":        job.Processed,
+		"succeeded":        job.Succeeded,
+		"failed":           job.Failed,
+		"total":            job.Total,
+		"quarantined":      job.Quarantined,
+		"progress_percent": progress,
 		"elapsed_time":     elapsed.Round(time.Second).String(),
-		"start_time":       job.StartTime,
-		"end_time":         job.EndTime,
+		"created_at":       job.CreatedAt,
+		"start_time":       job.StartedAt,
+		"end_time":         job.FinishedAt,
+		"last_heartbeat":   job.HeartbeatAt,
+		"cancel_requested": job.CancelRequested,
 		"error":            job.Error,
 	}
+}
+
+// GetJobStatus returns status of specific job
+func (h *CrawlerHandler) GetJobStatus(c *gin.Context) {
+	jobID := c.Param("id")
+
+	job, err := h.jobs.Get(c.Request.Context(), jobID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to load job: %v", err),
+		})
+		return
+	}
+	if job == nil {
+		c.JSON(http.StatusNotFound, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Job not found: %s", jobID),
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, CrawlResponse{
 		Success: true,
 		Message: "Job status retrieved",
-		Data:    statusData,
+		Data:    jobStatusData(job),
 	})
 }
+
+// StreamJobEvents streams a job's events (progress ticks, info, warnings,
+// errors) as Server-Sent Events until the job finishes or the client leaves.
+// Each event's SSE id is its event ID, so clients reconnecting with
+// Last-Event-ID (or ?after=<id>) continue where they left off. A final "done"
+// event carries the job's status.
+func (h *CrawlerHandler) StreamJobEvents(c *gin.Context) {
+	jobID := c.Param("id")
+	ctx := c.Request.Context()
+
+	job, err := h.jobs.Get(ctx, jobID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to load job: %v", err),
+		})
+		return
+	}
+	if job == nil {
+		c.JSON(http.StatusNotFound, CrawlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Job not found: %s", jobID),
+		})
+		return
+	}
+
+	lastID, _ := strconv.ParseInt(c.GetHeader("Last-Event-ID"), 10, 64)
+	if after := c.Query("after"); after != "" {
+		lastID, _ = strconv.ParseInt(after, 10, 64)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	ticker := time.NewTicker(jobEventPollInterval)
+	defer ticker.Stop()
+	lastWrite := time.Now()
+
+	for {
+		// Check the job before reading events: events are all written
+		// before a job is marked finished
+		job, err = h.jobs.Get(ctx, jobID)
+		if err != nil || job == nil {
+			return
+		}
+		finished := job.Status != crawler.JobQueued && job.Status != crawler.JobRunning
+
+		for {
+			events, err := h.jobs.Events(ctx, jobID, lastID, jobEventBatch)
+			if err != nil {
+				return
+			}
+			for _, event := range events {
+				writeSSE(c, strconv.FormatInt(event.ID, 10), event.Level, event)
+				lastID = event.ID
+				lastWrite = time.Now()
+			}
+			if len(events) < jobEventBatch {
+				break
+			}
+		}
+
+		if finished {
+			writeSSE(c, "", "done", jobStatusData(job))
+			c.Writer.Flush()
+			return
+		}
+		if time.Since(lastWrite) >= jobEventKeepAlive {
+			// Comment line keeps proxies from closing an idle stream
+			fmt.Fprint(c.Writer, ": keep-alive\n\n")
+			lastWrite = time.Now()
+		}
+		c.Writer.Flush()
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// writeSSE writes one Server-Sent Event with a JSON payload
+func writeSSE(c *gin.Context, id, event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	if id != "" {
+		fmt.Fprintf(c.Writer, "id: %s\n", id)
+	}
+	fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event, payload)
+}
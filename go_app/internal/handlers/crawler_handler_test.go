@@ -0,0 +1,37 @@
+package handlers
+
+import "testing"
+
+func TestValidateCrawlRequestBoundsDryRuns(t *testing.T) {
+	const upstream = "https://api.shngm.io/v1"
+	tests := []struct {
+		req   CrawlRequest
+		valid bool
+	}{
+		{CrawlRequest{Mode: "manga", EndPage: 5, DryRun: true}, true},
+		{CrawlRequest{Mode: "manga", EndPage: -1, DryRun: true}, false},
+		{CrawlRequest{Mode: "pipeline", EndPage: 6, DryRun: true}, false},
+		{CrawlRequest{Mode: "chapters", MangaID: "all", DryRun: true}, false},
+		{CrawlRequest{Mode: "genres", DryRun: true}, true},
+		{CrawlRequest{Mode: "pages", DryRun: true}, false},
+		{CrawlRequest{Mode: "auto", DryRun: true}, false},
+		{CrawlRequest{Mode: "authors", DryRun: true}, false},
+		{CrawlRequest{Mode: "artists", DryRun: true}, false},
+		{CrawlRequest{Mode: "retry_failures", DryRun: true}, false},
+		{CrawlRequest{Mode: "auto"}, true},
+		{CrawlRequest{Mode: "manga", BaseURL: upstream + "/"}, true},
+		{CrawlRequest{Mode: "manga", BaseURL: "http://169.254.169.254/v1"}, false},
+	}
+
+	for _, tt := range tests {
+		req := tt.req
+		req.StartPage = 1
+		if req.EndPage == 0 {
+			req.EndPage = 1
+		}
+		err := validateCrawlRequest(&req, []string{upstream})
+		if (err == nil) != tt.valid {
+			t.Errorf("%+v: error = %v, want valid = %v", tt.req, err, tt.valid)
+		}
+	}
+}
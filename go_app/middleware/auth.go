@@ -100,3 +100,25 @@ func OptionalAuth(cfg *config.Config) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// AdminRequired allows only admins through; it must run after AuthRequired.
+// Admins are Supabase service_role tokens and the users listed in ADMIN_EMAILS.
+func AdminRequired(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("user_role") == "service_role" {
+			c.Next()
+			return
+		}
+
+		email := c.GetString("user_email")
+		for _, admin := range cfg.AdminEmails {
+			if email != "" && strings.EqualFold(strings.TrimSpace(admin), email) {
+				c.Next()
+				return
+			}
+		}
+
+		utils.ErrorResponse(c, http.StatusForbidden, "Admin access required")
+		c.Abort()
+	}
+}
@@ -0,0 +1,17 @@
+-- Field-level history of crawled comics and chapters: one row per field whose
+-- ingested value differed from the stored one
+CREATE TABLE IF NOT EXISTS "mChangeLog" (
+    id BIGSERIAL PRIMARY KEY,
+    entity_type VARCHAR(20) NOT NULL, -- komik, chapter
+    entity_id UUID NOT NULL,
+    komik_id UUID NOT NULL, -- the comic (also for chapter changes); no FK so history outlives deletes
+    field VARCHAR(50) NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    job_id VARCHAR(255),
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- History of one comic, and feeds of one field (e.g. status changes) for notifications
+CREATE INDEX IF NOT EXISTS idx_mchangelog_komik_id ON "mChangeLog"(komik_id, changed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_mchangelog_field_changed_at ON "mChangeLog"(field, changed_at DESC);
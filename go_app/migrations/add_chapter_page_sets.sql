@@ -0,0 +1,22 @@
+-- Content hash of each chapter's current page set (its ordered page files),
+-- so a re-crawl with the same pages leaves "trChapter" alone, and a version
+-- that goes up whenever the upstream re-uploads the chapter
+ALTER TABLE "mChapter" ADD COLUMN IF NOT EXISTS page_set_hash CHAR(64);
+ALTER TABLE "mChapter" ADD COLUMN IF NOT EXISTS page_set_version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE "mChapter" ADD COLUMN IF NOT EXISTS page_set_at TIMESTAMP;
+
+-- Superseded page sets, kept for a retention period (30 days by default) as
+-- evidence of upstream re-uploads. The current set lives in "trChapter".
+CREATE TABLE IF NOT EXISTS "mChapterPageSet" (
+    id BIGSERIAL PRIMARY KEY,
+    id_chapter UUID NOT NULL REFERENCES "mChapter"(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL, -- 0 = pages stored before page sets were versioned
+    content_hash CHAR(64), -- NULL for version 0
+    page_urls TEXT[] NOT NULL,
+    job_id VARCHAR(255), -- job that replaced the set
+    valid_from TIMESTAMP, -- NULL for version 0
+    superseded_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (id_chapter, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_mchapterpageset_superseded_at ON "mChapterPageSet"(superseded_at DESC);
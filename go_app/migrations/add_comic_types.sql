@@ -0,0 +1,9 @@
+-- Comic-Type relationship (manga, manhwa, manhua, ...), saved by the crawler
+-- from each manga's taxonomy. Slugs of all taxonomy tables: add_taxonomy_slugs.sql
+CREATE TABLE IF NOT EXISTS "trType" (
+    id_komik UUID NOT NULL REFERENCES "mKomik"(id) ON DELETE CASCADE,
+    id_type UUID NOT NULL REFERENCES "mType"(id) ON DELETE CASCADE,
+    PRIMARY KEY (id_komik, id_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_trtype_id_type ON "trType"(id_type);
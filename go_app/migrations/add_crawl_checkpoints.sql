@@ -0,0 +1,21 @@
+-- Crawl checkpoints, one row per crawl job
+-- Lets interrupted crawls resume after a crash or redeploy without repeating work
+CREATE TABLE IF NOT EXISTS "mCrawlCheckpoint" (
+    job_id VARCHAR(255) PRIMARY KEY,
+    phase VARCHAR(50) NOT NULL,
+    current_page INTEGER DEFAULT 0,
+    end_page INTEGER DEFAULT -1,
+    total_processed INTEGER DEFAULT 0,
+    last_manga_id VARCHAR(255) DEFAULT '',
+    last_chapter_id VARCHAR(255) DEFAULT '',
+    estimated_total INTEGER DEFAULT 0,
+    success_count INTEGER DEFAULT 0,
+    error_count INTEGER DEFAULT 0,
+    stages JSONB,
+    start_time TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Latest checkpoint lookup (resume/status without a job ID)
+CREATE INDEX IF NOT EXISTS idx_mcrawlcheckpoint_updated_at ON "mCrawlCheckpoint"(updated_at DESC);
@@ -0,0 +1,20 @@
+-- Dead-letter table of items that failed for good during a crawl
+-- One row per item; repeated failures bump attempt_count and last_attempt_at
+CREATE TABLE IF NOT EXISTS "mCrawlFailure" (
+    id BIGSERIAL PRIMARY KEY,
+    entity_type VARCHAR(50) NOT NULL, -- manga_page, manga, chapter
+    external_id VARCHAR(255) NOT NULL,
+    job_id VARCHAR(255),
+    reason VARCHAR(50) NOT NULL,
+    error_message TEXT NOT NULL,
+    attempt_count INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, ignored, resolved
+    first_failed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMP,
+    UNIQUE (entity_type, external_id)
+);
+
+-- Listing and retrying failures by status and entity
+CREATE INDEX IF NOT EXISTS idx_mcrawlfailure_status_entity ON "mCrawlFailure"(status, entity_type);
+CREATE INDEX IF NOT EXISTS idx_mcrawlfailure_last_attempt_at ON "mCrawlFailure"(last_attempt_at DESC);
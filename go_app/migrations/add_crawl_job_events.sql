@@ -0,0 +1,13 @@
+-- Structured log of crawl jobs (progress ticks, info, warnings, errors)
+-- Streamed by GET /api/crawler/jobs/:id/events; the newest 2000 events per job are kept
+CREATE TABLE IF NOT EXISTS "mCrawlJobEvent" (
+    id BIGSERIAL PRIMARY KEY,
+    job_id VARCHAR(255) NOT NULL REFERENCES "mCrawlJob"(id) ON DELETE CASCADE,
+    level VARCHAR(20) NOT NULL, -- info, progress, warning, error
+    message TEXT NOT NULL,
+    data JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Reading a job's events after a given ID
+CREATE INDEX IF NOT EXISTS idx_mcrawljobevent_job_id ON "mCrawlJobEvent"(job_id, id);
@@ -0,0 +1,25 @@
+-- Crawl job queue shared by the API and cmd/crawler
+-- Workers claim queued jobs with SELECT ... FOR UPDATE SKIP LOCKED
+CREATE TABLE IF NOT EXISTS "mCrawlJob" (
+    id VARCHAR(255) PRIMARY KEY,
+    mode VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued', -- queued, running, succeeded, failed, cancelled
+    params JSONB NOT NULL DEFAULT '{}',
+    worker_id VARCHAR(255),
+    cancel_requested BOOLEAN NOT NULL DEFAULT false,
+    processed_items INTEGER NOT NULL DEFAULT 0,
+    success_items INTEGER NOT NULL DEFAULT 0,
+    failed_items INTEGER NOT NULL DEFAULT 0,
+    total_items INTEGER NOT NULL DEFAULT 0,
+    error_message TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    heartbeat_at TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Claiming the oldest queued job, listing by status, pruning old jobs
+CREATE INDEX IF NOT EXISTS idx_mcrawljob_status_created ON "mCrawlJob"(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_mcrawljob_created_at ON "mCrawlJob"(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_mcrawljob_finished_at ON "mCrawlJob"(finished_at);
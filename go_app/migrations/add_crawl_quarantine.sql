@@ -0,0 +1,20 @@
+-- Upstream records that failed validation and were not persisted, with the
+-- reason and the payload as received. One row per item; a record that fails
+-- again bumps occurrences and last_seen_at.
+CREATE TABLE IF NOT EXISTS "mCrawlQuarantine" (
+    id BIGSERIAL PRIMARY KEY,
+    entity_type VARCHAR(50) NOT NULL, -- manga, chapter, chapter_detail
+    external_id VARCHAR(255) NOT NULL, -- '' when the record has no usable ID
+    job_id VARCHAR(255),
+    reason TEXT NOT NULL,
+    payload JSONB,
+    occurrences INTEGER NOT NULL DEFAULT 1,
+    first_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (entity_type, external_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_mcrawlquarantine_last_seen_at ON "mCrawlQuarantine"(last_seen_at DESC);
+
+-- Per-job count of quarantined records
+ALTER TABLE "mCrawlJob" ADD COLUMN IF NOT EXISTS quarantined_items INTEGER NOT NULL DEFAULT 0;
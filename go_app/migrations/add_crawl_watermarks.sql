@@ -0,0 +1,11 @@
+-- High-water marks of incremental crawls: the newest upstream update seen per
+-- source and entity. An incremental manga crawl stops paginating once a full
+-- page is older than the mark (minus an overlap window).
+CREATE TABLE IF NOT EXISTS "mCrawlWatermark" (
+    source VARCHAR(50) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL, -- manga
+    high_water_mark TIMESTAMP NOT NULL,
+    job_id VARCHAR(255),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (source, entity_type)
+);
@@ -0,0 +1,37 @@
+-- Fuzzy duplicate detection for crawled manga
+-- Titles are compared normalized (lowercase, punctuation collapsed) with trigram similarity
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE OR REPLACE FUNCTION normalize_title(title TEXT) RETURNS TEXT AS $$
+    SELECT btrim(regexp_replace(lower(COALESCE(title, '')), '[^[:alnum:]]+', ' ', 'g'))
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE INDEX IF NOT EXISTS idx_mkomik_title_trgm ON "mKomik" USING GIN (normalize_title(title) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_mkomik_alternative_title_trgm ON "mKomik" USING GIN (normalize_title(alternative_title) gin_trgm_ops);
+
+-- Comics linked as the same work (e.g. separate releases) share a work_id
+ALTER TABLE "mKomik" ADD COLUMN IF NOT EXISTS work_id UUID;
+CREATE INDEX IF NOT EXISTS idx_mkomik_work_id ON "mKomik"(work_id);
+
+-- Review queue of ambiguous duplicate candidates: a newly crawled comic and
+-- an existing one it may duplicate
+CREATE TABLE IF NOT EXISTS "mKomikDuplicate" (
+    id BIGSERIAL PRIMARY KEY,
+    komik_id UUID REFERENCES "mKomik"(id) ON DELETE SET NULL,
+    candidate_id UUID NOT NULL REFERENCES "mKomik"(id) ON DELETE CASCADE,
+    external_id VARCHAR(255) NOT NULL, -- upstream manga ID of komik_id
+    title TEXT NOT NULL,
+    score REAL NOT NULL,
+    signals JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, merged, linked, distinct
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMP,
+    UNIQUE (external_id, candidate_id)
+);
+
+-- Admin who resolved the review (user ID, or service_role)
+ALTER TABLE "mKomikDuplicate" ADD COLUMN IF NOT EXISTS resolved_by VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_mkomikduplicate_status ON "mKomikDuplicate"(status, score DESC);
+-- Merged upstream IDs are resolved to the comic they were merged into
+CREATE INDEX IF NOT EXISTS idx_mkomikduplicate_merged ON "mKomikDuplicate"(external_id) WHERE status = 'merged';
@@ -0,0 +1,15 @@
+-- Last raw upstream payload per manga and per chapter, so fields the crawler
+-- does not map yet can be derived later without re-crawling (crawler mode "reprocess")
+CREATE TABLE IF NOT EXISTS "mRawPayload" (
+    entity_type VARCHAR(50) NOT NULL, -- manga, chapter (chapter list item), chapter_detail
+    external_id VARCHAR(255) NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    content_hash CHAR(64) NOT NULL, -- sha256 of the payload as received
+    fetched_at TIMESTAMP NOT NULL DEFAULT NOW(), -- last time the payload was received
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW(), -- last time its content_hash changed
+    PRIMARY KEY (entity_type, external_id)
+);
+
+-- Reprocessing the chapters of one manga
+CREATE INDEX IF NOT EXISTS idx_mrawpayload_manga_id ON "mRawPayload"((payload->>'manga_id')) WHERE entity_type <> 'manga';
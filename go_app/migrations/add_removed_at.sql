@@ -0,0 +1,9 @@
+-- Manga and chapters the upstream no longer lists (see Crawler.Reconcile).
+-- Removed rows are hidden from the public API; a crawl that sees them again
+-- clears removed_at.
+ALTER TABLE "mKomik" ADD COLUMN IF NOT EXISTS removed_at TIMESTAMP;
+ALTER TABLE "mChapter" ADD COLUMN IF NOT EXISTS removed_at TIMESTAMP;
+
+-- Admin report of removed rows
+CREATE INDEX IF NOT EXISTS idx_mkomik_removed_at ON "mKomik"(removed_at DESC) WHERE removed_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_mchapter_removed_at ON "mChapter"(removed_at DESC) WHERE removed_at IS NOT NULL;
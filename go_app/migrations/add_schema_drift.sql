@@ -0,0 +1,11 @@
+-- Results of upstream schema drift checks: sample responses compared against
+-- the fields our structs expect. report holds every target's added, removed
+-- and retyped fields.
+CREATE TABLE IF NOT EXISTS "mSchemaDrift" (
+    id BIGSERIAL PRIMARY KEY,
+    checked_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    drifted BOOLEAN NOT NULL DEFAULT FALSE,
+    report JSONB NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_mschemadrift_checked_at ON "mSchemaDrift"(checked_at DESC);
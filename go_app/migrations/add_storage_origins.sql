@@ -0,0 +1,17 @@
+-- Hosts chapter page images are served from: base_url at full quality and
+-- base_url_low (an image proxy prefix) at low quality, as given by the
+-- upstream chapter detail
+CREATE TABLE IF NOT EXISTS "mStorageOrigin" (
+    id SERIAL PRIMARY KEY,
+    base_url TEXT NOT NULL,
+    base_url_low TEXT NOT NULL DEFAULT '', -- empty = no low quality variant
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (base_url, base_url_low)
+);
+
+-- Pages keep their path relative to the origin, so the API can serve either
+-- quality (and follow a CDN move) without rewriting URLs. page_url keeps the
+-- full quality URL; pages crawled before this have no path or origin until
+-- their chapter is crawled again.
+ALTER TABLE "trChapter" ADD COLUMN IF NOT EXISTS page_path TEXT;
+ALTER TABLE "trChapter" ADD COLUMN IF NOT EXISTS id_storage_origin INTEGER REFERENCES "mStorageOrigin"(id);
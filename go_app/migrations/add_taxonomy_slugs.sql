@@ -0,0 +1,14 @@
+-- Upstream slugs on master data tables
+-- Manga ingestion creates missing genres, authors, artists, formats and types
+-- from the manga's taxonomy, keeping the upstream slug
+ALTER TABLE "mGenre" ADD COLUMN IF NOT EXISTS slug VARCHAR(255);
+ALTER TABLE "mAuthor" ADD COLUMN IF NOT EXISTS slug VARCHAR(255);
+ALTER TABLE "mArtist" ADD COLUMN IF NOT EXISTS slug VARCHAR(255);
+ALTER TABLE "mFormat" ADD COLUMN IF NOT EXISTS slug VARCHAR(255);
+ALTER TABLE "mType" ADD COLUMN IF NOT EXISTS slug VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_mgenre_slug ON "mGenre"(slug);
+CREATE INDEX IF NOT EXISTS idx_mauthor_slug ON "mAuthor"(slug);
+CREATE INDEX IF NOT EXISTS idx_martist_slug ON "mArtist"(slug);
+CREATE INDEX IF NOT EXISTS idx_mformat_slug ON "mFormat"(slug);
+CREATE INDEX IF NOT EXISTS idx_mtype_slug ON "mType"(slug);
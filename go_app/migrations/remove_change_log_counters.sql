@@ -0,0 +1,5 @@
+-- View, vote and bookmark counts and rank are no longer logged to "mChangeLog"
+-- (they changed on nearly every crawl); drop the rows logged before.
+-- Run once after add_change_log.sql.
+DELETE FROM "mChangeLog"
+WHERE entity_type = 'komik' AND field IN ('view_count', 'vote_count', 'bookmark_count', 'rank');
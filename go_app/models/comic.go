@@ -30,6 +30,7 @@ type ComicWithDetails struct {
 	Authors         []Author        `json:"authors,omitempty"`
 	Artists         []Artist        `json:"artists,omitempty"`
 	Formats         []Format        `json:"formats,omitempty"`
+	Types           []Type          `json:"types,omitempty"`
 }
 
 // ComicComplete represents complete comic response format (old)
@@ -64,6 +65,7 @@ type ComicCompleteData struct {
 	Authors []Author `json:"authors"`
 	Artists []Artist `json:"artists"`
 	Formats []Format `json:"formats"`
+	Types   []Type   `json:"types"`
 }
 
 // UserDataComplete - EXACT user data format from Next.js /api/comics/[id]/complete
@@ -105,6 +107,13 @@ type Format struct {
 	Name string `json:"name" db:"name"`
 }
 
+// Type represents the mType table (e.g. Manga, Manhwa, Manhua)
+type Type struct {
+	ID   string  `json:"id" db:"id"`
+	Name string  `json:"name" db:"name"`
+	Slug *string `json:"slug" db:"slug"`
+}
+
 // PopularComic represents popular comic from mPopular table
 type PopularComic struct {
 	Comic
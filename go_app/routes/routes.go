@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -50,7 +51,12 @@ func Setup(router *gin.Engine, db *database.DB, cfg *config.Config) {
 			},
 		}
 		crawlerInstance := crawler.New(db, crawlerConfig)
-		crawlerHandler = crawlerHandlers.NewCrawlerHandler(crawlerInstance)
+		crawlerHandler = crawlerHandlers.NewCrawlerHandler(crawlerInstance,
+			append([]string{crawlerConfig.BaseURL}, cfg.CrawlerBaseURLs...))
+
+		// Run queued crawl jobs in this process (cmd/crawler --mode=worker can run more)
+		crawlWorker := crawler.NewWorker(crawlerInstance, crawler.WorkerConfig{Concurrency: 2})
+		go crawlWorker.Run(context.Background())
 
 		// Initialize auto-update service
 		autoUpdateService := autoupdate.NewAutoUpdateService(db, crawlerInstance)
@@ -153,6 +159,7 @@ func Setup(router *gin.Engine, db *database.DB, cfg *config.Config) {
 		// Crawler routes (admin only)
 		if crawlerHandler != nil {
 			crawler := v1.Group("/crawler")
+			crawler.Use(middleware.AuthRequired(cfg), middleware.AdminRequired(cfg))
 			{
 				crawler.POST("/start", crawlerHandler.StartCrawling)
 				crawler.GET("/status", crawlerHandler.GetCrawlStatus)
@@ -160,18 +167,35 @@ func Setup(router *gin.Engine, db *database.DB, cfg *config.Config) {
 				crawler.POST("/resume", crawlerHandler.ResumeCrawling)
 				crawler.GET("/history", crawlerHandler.GetCrawlHistory)
 				crawler.GET("/jobs/:id", crawlerHandler.GetJobStatus)
+				crawler.GET("/jobs/:id/events", crawlerHandler.StreamJobEvents)
+				crawler.GET("/failures", crawlerHandler.GetFailures)
+				crawler.POST("/failures/retry", crawlerHandler.RetryFailures)
+				crawler.POST("/failures/ignore", crawlerHandler.IgnoreFailures)
+				crawler.GET("/duplicates", crawlerHandler.GetDuplicates)
+				crawler.POST("/duplicates/:id/merge", crawlerHandler.MergeDuplicate)
+				crawler.POST("/duplicates/:id/link", crawlerHandler.LinkDuplicate)
+				crawler.POST("/duplicates/:id/distinct", crawlerHandler.MarkDuplicateDistinct)
+				crawler.GET("/quarantine", crawlerHandler.GetQuarantine)
+				crawler.GET("/changes", crawlerHandler.GetChanges)
+				crawler.GET("/comics/:id/changes", crawlerHandler.GetComicChanges)
+				crawler.GET("/removed", crawlerHandler.GetRemoved)
+				crawler.GET("/page-sets", crawlerHandler.GetPageSetChanges)
+				crawler.GET("/chapters/:id/page-sets", crawlerHandler.GetChapterPageSets)
 			}
 		}
 
 		// Auto-Update routes (admin only)
 		if autoUpdateHandler != nil {
 			autoUpdate := v1.Group("/auto-update")
+			autoUpdate.Use(middleware.AuthRequired(cfg), middleware.AdminRequired(cfg))
 			{
 				autoUpdate.POST("/start", autoUpdateHandler.StartAutoUpdate)
 				autoUpdate.POST("/stop", autoUpdateHandler.StopAutoUpdate)
 				autoUpdate.GET("/status", autoUpdateHandler.GetAutoUpdateStatus)
 				autoUpdate.PUT("/config", autoUpdateHandler.UpdateAutoUpdateConfig)
 				autoUpdate.POST("/trigger", autoUpdateHandler.TriggerManualUpdate)
+				autoUpdate.GET("/drift", autoUpdateHandler.GetDriftReport)
+				autoUpdate.POST("/drift/check", autoUpdateHandler.CheckDrift)
 			}
 		}
 	}
@@ -119,11 +119,11 @@ func (s *BookmarkService) GetDetailedBookmarks(userID string, page, limit int) (
 		LEFT JOIN LATERAL (
 			SELECT id, chapter_number, release_date, created_date
 			FROM "mChapter" c
-			WHERE c.id_komik = k.id
+			WHERE c.id_komik = k.id AND c.removed_at IS NULL
 			ORDER BY c.release_date DESC, c.chapter_number DESC
 			LIMIT 1
 		) lc ON true
-		WHERE b.id_user = $1
+		WHERE b.id_user = $1 AND k.removed_at IS NULL
 		ORDER BY b.created_at DESC
 		LIMIT $2 OFFSET $3
 	`
@@ -168,7 +168,11 @@ func (s *BookmarkService) GetDetailedBookmarks(userID string, page, limit int) (
 	}
 
 	// Get total count
-	countQuery := `SELECT COUNT(*) FROM "trUserBookmark" WHERE id_user = $1`
+	countQuery := `
+		SELECT COUNT(*) FROM "trUserBookmark" b
+		JOIN "mKomik" k ON b.id_komik = k.id
+		WHERE b.id_user = $1 AND k.removed_at IS NULL
+	`
 	var total int
 	err = s.GetDB().QueryRow(ctx, countQuery, userID).Scan(&total)
 	if err != nil {
@@ -14,12 +14,14 @@ import (
 // ChapterService provides chapter-related functionality
 type ChapterService struct {
 	*BaseService
+	urlService *URLService
 }
 
 // NewChapterService creates a new chapter service
 func NewChapterService(db *database.DB) *ChapterService {
 	return &ChapterService{
 		BaseService: NewBaseService(db),
+		urlService:  NewURLService(),
 	}
 }
 
@@ -40,7 +42,7 @@ func (s *ChapterService) GetChapterDetails(id string) (*models.ChapterDetailsRes
 			k.id, k.title, k.alternative_title, k.cover_image_url
 		FROM "mChapter" c
 		JOIN "mKomik" k ON c.id_komik = k.id
-		WHERE c.id = $1
+		WHERE c.id = $1 AND c.removed_at IS NULL AND k.removed_at IS NULL
 	`
 
 	var chapter models.ChapterWithComic
@@ -76,7 +78,7 @@ func (s *ChapterService) GetChapterDetails(id string) (*models.ChapterDetailsRes
 	prevQuery := `
 		SELECT id, chapter_number
 		FROM "mChapter"
-		WHERE id_komik = $1 AND chapter_number < $2
+		WHERE id_komik = $1 AND chapter_number < $2 AND removed_at IS NULL
 		ORDER BY chapter_number DESC
 		LIMIT 1
 	`
@@ -92,7 +94,7 @@ func (s *ChapterService) GetChapterDetails(id string) (*models.ChapterDetailsRes
 	nextQuery := `
 		SELECT id, chapter_number
 		FROM "mChapter"
-		WHERE id_komik = $1 AND chapter_number > $2
+		WHERE id_komik = $1 AND chapter_number > $2 AND removed_at IS NULL
 		ORDER BY chapter_number ASC
 		LIMIT 1
 	`
@@ -128,8 +130,9 @@ func (s *ChapterService) GetChapterDetails(id string) (*models.ChapterDetailsRes
 	return result, nil
 }
 
-// GetCompleteChapterDetails - EXACT COPY from Next.js /api/chapters/[id]/complete/route.ts
-func (s *ChapterService) GetCompleteChapterDetails(id string, userID *string) (*models.ChapterCompleteResponse, error) {
+// GetCompleteChapterDetails - EXACT COPY from Next.js /api/chapters/[id]/complete/route.ts,
+// with page URLs at low quality if lowQuality is set
+func (s *ChapterService) GetCompleteChapterDetails(id string, userID *string, lowQuality bool) (*models.ChapterCompleteResponse, error) {
 	ctx, cancel := s.WithTimeout(30 * time.Second)
 	defer cancel()
 
@@ -146,7 +149,7 @@ func (s *ChapterService) GetCompleteChapterDetails(id string, userID *string) (*
 			k.id, k.title, k.alternative_title, k.cover_image_url
 		FROM "mChapter" c
 		JOIN "mKomik" k ON c.id_komik = k.id
-		WHERE c.id = $1
+		WHERE c.id = $1 AND c.removed_at IS NULL AND k.removed_at IS NULL
 	`
 
 	var chapterData models.ChapterWithComic
@@ -168,31 +171,13 @@ func (s *ChapterService) GetCompleteChapterDetails(id string, userID *string) (*
 	}
 
 	// Fetch pages for the chapter - EXACTLY like Next.js lines 58-63
-	pagesQuery := `
-		SELECT id_chapter, page_number, page_url
-		FROM "trChapter"
-		WHERE id_chapter = $1
-		ORDER BY page_number ASC
-	`
-
-	pagesRows, err := s.GetDB().Query(ctx, pagesQuery, id)
+	pagesData, err := s.loadChapterPages(ctx, id, lowQuality)
 	if err != nil {
 		s.LogError(err, "Failed to fetch pages", logrus.Fields{
 			"chapter_id": id,
 		})
 		return nil, err
 	}
-	defer pagesRows.Close()
-
-	var pagesData []models.ChapterPage
-	for pagesRows.Next() {
-		var page models.ChapterPage
-		err := pagesRows.Scan(&page.IDChapter, &page.PageNumber, &page.PageURL)
-		if err != nil {
-			continue
-		}
-		pagesData = append(pagesData, page)
-	}
 
 	// Fetch next and previous chapters for navigation - EXACTLY like Next.js lines 69-101
 	var prevChapter *models.ChapterNav
@@ -202,7 +187,7 @@ func (s *ChapterService) GetCompleteChapterDetails(id string, userID *string) (*
 	prevQuery := `
 		SELECT id, chapter_number
 		FROM "mChapter"
-		WHERE id_komik = $1 AND chapter_number < $2
+		WHERE id_komik = $1 AND chapter_number < $2 AND removed_at IS NULL
 		ORDER BY chapter_number DESC
 		LIMIT 1
 	`
@@ -218,7 +203,7 @@ func (s *ChapterService) GetCompleteChapterDetails(id string, userID *string) (*
 	nextQuery := `
 		SELECT id, chapter_number
 		FROM "mChapter"
-		WHERE id_komik = $1 AND chapter_number > $2
+		WHERE id_komik = $1 AND chapter_number > $2 AND removed_at IS NULL
 		ORDER BY chapter_number ASC
 		LIMIT 1
 	`
@@ -307,20 +292,23 @@ func (s *ChapterService) GetCompleteChapterDetails(id string, userID *string) (*
 	return result, nil
 }
 
-// GetChapterPages - EXACT COPY from Next.js /api/chapters/[id]/pages/route.ts
-func (s *ChapterService) GetChapterPages(id string) (*models.ChapterPagesResponse, error) {
+// GetChapterPages - EXACT COPY from Next.js /api/chapters/[id]/pages/route.ts,
+// with page URLs at low quality if lowQuality is set
+func (s *ChapterService) GetChapterPages(id string, lowQuality bool) (*models.ChapterPagesResponse, error) {
 	ctx, cancel := s.WithTimeout(30 * time.Second)
 	defer cancel()
 
 	s.LogInfo("Getting chapter pages", logrus.Fields{
-		"chapter_id": id,
+		"chapter_id":  id,
+		"low_quality": lowQuality,
 	})
 
 	// First, verify that the chapter exists - EXACTLY like Next.js lines 23-27
 	chapterQuery := `
-		SELECT id, chapter_number, id_komik
-		FROM "mChapter"
-		WHERE id = $1
+		SELECT c.id, c.chapter_number, c.id_komik
+		FROM "mChapter" c
+		JOIN "mKomik" k ON c.id_komik = k.id
+		WHERE c.id = $1 AND c.removed_at IS NULL AND k.removed_at IS NULL
 	`
 
 	var chapter models.ChapterBasicInfo
@@ -338,32 +326,13 @@ func (s *ChapterService) GetChapterPages(id string) (*models.ChapterPagesRespons
 	}
 
 	// Fetch pages for the chapter, sorted by page number - EXACTLY like Next.js lines 43-47
-	pagesQuery := `
-		SELECT id_chapter, page_number, page_url
-		FROM "trChapter"
-		WHERE id_chapter = $1
-		ORDER BY page_number ASC
-	`
-
-	pagesRows, err := s.GetDB().Query(ctx, pagesQuery, id)
+	pages, err := s.loadChapterPages(ctx, id, lowQuality)
 	if err != nil {
 		s.LogError(err, "Failed to get chapter pages", logrus.Fields{
 			"chapter_id": id,
 		})
 		return nil, err
 	}
-	defer pagesRows.Close()
-
-	var pages []models.ChapterPage
-	for pagesRows.Next() {
-		var page models.ChapterPage
-		err := pagesRows.Scan(&page.IDChapter, &page.PageNumber, &page.PageURL)
-		if err != nil {
-			s.LogError(err, "Failed to scan page row", nil)
-			continue
-		}
-		pages = append(pages, page)
-	}
 
 	// Fetch comic information - EXACTLY like Next.js lines 54-58
 	comicQuery := `
@@ -402,6 +371,49 @@ func (s *ChapterService) GetChapterPages(id string) (*models.ChapterPagesRespons
 	return result, nil
 }
 
+// loadChapterPages loads the pages of a chapter sorted by page number, with
+// URLs built from each page's storage origin at the requested quality.
+// Pages saved before they had an origin keep their stored URL, served at low
+// quality through the default origin when it is theirs.
+func (s *ChapterService) loadChapterPages(ctx context.Context, chapterID string, lowQuality bool) ([]models.ChapterPage, error) {
+	query := `
+		SELECT p.id_chapter, p.page_number, p.page_url, p.page_path, o.base_url, o.base_url_low
+		FROM "trChapter" p
+		LEFT JOIN "mStorageOrigin" o ON o.id = p.id_storage_origin
+		WHERE p.id_chapter = $1
+		ORDER BY p.page_number ASC
+	`
+
+	rows, err := s.GetDB().Query(ctx, query, chapterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pages []models.ChapterPage
+	for rows.Next() {
+		var page models.ChapterPage
+		var pagePath, baseURL, baseURLLow *string
+		if err := rows.Scan(&page.IDChapter, &page.PageNumber, &page.PageURL, &pagePath, &baseURL, &baseURLLow); err != nil {
+			s.LogError(err, "Failed to scan page row", nil)
+			continue
+		}
+
+		if pagePath != nil && baseURL != nil {
+			origin := StorageOrigin{BaseURL: *baseURL}
+			if baseURLLow != nil {
+				origin.BaseURLLow = *baseURLLow
+			}
+			page.PageURL = s.urlService.GetOriginPageURL(origin, *pagePath, lowQuality)
+		} else if lowQuality {
+			page.PageURL = s.urlService.GetChapterPageURL(s.urlService.ConvertToRelativePath(page.PageURL), true)
+		}
+		pages = append(pages, page)
+	}
+
+	return pages, rows.Err()
+}
+
 // getChapterPages - EXACT COPY from Next.js: use "trChapter" table, not "mPage"
 func (s *ChapterService) getChapterPages(ctx context.Context, chapterID string) ([]models.Page, error) {
 	query := `
@@ -442,7 +454,7 @@ func (s *ChapterService) getChapterNavigation(ctx context.Context, comicID strin
 	nextQuery := `
 		SELECT id, chapter_number
 		FROM "mChapter"
-		WHERE id_komik = $1 AND chapter_number > $2
+		WHERE id_komik = $1 AND chapter_number > $2 AND removed_at IS NULL
 		ORDER BY chapter_number ASC
 		LIMIT 1
 	`
@@ -460,7 +472,7 @@ func (s *ChapterService) getChapterNavigation(ctx context.Context, comicID strin
 	prevQuery := `
 		SELECT id, chapter_number
 		FROM "mChapter"
-		WHERE id_komik = $1 AND chapter_number < $2
+		WHERE id_komik = $1 AND chapter_number < $2 AND removed_at IS NULL
 		ORDER BY chapter_number DESC
 		LIMIT 1
 	`
@@ -524,7 +536,7 @@ func (s *ChapterService) GetAdjacentChapters(chapterID string, limit int) (*mode
 	currentChapterQuery := `
 		SELECT id, id_komik, chapter_number
 		FROM "mChapter"
-		WHERE id = $1
+		WHERE id = $1 AND removed_at IS NULL
 	`
 
 	var currentChapter struct {
@@ -547,7 +559,7 @@ func (s *ChapterService) GetAdjacentChapters(chapterID string, limit int) (*mode
 	prevQuery := `
 		SELECT id, chapter_number, release_date, thumbnail_image_url, created_date
 		FROM "mChapter"
-		WHERE id_komik = $1 AND chapter_number < $2
+		WHERE id_komik = $1 AND chapter_number < $2 AND removed_at IS NULL
 		ORDER BY chapter_number DESC
 		LIMIT $3
 	`
@@ -577,7 +589,7 @@ func (s *ChapterService) GetAdjacentChapters(chapterID string, limit int) (*mode
 	nextQuery := `
 		SELECT id, chapter_number, release_date, thumbnail_image_url, created_date
 		FROM "mChapter"
-		WHERE id_komik = $1 AND chapter_number > $2
+		WHERE id_komik = $1 AND chapter_number > $2 AND removed_at IS NULL
 		ORDER BY chapter_number ASC
 		LIMIT $3
 	`
@@ -47,12 +47,12 @@ func (s *ComicService) GetComics(page, limit int, search, genre, country, sort,
 			k.id, k.title, k.alternative_title, k.description, k.status,
 			k.country_id, k.view_count, k.vote_count, k.bookmark_count,
 			k.cover_image_url, k.created_date, k.rank, k.release_year,
-			(SELECT COUNT(*) FROM "mChapter" c WHERE c.id_komik = k.id) as chapter_count
+			(SELECT COUNT(*) FROM "mChapter" c WHERE c.id_komik = k.id AND c.removed_at IS NULL) as chapter_count
 		FROM "mKomik" k
 	`
 
-	// Build WHERE conditions
-	var conditions []string
+	// Build WHERE conditions - comics removed upstream are hidden
+	conditions := []string{"k.removed_at IS NULL"}
 	var args []interface{}
 	argIndex := 1
 
@@ -81,10 +81,8 @@ func (s *ComicService) GetComics(page, limit int, search, genre, country, sort,
 		argIndex++
 	}
 
-	// Add WHERE clause if conditions exist
-	if len(conditions) > 0 {
-		baseQuery += " WHERE " + strings.Join(conditions, " AND ")
-	}
+	// Add WHERE clause
+	baseQuery += " WHERE " + strings.Join(conditions, " AND ")
 
 	// Add ORDER BY
 	orderClause := "ORDER BY "
@@ -150,7 +148,7 @@ func (s *ComicService) GetComics(page, limit int, search, genre, country, sort,
 			k.id, k.title, k.alternative_title, k.description, k.status,
 			k.country_id, k.view_count, k.vote_count, k.bookmark_count,
 			k.cover_image_url, k.created_date, k.rank, k.release_year,
-			(SELECT COUNT(*) FROM "mChapter" c WHERE c.id_komik = k.id) as chapter_count`, "SELECT COUNT(DISTINCT k.id)", 1)
+			(SELECT COUNT(*) FROM "mChapter" c WHERE c.id_komik = k.id AND c.removed_at IS NULL) as chapter_count`, "SELECT COUNT(DISTINCT k.id)", 1)
 
 	var total int
 	countArgs := args[:len(args)-2] // Remove LIMIT and OFFSET args
@@ -244,8 +242,9 @@ func (s *ComicService) GetHomeComics(page, limit int, sort, order string) ([]mod
 			k.id, k.title, k.alternative_title, k.description, k.status,
 			k.country_id, k.view_count, k.vote_count, k.bookmark_count,
 			k.cover_image_url, k.created_date, k.rank, k.release_year,
-			(SELECT COUNT(*) FROM "mChapter" c WHERE c.id_komik = k.id) as chapter_count
+			(SELECT COUNT(*) FROM "mChapter" c WHERE c.id_komik = k.id AND c.removed_at IS NULL) as chapter_count
 		FROM "mKomik" k
+		WHERE k.removed_at IS NULL
 		ORDER BY k.created_date DESC
 		LIMIT $1 OFFSET $2
 	`
@@ -274,7 +273,7 @@ func (s *ComicService) GetHomeComics(page, limit int, sort, order string) ([]mod
 	}
 
 	// Get total count
-	countQuery := `SELECT COUNT(*) FROM "mKomik"`
+	countQuery := `SELECT COUNT(*) FROM "mKomik" WHERE removed_at IS NULL`
 
 	var total int
 	err = s.GetDB().QueryRow(ctx, countQuery).Scan(&total)
@@ -318,7 +317,7 @@ func (s *ComicService) loadLatestChapters(ctx context.Context, comics []models.C
 		SELECT DISTINCT ON (c.id_komik)
 			c.id_komik, c.id, c.chapter_number, c.release_date, c.thumbnail_image_url, c.created_date
 		FROM "mChapter" c
-		WHERE c.id_komik = ANY($1)
+		WHERE c.id_komik = ANY($1) AND c.removed_at IS NULL
 		ORDER BY c.id_komik, c.release_date DESC, c.chapter_number DESC
 	`
 
@@ -366,7 +365,7 @@ func (s *ComicService) GetPopularComics(typeParam string, limit int) ([]models.P
 			k.status, k.created_date, p.type
 		FROM "mPopular" p
 		JOIN "mKomik" k ON p.id_komik = k.id
-		WHERE p.type = $1
+		WHERE p.type = $1 AND k.removed_at IS NULL
 		LIMIT $2
 	`
 
@@ -26,6 +26,7 @@ func (s *ComicService) GetRecommendedComics(limit int) ([]models.RecommendedComi
 			k.status, k.created_date
 		FROM "mRecomed" r
 		JOIN "mKomik" k ON r.id_komik = k.id
+		WHERE k.removed_at IS NULL
 		ORDER BY k.created_date DESC
 		LIMIT $1
 	`
@@ -74,9 +75,9 @@ func (s *ComicService) GetComicDetails(id string) (*models.ComicWithDetails, err
 			k.id, k.title, k.alternative_title, k.description, k.status,
 			k.country_id, k.view_count, k.vote_count, k.bookmark_count,
 			k.cover_image_url, k.created_date, k.rank, k.release_year,
-			(SELECT COUNT(*) FROM "mChapter" c WHERE c.id_komik = k.id) as chapter_count
+			(SELECT COUNT(*) FROM "mChapter" c WHERE c.id_komik = k.id AND c.removed_at IS NULL) as chapter_count
 		FROM "mKomik" k
-		WHERE k.id = $1
+		WHERE k.id = $1 AND k.removed_at IS NULL
 	`
 
 	var comic models.ComicWithDetails
@@ -103,7 +104,7 @@ func (s *ComicService) GetComicDetails(id string) (*models.ComicWithDetails, err
 		})
 	}
 
-	// Load authors, artists, formats
+	// Load authors, artists, formats, types
 	if err := s.loadComicRelations(ctx, &comics[0]); err != nil {
 		s.LogError(err, "Failed to load comic relations", logrus.Fields{
 			"comic_id": id,
@@ -134,7 +135,7 @@ func (s *ComicService) GetCompleteComicDetails(id string, userID *string) (*mode
 			k.country_id, k.view_count, k.vote_count, k.bookmark_count,
 			k.cover_image_url, k.created_date, k.rank, k.release_year
 		FROM "mKomik" k
-		WHERE k.id = $1
+		WHERE k.id = $1 AND k.removed_at IS NULL
 	`
 
 	var comic models.ComicCompleteData
@@ -154,7 +155,7 @@ func (s *ComicService) GetCompleteComicDetails(id string, userID *string) (*mode
 		return nil, err
 	}
 
-	// Load trGenre, trAuthor, trArtist, trFormat - EXACTLY like Next.js lines 36-47
+	// Load trGenre, trAuthor, trArtist, trFormat - EXACTLY like Next.js lines 36-47, plus trType
 	if err := s.loadComicRelationsForComplete(ctx, &comic); err != nil {
 		s.LogError(err, "Failed to load comic relations", nil)
 		// Continue even if relations fail
@@ -242,7 +243,7 @@ func (s *ComicService) GetComicChapters(id string, page, limit int, sort, order
 	})
 
 	// First, verify comic exists and get basic info
-	comicQuery := `SELECT id, title FROM "mKomik" WHERE id = $1`
+	comicQuery := `SELECT id, title FROM "mKomik" WHERE id = $1 AND removed_at IS NULL`
 	var comic models.ComicBasic
 	err := s.GetDB().QueryRow(ctx, comicQuery, id).Scan(&comic.ID, &comic.Title)
 	if err != nil {
@@ -278,7 +279,7 @@ func (s *ComicService) GetComicChapters(id string, page, limit int, sort, order
 			c.id, c.id_komik, c.chapter_number, c.release_date,
 			c.rating, c.view_count, c.vote_count, c.thumbnail_image_url, c.created_date
 		FROM "mChapter" c
-		WHERE c.id_komik = $1
+		WHERE c.id_komik = $1 AND c.removed_at IS NULL
 		` + orderClause + `
 		LIMIT $2 OFFSET $3
 	`
@@ -308,7 +309,7 @@ func (s *ComicService) GetComicChapters(id string, page, limit int, sort, order
 	}
 
 	// Get total count
-	countQuery := `SELECT COUNT(*) FROM "mChapter" WHERE id_komik = $1`
+	countQuery := `SELECT COUNT(*) FROM "mChapter" WHERE id_komik = $1 AND removed_at IS NULL`
 	var total int
 	err = s.GetDB().QueryRow(ctx, countQuery, id).Scan(&total)
 	if err != nil {
@@ -529,6 +530,13 @@ func (s *ComicService) loadComicRelationsForComplete(ctx context.Context, comic
 	}
 	comic.Formats = formats
 
+	// Load trType
+	types, err := s.loadComicTypes(ctx, comic.ID)
+	if err != nil {
+		return err
+	}
+	comic.Types = types
+
 	return nil
 }
 
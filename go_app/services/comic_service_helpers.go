@@ -5,7 +5,7 @@ import (
 	"baca-komik-api/models"
 )
 
-// loadComicRelations loads authors, artists, and formats for a comic
+// loadComicRelations loads authors, artists, formats and types for a comic
 func (s *ComicService) loadComicRelations(ctx context.Context, comic *models.ComicWithDetails) error {
 	// Load authors
 	authorsQuery := `
@@ -82,9 +82,43 @@ func (s *ComicService) loadComicRelations(ctx context.Context, comic *models.Com
 	}
 	comic.Formats = formats
 
+	// Load types
+	types, err := s.loadComicTypes(ctx, comic.ID)
+	if err != nil {
+		return err
+	}
+	comic.Types = types
+
 	return nil
 }
 
+// loadComicTypes loads the types (manga, manhwa, manhua, ...) of a comic
+func (s *ComicService) loadComicTypes(ctx context.Context, comicID string) ([]models.Type, error) {
+	typesQuery := `
+		SELECT t.id, t.name, t.slug
+		FROM "trType" tt
+		JOIN "mType" t ON tt.id_type = t.id
+		WHERE tt.id_komik = $1
+		ORDER BY t.name
+	`
+
+	rows, err := s.GetDB().Query(ctx, typesQuery, comicID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var types []models.Type
+	for rows.Next() {
+		var typ models.Type
+		if err := rows.Scan(&typ.ID, &typ.Name, &typ.Slug); err != nil {
+			continue
+		}
+		types = append(types, typ)
+	}
+	return types, nil
+}
+
 // loadUserComicData loads user-specific data for a comic
 func (s *ComicService) loadUserComicData(ctx context.Context, comicID, userID string) (*models.UserData, error) {
 	userData := &models.UserData{}
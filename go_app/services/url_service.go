@@ -55,6 +55,28 @@ func (u *URLService) GetChapterPageURL(relativePath string, isLowQuality bool) s
 	return u.GetFullImageURL(relativePath, isLowQuality)
 }
 
+// StorageOrigin is a host chapter pages are stored on (mStorageOrigin), with
+// its full and low quality base URLs
+type StorageOrigin struct {
+	BaseURL    string
+	BaseURLLow string
+}
+
+// GetOriginPageURL constructs a chapter page URL from its storage origin and
+// its path relative to it. Origins without a low quality URL serve full quality.
+func (u *URLService) GetOriginPageURL(origin StorageOrigin, relativePath string, isLowQuality bool) string {
+	baseURL := origin.BaseURL
+	if isLowQuality && origin.BaseURLLow != "" {
+		baseURL = origin.BaseURLLow
+	}
+
+	// Ensure relative path starts with /
+	if !strings.HasPrefix(relativePath, "/") {
+		relativePath = "/" + relativePath
+	}
+	return strings.TrimSuffix(baseURL, "/") + relativePath
+}
+
 // UpdateBaseURL updates the base URL (for when external service changes)
 func (u *URLService) UpdateBaseURL(newBaseURL, newBaseURLLow string) {
 	u.baseURL = newBaseURL